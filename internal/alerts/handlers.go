@@ -0,0 +1,154 @@
+package alerts
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"windz/internal/auth"
+)
+
+// RegisterHandlers registers the alert HTTP handlers. When adminToken is set, creating,
+// replacing and deleting rules require it as a bearer token.
+func RegisterHandlers(mux *http.ServeMux, mgr Manager, adminToken string) {
+	mux.HandleFunc("/api/alerts", handleAlerts(mgr))
+	mux.HandleFunc("/api/alerts/events", handleEvents(mgr))
+	mux.HandleFunc("/api/alerts/rules", auth.RequireTokenForChanges(adminToken, handleRules(mgr)))
+	mux.HandleFunc("/api/alerts/rules/", auth.RequireTokenForChanges(adminToken, handleRule(mgr)))
+}
+
+// handleAlerts lists rules with their state (?status=active to filter)
+func handleAlerts(mgr Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		alerts := mgr.GetAlerts()
+		if status := r.URL.Query().Get("status"); status != "" {
+			filtered := make([]Alert, 0, len(alerts))
+			for _, alert := range alerts {
+				if alert.State.Status == status {
+					filtered = append(filtered, alert)
+				}
+			}
+			alerts = filtered
+		}
+
+		if err := json.NewEncoder(w).Encode(alerts); err != nil {
+			log.Printf("Error encoding alerts response: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// handleEvents lists recent fired and cleared events (?limit=n)
+func handleEvents(mgr Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		limit := 50
+		if value := r.URL.Query().Get("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "Invalid limit", http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+
+		if err := json.NewEncoder(w).Encode(mgr.GetEvents(limit)); err != nil {
+			log.Printf("Error encoding alert events response: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// handleRules lists rules (GET) or creates one (POST)
+func handleRules(mgr Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.Method {
+		case http.MethodGet:
+			if err := json.NewEncoder(w).Encode(mgr.GetRules()); err != nil {
+				log.Printf("Error encoding alert rules response: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			}
+		case http.MethodPost:
+			var rule Rule
+			if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+				http.Error(w, "Invalid rule: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if rule.ID != "" {
+				if _, exists := mgr.GetRule(rule.ID); exists {
+					http.Error(w, "Rule already exists", http.StatusConflict)
+					return
+				}
+			}
+			saveRule(w, mgr, rule, http.StatusCreated)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// handleRule reads (GET), replaces (PUT) or deletes (DELETE) a single rule
+func handleRule(mgr Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		ruleID := strings.TrimPrefix(r.URL.Path, "/api/alerts/rules/")
+		if ruleID == "" {
+			http.Error(w, "Rule ID required", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			rule, exists := mgr.GetRule(ruleID)
+			if !exists {
+				http.Error(w, "Rule not found", http.StatusNotFound)
+				return
+			}
+			if err := json.NewEncoder(w).Encode(rule); err != nil {
+				log.Printf("Error encoding alert rule response: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			}
+		case http.MethodPut:
+			var rule Rule
+			if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+				http.Error(w, "Invalid rule: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			rule.ID = ruleID
+			saveRule(w, mgr, rule, http.StatusOK)
+		case http.MethodDelete:
+			if !mgr.DeleteRule(ruleID) {
+				http.Error(w, "Rule not found", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// saveRule stores a rule and writes it back with the given status
+func saveRule(w http.ResponseWriter, mgr Manager, rule Rule, status int) {
+	saved, err := mgr.SetRule(rule)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(saved); err != nil {
+		log.Printf("Error encoding alert rule response: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"windz/internal/observations"
+)
+
+// Manager defines the interface for alert rule evaluation and state
+type Manager interface {
+	// Start loads rules and state and begins checking for missing data
+	Start(ctx context.Context) error
+
+	// Stop stops the checker and saves the alert state
+	Stop() error
+
+	// Evaluate checks the rules of an observation's station; call it for every new observation
+	Evaluate(obs observations.WindObservation)
+
+	// EvaluateFreshness checks the freshness rules of a station whose freshness changed
+	EvaluateFreshness(event observations.FreshnessEvent)
+
+	// GetRules returns all rules sorted by ID
+	GetRules() []Rule
+
+	// GetRule returns a specific rule by ID
+	GetRule(id string) (Rule, bool)
+
+	// SetRule validates and adds or replaces a rule, generating an ID when empty
+	SetRule(rule Rule) (Rule, error)
+
+	// DeleteRule removes a rule and its state
+	DeleteRule(id string) bool
+
+	// GetAlerts returns every rule with its current state
+	GetAlerts() []Alert
+
+	// GetEvents returns the most recent fired and cleared events, newest first
+	GetEvents(limit int) []Event
+}
+
+// Rule is a user-defined alert condition for one station. All set value conditions
+// must hold for the rule to fire; NoDataFor rules fire when the station goes silent and
+// Freshness rules when its data falls at least that far behind.
+type Rule struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	StationID string `json:"station_id"`
+
+	MinSpeed      *float64 `json:"min_speed,omitempty"`
+	MaxSpeed      *float64 `json:"max_speed,omitempty"`
+	MinGust       *float64 `json:"min_gust,omitempty"`
+	MaxGust       *float64 `json:"max_gust,omitempty"`
+	DirectionFrom *float64 `json:"direction_from,omitempty"` // Clockwise sector start in degrees
+	DirectionTo   *float64 `json:"direction_to,omitempty"`   // Clockwise sector end in degrees
+	NoDataFor     Duration `json:"no_data_for,omitempty"`
+
+	Freshness observations.Freshness `json:"freshness,omitempty"` // late, stale or offline
+
+	For                 Duration `json:"for,omitempty"`                  // How long conditions must hold before firing
+	Cooldown            Duration `json:"cooldown,omitempty"`             // Minimum time between firings
+	SpeedHysteresis     float64  `json:"speed_hysteresis,omitempty"`     // m/s margin before a fired speed/gust condition clears
+	DirectionHysteresis float64  `json:"direction_hysteresis,omitempty"` // Degrees margin before a fired direction condition clears
+	Disabled            bool     `json:"disabled,omitempty"`
+}
+
+// Alert states
+const (
+	StatusInactive = "inactive"
+	StatusPending  = "pending" // Conditions hold but For or Cooldown has not passed
+	StatusActive   = "active"
+)
+
+// State is the persisted evaluation state of a rule
+type State struct {
+	RuleID          string                        `json:"rule_id"`
+	Status          string                        `json:"status"`
+	PendingSince    time.Time                     `json:"pending_since"`
+	FiredAt         time.Time                     `json:"fired_at"`
+	ClearedAt       time.Time                     `json:"cleared_at"`
+	FireCount       int                           `json:"fire_count"`
+	LastObservation *observations.WindObservation `json:"last_observation,omitempty"`
+}
+
+// Alert pairs a rule with its state for API responses
+type Alert struct {
+	Rule  Rule  `json:"rule"`
+	State State `json:"state"`
+}
+
+// Event types
+const (
+	EventFired   = "fired"
+	EventCleared = "cleared"
+)
+
+// Event is published as an "alert" SSE event when a rule fires or clears
+type Event struct {
+	RuleID      string                        `json:"rule_id"`
+	RuleName    string                        `json:"rule_name"`
+	StationID   string                        `json:"station_id"`
+	StationName string                        `json:"station_name"`
+	Type        string                        `json:"type"` // fired or cleared
+	Timestamp   time.Time                     `json:"timestamp"`
+	Message     string                        `json:"message"`
+	Observation *observations.WindObservation `json:"observation,omitempty"`
+
+	observationDetail bool // Message ends with the description of Observation
+}
+
+// Duration is a time.Duration that reads and writes JSON as a string such as "20m"
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON accepts a duration string or a number of nanoseconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", v, err)
+		}
+		*d = Duration(parsed)
+	case float64:
+		*d = Duration(time.Duration(v))
+	default:
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	return nil
+}
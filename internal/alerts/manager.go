@@ -0,0 +1,699 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"windz/internal/checkpoint"
+	"windz/internal/observations"
+	"windz/internal/sse"
+	"windz/internal/stations"
+	"windz/internal/units"
+)
+
+const (
+	maxEvents          = 200              // Recent events kept for the API
+	noDataCheckPeriod  = 30 * time.Second // How often silent and out of date stations are checked
+	calmDirectionSpeed = 0.5              // m/s; direction conditions never match in calm
+)
+
+// Config holds the alert manager settings
+type Config struct {
+	RulesFile string // Rule definitions, rewritten when rules change through the API
+	StateFile string // Alert state persistence file
+}
+
+// manager implements the alerts Manager interface
+type manager struct {
+	stationMgr stations.Manager
+	sseMgr     sse.Manager
+	obsMgr     observations.Manager
+	rulesFile  string
+	stateFile  string
+
+	mu       sync.RWMutex
+	rules    map[string]Rule
+	states   map[string]*State
+	lastSeen map[string]time.Time // Newest observation timestamp per station
+	events   []Event              // Newest last
+	started  time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewManager creates a new alert manager instance
+func NewManager(stationMgr stations.Manager, sseMgr sse.Manager, obsMgr observations.Manager, cfg Config) Manager {
+	return &manager{
+		stationMgr: stationMgr,
+		sseMgr:     sseMgr,
+		obsMgr:     obsMgr,
+		rulesFile:  cfg.RulesFile,
+		stateFile:  cfg.StateFile,
+		rules:      make(map[string]Rule),
+		states:     make(map[string]*State),
+		lastSeen:   make(map[string]time.Time),
+	}
+}
+
+// Start loads rules and state and begins checking for missing data
+func (m *manager) Start(ctx context.Context) error {
+	if err := m.loadRules(); err != nil {
+		return err
+	}
+	m.loadStates()
+
+	m.mu.Lock()
+	m.started = time.Now()
+	for stationID, obs := range m.obsMgr.GetAllLatestObservations() {
+		m.lastSeen[stationID] = obs.Timestamp
+	}
+	m.mu.Unlock()
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.done = make(chan struct{})
+	go m.runNoDataChecker(ctx)
+
+	log.Printf("Alert manager started with %d rules", len(m.GetRules()))
+	return nil
+}
+
+// Stop stops the checker and saves the alert state
+func (m *manager) Stop() error {
+	if m.cancel != nil {
+		m.cancel()
+		<-m.done
+	}
+	m.saveStates()
+	return nil
+}
+
+// runNoDataChecker periodically evaluates the NoDataFor and Freshness rules
+func (m *manager) runNoDataChecker(ctx context.Context) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(noDataCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.checkNoData(time.Now())
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Evaluate checks the rules of an observation's station
+func (m *manager) Evaluate(obs observations.WindObservation) {
+	m.mu.Lock()
+	if obs.Timestamp.After(m.lastSeen[obs.StationID]) {
+		m.lastSeen[obs.StationID] = obs.Timestamp
+	}
+
+	var events []Event
+	for _, rule := range m.sortedRulesLocked() {
+		if rule.Disabled || rule.StationID != obs.StationID || rule.Freshness != "" {
+			continue
+		}
+		state := m.stateLocked(rule.ID)
+
+		var event *Event
+		if rule.NoDataFor > 0 {
+			event = m.evaluateNoData(rule, state, obs.Timestamp, &obs)
+		} else {
+			event = m.evaluateObservation(rule, state, obs)
+		}
+		if event != nil {
+			events = append(events, *event)
+		}
+	}
+	m.mu.Unlock()
+
+	m.publish(events)
+}
+
+// EvaluateFreshness checks the freshness rules of a station whose freshness changed
+func (m *manager) EvaluateFreshness(event observations.FreshnessEvent) {
+	m.mu.Lock()
+	var events []Event
+	for _, rule := range m.sortedRulesLocked() {
+		if rule.Disabled || rule.StationID != event.StationID || rule.Freshness == "" {
+			continue
+		}
+		if e := m.evaluateFreshness(rule, m.stateLocked(rule.ID), event.Freshness, event.Timestamp); e != nil {
+			events = append(events, *e)
+		}
+	}
+	m.mu.Unlock()
+
+	m.publish(events)
+}
+
+// checkNoData fires NoDataFor rules of stations that have gone silent and advances
+// Freshness rules waiting for For to pass
+func (m *manager) checkNoData(now time.Time) {
+	// Looked up before locking so no observation manager lock is taken under ours
+	freshness := make(map[string]observations.Freshness)
+	for _, rule := range m.GetRules() {
+		if !rule.Disabled && rule.Freshness != "" {
+			freshness[rule.StationID] = m.obsMgr.GetFreshness(rule.StationID)
+		}
+	}
+
+	m.mu.Lock()
+	var events []Event
+	for _, rule := range m.sortedRulesLocked() {
+		var event *Event
+		switch {
+		case rule.Disabled:
+		case rule.NoDataFor > 0:
+			event = m.evaluateNoData(rule, m.stateLocked(rule.ID), now, nil)
+		case rule.Freshness != "":
+			if current, ok := freshness[rule.StationID]; ok {
+				event = m.evaluateFreshness(rule, m.stateLocked(rule.ID), current, now)
+			}
+		}
+		if event != nil {
+			events = append(events, *event)
+		}
+	}
+	m.mu.Unlock()
+
+	m.publish(events)
+}
+
+// evaluateObservation advances a value rule's state machine with a new observation
+func (m *manager) evaluateObservation(rule Rule, state *State, obs observations.WindObservation) *Event {
+	state.LastObservation = &obs
+
+	if state.Status == StatusActive {
+		if matches(rule, obs, true) {
+			return nil
+		}
+		return m.clear(rule, state, obs.Timestamp, &obs)
+	}
+
+	if !matches(rule, obs, false) {
+		state.Status = StatusInactive
+		state.PendingSince = time.Time{}
+		return nil
+	}
+
+	if state.PendingSince.IsZero() {
+		state.PendingSince = obs.Timestamp
+	}
+	if obs.Timestamp.Sub(state.PendingSince) < time.Duration(rule.For) || inCooldown(rule, state, obs.Timestamp) {
+		state.Status = StatusPending
+		return nil
+	}
+
+	return m.fire(rule, state, obs.Timestamp, &obs, describeObservation(obs, units.Convention{}))
+}
+
+// evaluateNoData fires a NoDataFor rule when the station's newest data is too old and
+// clears it when data arrives again
+func (m *manager) evaluateNoData(rule Rule, state *State, now time.Time, obs *observations.WindObservation) *Event {
+	lastSeen, seen := m.lastSeen[rule.StationID]
+	if !seen {
+		lastSeen = m.started // Give stations without any data a full window after startup
+	}
+	silent := now.Sub(lastSeen) >= time.Duration(rule.NoDataFor)
+
+	if state.Status == StatusActive {
+		if silent {
+			return nil
+		}
+		return m.clear(rule, state, now, obs)
+	}
+
+	if !silent {
+		state.Status = StatusInactive
+		state.PendingSince = time.Time{}
+		return nil
+	}
+	if inCooldown(rule, state, now) {
+		state.Status = StatusPending
+		return nil
+	}
+
+	message := fmt.Sprintf("no data for %s", now.Sub(lastSeen).Truncate(time.Minute))
+	if !seen {
+		message = fmt.Sprintf("no data since startup %s ago", now.Sub(lastSeen).Truncate(time.Minute))
+	}
+	return m.fire(rule, state, now, nil, message)
+}
+
+// evaluateFreshness fires a Freshness rule once the station's data has been at least
+// that far behind for For, and clears it when the data catches up
+func (m *manager) evaluateFreshness(rule Rule, state *State, freshness observations.Freshness, at time.Time) *Event {
+	behind := freshness.AtLeast(rule.Freshness)
+
+	if state.Status == StatusActive {
+		if behind {
+			return nil
+		}
+		return m.clear(rule, state, at, nil)
+	}
+
+	if !behind {
+		state.Status = StatusInactive
+		state.PendingSince = time.Time{}
+		return nil
+	}
+
+	if state.PendingSince.IsZero() {
+		state.PendingSince = at
+	}
+	if at.Sub(state.PendingSince) < time.Duration(rule.For) || inCooldown(rule, state, at) {
+		state.Status = StatusPending
+		return nil
+	}
+
+	return m.fire(rule, state, at, nil, fmt.Sprintf("data %s", freshness))
+}
+
+// fire marks a rule active and returns its event
+func (m *manager) fire(rule Rule, state *State, at time.Time, obs *observations.WindObservation, detail string) *Event {
+	state.Status = StatusActive
+	state.FiredAt = at
+	state.PendingSince = time.Time{}
+	state.FireCount++
+	return m.newEvent(rule, EventFired, at, obs, detail)
+}
+
+// clear marks a rule inactive and returns its event
+func (m *manager) clear(rule Rule, state *State, at time.Time, obs *observations.WindObservation) *Event {
+	state.Status = StatusInactive
+	state.ClearedAt = at
+	state.PendingSince = time.Time{}
+
+	detail := "conditions no longer met"
+	if obs != nil && rule.NoDataFor == 0 {
+		detail = describeObservation(*obs, units.Convention{})
+	} else if rule.NoDataFor > 0 {
+		detail = "data received again"
+	} else if rule.Freshness != "" {
+		detail = "data up to date again"
+	}
+	return m.newEvent(rule, EventCleared, at, obs, detail)
+}
+
+func (m *manager) newEvent(rule Rule, eventType string, at time.Time, obs *observations.WindObservation, detail string) *Event {
+	stationName := rule.StationID
+	if station, exists := m.stationMgr.GetStation(rule.StationID); exists {
+		stationName = station.Name
+	}
+
+	return &Event{
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		StationID:   rule.StationID,
+		StationName: stationName,
+		Type:        eventType,
+		Timestamp:   at,
+		Message:     fmt.Sprintf("%s %s at %s: %s", rule.Name, eventType, stationName, detail),
+		Observation: obs,
+
+		observationDetail: obs != nil && detail == describeObservation(*obs, units.Convention{}),
+	}
+}
+
+// publish records events, broadcasts them and persists the changed state
+func (m *manager) publish(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	m.events = append(m.events, events...)
+	if len(m.events) > maxEvents {
+		m.events = append([]Event(nil), m.events[len(m.events)-maxEvents:]...)
+	}
+	m.mu.Unlock()
+
+	for _, event := range events {
+		log.Printf("Alert %s", event.Message)
+		m.sseMgr.Broadcast(sse.Message{
+			ID:        event.Timestamp.Unix(),
+			Type:      "alert",
+			StationID: event.StationID,
+			Data:      event,
+		})
+	}
+
+	m.saveStates()
+}
+
+// matches reports whether an observation satisfies the rule's value conditions. With
+// clearing set, bounds are widened by the hysteresis so an active rule does not flap.
+func matches(rule Rule, obs observations.WindObservation, clearing bool) bool {
+	speedMargin, directionMargin := 0.0, 0.0
+	if clearing {
+		speedMargin, directionMargin = rule.SpeedHysteresis, rule.DirectionHysteresis
+	}
+
+	if rule.MinSpeed != nil && obs.WindSpeed < *rule.MinSpeed-speedMargin {
+		return false
+	}
+	if rule.MaxSpeed != nil && obs.WindSpeed > *rule.MaxSpeed+speedMargin {
+		return false
+	}
+	if rule.MinGust != nil && obs.WindGust < *rule.MinGust-speedMargin {
+		return false
+	}
+	if rule.MaxGust != nil && obs.WindGust > *rule.MaxGust+speedMargin {
+		return false
+	}
+	if rule.DirectionFrom != nil && rule.DirectionTo != nil {
+		if obs.WindSpeed < calmDirectionSpeed {
+			return false
+		}
+		if !inSector(obs.WindDirection, *rule.DirectionFrom-directionMargin, *rule.DirectionTo+directionMargin) {
+			return false
+		}
+	}
+	return true
+}
+
+// inSector reports whether a direction lies in the clockwise sector from -> to
+func inSector(direction, from, to float64) bool {
+	if to-from >= 360 {
+		return true
+	}
+	direction = normalizeDegrees(direction)
+	from, to = normalizeDegrees(from), normalizeDegrees(to)
+	if from <= to {
+		return direction >= from && direction <= to
+	}
+	return direction >= from || direction <= to // Sector wraps through north
+}
+
+func normalizeDegrees(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
+
+// inCooldown reports whether the rule fired too recently to fire again
+func inCooldown(rule Rule, state *State, at time.Time) bool {
+	return rule.Cooldown > 0 && !state.FiredAt.IsZero() && at.Sub(state.FiredAt) < time.Duration(rule.Cooldown)
+}
+
+// describeObservation summarises an observation for event messages in a convention
+func describeObservation(obs observations.WindObservation, conv units.Convention) string {
+	if conv.IsDefault() {
+		return fmt.Sprintf("%.1f m/s, gust %.1f m/s, %.0f°", obs.WindSpeed, obs.WindGust, obs.WindDirection)
+	}
+	obs = obs.InUnits(conv)
+	return fmt.Sprintf("%s, gust %s, %.0f° %s", conv.Unit.Format(obs.WindSpeed), conv.Unit.Format(obs.WindGust), obs.WindDirection, obs.Compass)
+}
+
+// ConvertUnits implements units.Converter for SSE alert events, converting the
+// observation and its description in the message
+func (e Event) ConvertUnits(c units.Convention) any {
+	if e.Observation == nil {
+		return e
+	}
+	if e.observationDetail {
+		e.Message = strings.TrimSuffix(e.Message, describeObservation(*e.Observation, units.Convention{})) + describeObservation(*e.Observation, c)
+	}
+	obs := e.Observation.InUnits(c)
+	e.Observation = &obs
+	return e
+}
+
+// stateLocked returns the state of a rule, creating it if needed. Caller holds m.mu.
+func (m *manager) stateLocked(ruleID string) *State {
+	state, exists := m.states[ruleID]
+	if !exists {
+		state = &State{RuleID: ruleID, Status: StatusInactive}
+		m.states[ruleID] = state
+	}
+	return state
+}
+
+// sortedRulesLocked returns the rules ordered by ID. Caller holds m.mu.
+func (m *manager) sortedRulesLocked() []Rule {
+	result := make([]Rule, 0, len(m.rules))
+	for _, rule := range m.rules {
+		result = append(result, rule)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// GetRules returns all rules sorted by ID
+func (m *manager) GetRules() []Rule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.sortedRulesLocked()
+}
+
+// GetRule returns a specific rule by ID
+func (m *manager) GetRule(id string) (Rule, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rule, exists := m.rules[id]
+	return rule, exists
+}
+
+// SetRule validates and adds or replaces a rule, generating an ID when empty
+func (m *manager) SetRule(rule Rule) (Rule, error) {
+	if err := m.validateRule(&rule); err != nil {
+		return Rule{}, err
+	}
+
+	m.mu.Lock()
+	if rule.ID == "" {
+		for i := len(m.rules) + 1; ; i++ {
+			id := fmt.Sprintf("rule-%d", i)
+			if _, taken := m.rules[id]; !taken {
+				rule.ID = id
+				break
+			}
+		}
+	}
+	if rule.Name == "" {
+		rule.Name = rule.ID
+	}
+	if _, exists := m.rules[rule.ID]; exists {
+		// Changed conditions start from a clean state
+		delete(m.states, rule.ID)
+	}
+	m.rules[rule.ID] = rule
+	m.mu.Unlock()
+
+	m.saveRules()
+	return rule, nil
+}
+
+// DeleteRule removes a rule and its state
+func (m *manager) DeleteRule(id string) bool {
+	m.mu.Lock()
+	_, exists := m.rules[id]
+	delete(m.rules, id)
+	delete(m.states, id)
+	m.mu.Unlock()
+
+	if exists {
+		m.saveRules()
+		m.saveStates()
+	}
+	return exists
+}
+
+// GetAlerts returns every rule with its current state
+func (m *manager) GetAlerts() []Alert {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rules := m.sortedRulesLocked()
+	result := make([]Alert, 0, len(rules))
+	for _, rule := range rules {
+		state := State{RuleID: rule.ID, Status: StatusInactive}
+		if s, exists := m.states[rule.ID]; exists {
+			state = *s
+		}
+		result = append(result, Alert{Rule: rule, State: state})
+	}
+	return result
+}
+
+// GetEvents returns the most recent fired and cleared events, newest first
+func (m *manager) GetEvents(limit int) []Event {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if limit <= 0 || limit > len(m.events) {
+		limit = len(m.events)
+	}
+	result := make([]Event, 0, limit)
+	for i := len(m.events) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, m.events[i])
+	}
+	return result
+}
+
+// validateRule checks a rule for consistency
+func (m *manager) validateRule(rule *Rule) error {
+	rule.ID = strings.TrimSpace(rule.ID)
+	if strings.ContainsAny(rule.ID, "/ ") {
+		return fmt.Errorf("rule ID %q must not contain spaces or slashes", rule.ID)
+	}
+	if _, exists := m.stationMgr.GetStation(rule.StationID); !exists {
+		return fmt.Errorf("unknown station %q", rule.StationID)
+	}
+	hasValue := rule.MinSpeed != nil || rule.MaxSpeed != nil || rule.MinGust != nil || rule.MaxGust != nil || rule.DirectionFrom != nil || rule.DirectionTo != nil
+	switch {
+	case rule.NoDataFor < 0 || rule.For < 0 || rule.Cooldown < 0:
+		return fmt.Errorf("durations must not be negative")
+	case rule.NoDataFor > 0 && hasValue:
+		return fmt.Errorf("no_data_for cannot be combined with value conditions")
+	case rule.Freshness != "" && (hasValue || rule.NoDataFor > 0):
+		return fmt.Errorf("freshness cannot be combined with other conditions")
+	case rule.Freshness != "" && (!rule.Freshness.Valid() || rule.Freshness == observations.FreshnessFresh):
+		return fmt.Errorf("freshness must be late, stale or offline")
+	case rule.NoDataFor == 0 && rule.Freshness == "" && !hasValue:
+		return fmt.Errorf("rule needs at least one condition")
+	case (rule.DirectionFrom == nil) != (rule.DirectionTo == nil):
+		return fmt.Errorf("direction_from and direction_to must be set together")
+	case rule.MinSpeed != nil && rule.MaxSpeed != nil && *rule.MinSpeed > *rule.MaxSpeed:
+		return fmt.Errorf("min_speed must not exceed max_speed")
+	case rule.MinGust != nil && rule.MaxGust != nil && *rule.MinGust > *rule.MaxGust:
+		return fmt.Errorf("min_gust must not exceed max_gust")
+	case rule.SpeedHysteresis < 0 || rule.DirectionHysteresis < 0:
+		return fmt.Errorf("hysteresis must not be negative")
+	}
+	for _, dir := range []*float64{rule.DirectionFrom, rule.DirectionTo} {
+		if dir != nil && (*dir < 0 || *dir > 360) {
+			return fmt.Errorf("directions must be between 0 and 360")
+		}
+	}
+	return nil
+}
+
+// Persistence
+
+func (m *manager) loadRules() error {
+	if m.rulesFile == "" {
+		return nil
+	}
+
+	var rules []Rule
+	loaded, err := checkpoint.Load(m.rulesFile, func(data []byte) error {
+		rules = nil
+		return json.Unmarshal(data, &rules)
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to load alert rules: %w", err)
+	}
+	if loaded != m.rulesFile {
+		log.Printf("Alert rules file unreadable, recovered from %s", loaded)
+	}
+
+	byID := make(map[string]Rule, len(rules))
+	for i := range rules {
+		if rules[i].ID == "" {
+			return fmt.Errorf("alert rule %d has no ID", i)
+		}
+		if err := m.validateRule(&rules[i]); err != nil {
+			return fmt.Errorf("alert rule %s: %w", rules[i].ID, err)
+		}
+		if rules[i].Name == "" {
+			rules[i].Name = rules[i].ID
+		}
+		if _, duplicate := byID[rules[i].ID]; duplicate {
+			return fmt.Errorf("duplicate alert rule %s", rules[i].ID)
+		}
+		byID[rules[i].ID] = rules[i]
+	}
+
+	m.mu.Lock()
+	m.rules = byID
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *manager) saveRules() {
+	if m.rulesFile == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(m.GetRules(), "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling alert rules: %v", err)
+		return
+	}
+
+	if err := checkpoint.Write(m.rulesFile, data); err != nil {
+		log.Printf("Error saving alert rules: %v", err)
+	}
+}
+
+func (m *manager) loadStates() {
+	if m.stateFile == "" {
+		return
+	}
+
+	var states map[string]*State
+	loaded, err := checkpoint.Load(m.stateFile, func(data []byte) error {
+		states = make(map[string]*State)
+		return json.Unmarshal(data, &states)
+	})
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Error loading alert state: %v", err)
+		}
+		return
+	}
+	if loaded != m.stateFile {
+		log.Printf("Alert state file unreadable, recovered from %s", loaded)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Drop state of rules that no longer exist
+	for id, state := range states {
+		if _, exists := m.rules[id]; exists {
+			m.states[id] = state
+		}
+	}
+	log.Printf("Loaded alert state for %d rules", len(m.states))
+}
+
+func (m *manager) saveStates() {
+	if m.stateFile == "" {
+		return
+	}
+
+	m.mu.RLock()
+	data, err := json.MarshalIndent(m.states, "", "  ")
+	m.mu.RUnlock()
+
+	if err != nil {
+		log.Printf("Error marshaling alert state: %v", err)
+		return
+	}
+
+	if err := checkpoint.Write(m.stateFile, data); err != nil {
+		log.Printf("Error saving alert state: %v", err)
+	}
+}
@@ -0,0 +1,427 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"windz/internal/observations"
+	"windz/internal/sse"
+	"windz/internal/stations"
+	"windz/internal/units"
+)
+
+// mockSSEManager records broadcast messages
+type mockSSEManager struct {
+	messages []sse.Message
+}
+
+func (m *mockSSEManager) AddClient(clientID string) <-chan sse.Message      { return nil }
+func (m *mockSSEManager) RemoveClient(clientID string)                      {}
+func (m *mockSSEManager) HasClients() bool                                  { return false }
+func (m *mockSSEManager) Subscribers(stationID string) int                  { return 0 }
+func (m *mockSSEManager) ClientCount() int                                  { return 0 }
+func (m *mockSSEManager) Broadcast(message sse.Message)                     { m.messages = append(m.messages, message) }
+func (m *mockSSEManager) SetClientConnectCallback(callback func(string))    {}
+func (m *mockSSEManager) NotifyClientConnected(clientID string)             {}
+func (m *mockSSEManager) SendToClient(clientID string, message sse.Message) {}
+func (m *mockSSEManager) SetGroupResolver(func(string) ([]string, bool))    {}
+func (m *mockSSEManager) ResolveGroup(groupID string) ([]string, bool)      { return nil, false }
+func (m *mockSSEManager) AddListener(listener func(sse.Message))            {}
+func (m *mockSSEManager) AddFilteredClient(clientID string, stationIDs []string) <-chan sse.Message {
+	return nil
+}
+
+// mockObservationManager serves a fixed set of latest observations
+type mockObservationManager struct {
+	latest    map[string]observations.WindObservation
+	freshness map[string]observations.Freshness
+}
+
+func (m *mockObservationManager) Start(ctx context.Context) error { return nil }
+func (m *mockObservationManager) Stop() error                     { return nil }
+func (m *mockObservationManager) GetLatestObservation(id string) (observations.WindObservation, bool) {
+	obs, ok := m.latest[id]
+	return obs, ok
+}
+func (m *mockObservationManager) GetAllLatestObservations() map[string]observations.WindObservation {
+	return m.latest
+}
+func (m *mockObservationManager) GetPollingState(id string) (observations.PollingState, bool) {
+	return observations.PollingState{}, false
+}
+func (m *mockObservationManager) GetHistory(id string, from, to time.Time) []observations.WindObservation {
+	return nil
+}
+func (m *mockObservationManager) GetQualityFlags(id string) map[string]int { return nil }
+func (m *mockObservationManager) AddObservationListener(listener func(observations.WindObservation)) {
+}
+func (m *mockObservationManager) Refresh(stationID string) error { return nil }
+func (m *mockObservationManager) Wake()                          {}
+func (m *mockObservationManager) GetFreshness(stationID string) observations.Freshness {
+	return m.freshness[stationID]
+}
+func (m *mockObservationManager) AddFreshnessListener(listener func(observations.FreshnessEvent)) {
+}
+func (m *mockObservationManager) UpstreamStatus() observations.UpstreamStatus {
+	return observations.UpstreamStatus{State: observations.BreakerClosed}
+}
+
+func float(v float64) *float64 { return &v }
+
+func newTestManager(t *testing.T) (*manager, *mockSSEManager) {
+	sseMgr := &mockSSEManager{}
+	dir := t.TempDir()
+	mgr := NewManager(stations.NewManager(), sseMgr, &mockObservationManager{}, Config{
+		RulesFile: filepath.Join(dir, "rules.json"),
+		StateFile: filepath.Join(dir, "state.json"),
+	}).(*manager)
+	mgr.started = time.Now()
+	return mgr, sseMgr
+}
+
+func alertEvents(messages []sse.Message) []Event {
+	var events []Event
+	for _, msg := range messages {
+		if msg.Type == "alert" {
+			events = append(events, msg.Data.(Event))
+		}
+	}
+	return events
+}
+
+func TestGustRuleHysteresis(t *testing.T) {
+	mgr, sseMgr := newTestManager(t)
+	if _, err := mgr.SetRule(Rule{ID: "kalbada-gust", StationID: "101022", MinGust: float(15), SpeedHysteresis: 2}); err != nil {
+		t.Fatalf("SetRule() error = %v", err)
+	}
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i, gust := range []float64{12, 15.5, 14, 13.5, 12.5, 16} {
+		mgr.Evaluate(observations.WindObservation{StationID: "101022", Timestamp: base.Add(time.Duration(i) * 10 * time.Minute), WindSpeed: 10, WindGust: gust})
+	}
+
+	events := alertEvents(sseMgr.messages)
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	// Fires at 15.5, holds through 14 and 13.5, clears below 13, fires again at 16
+	if strings.Join(types, ",") != "fired,cleared,fired" {
+		t.Errorf("Unexpected event sequence: %v", types)
+	}
+	if events[0].StationName != "Kalbådagrund" || events[0].Observation.WindGust != 15.5 {
+		t.Errorf("Unexpected fired event: %+v", events[0])
+	}
+
+	// SSE clients asking for knots get the event converted, message included
+	converted := units.Convert(events[0], units.Convention{Unit: units.Knots, Lang: units.Finnish}).(Event)
+	if !strings.HasSuffix(converted.Message, ": 19.4 kn, gust 30.1 kn, 0° P") || converted.Observation.Units != units.Knots {
+		t.Errorf("Unexpected converted event: %q %+v", converted.Message, converted.Observation)
+	}
+	if !strings.HasSuffix(events[0].Message, ": 10.0 m/s, gust 15.5 m/s, 0°") || events[0].Observation.WindGust != 15.5 {
+		t.Errorf("Conversion modified the original event: %q", events[0].Message)
+	}
+
+	alerts := mgr.GetAlerts()
+	if len(alerts) != 1 || alerts[0].State.Status != StatusActive || alerts[0].State.FireCount != 2 {
+		t.Errorf("Unexpected alert state: %+v", alerts)
+	}
+}
+
+func TestSectorRuleFor(t *testing.T) {
+	mgr, sseMgr := newTestManager(t)
+	_, err := mgr.SetRule(Rule{
+		ID:            "harmaja-sw",
+		StationID:     "100996",
+		MinSpeed:      float(7),
+		MaxSpeed:      float(12),
+		DirectionFrom: float(200),
+		DirectionTo:   float(250),
+		For:           Duration(20 * time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("SetRule() error = %v", err)
+	}
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	evaluate := func(minute int, speed, direction float64) {
+		mgr.Evaluate(observations.WindObservation{StationID: "100996", Timestamp: base.Add(time.Duration(minute) * time.Minute), WindSpeed: speed, WindDirection: direction})
+	}
+
+	evaluate(0, 8, 220)
+	evaluate(10, 9, 230)
+	if state := mgr.GetAlerts()[0].State; state.Status != StatusPending {
+		t.Errorf("Expected pending after 10 minutes, got %s", state.Status)
+	}
+	evaluate(20, 8, 210)
+	if len(alertEvents(sseMgr.messages)) != 1 {
+		t.Fatal("Expected rule to fire after conditions held for 20 minutes")
+	}
+
+	// Other stations do not affect the rule
+	mgr.Evaluate(observations.WindObservation{StationID: "100908", Timestamp: base.Add(25 * time.Minute), WindSpeed: 1})
+
+	evaluate(30, 8, 180) // Out of sector clears
+	evaluate(40, 8, 220) // Back in sector restarts the For window
+	evaluate(50, 8, 220)
+	if events := alertEvents(sseMgr.messages); len(events) != 2 || events[1].Type != EventCleared {
+		t.Errorf("Expected fired and cleared only, got %+v", events)
+	}
+}
+
+func TestInSector(t *testing.T) {
+	tests := []struct {
+		direction, from, to float64
+		expected            bool
+	}{
+		{220, 200, 250, true},
+		{190, 200, 250, false},
+		{355, 340, 20, true},
+		{10, 340, 20, true},
+		{30, 340, 20, false},
+		{100, -10, 400, true},
+	}
+	for _, tt := range tests {
+		if got := inSector(tt.direction, tt.from, tt.to); got != tt.expected {
+			t.Errorf("inSector(%v, %v, %v) = %v, expected %v", tt.direction, tt.from, tt.to, got, tt.expected)
+		}
+	}
+}
+
+func TestCooldown(t *testing.T) {
+	mgr, sseMgr := newTestManager(t)
+	mgr.SetRule(Rule{ID: "gust", StationID: "101022", MinGust: float(15), Cooldown: Duration(time.Hour)})
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i, gust := range []float64{16, 10, 16, 16, 16, 16, 16, 16} {
+		mgr.Evaluate(observations.WindObservation{StationID: "101022", Timestamp: base.Add(time.Duration(i) * 10 * time.Minute), WindGust: gust})
+	}
+
+	// Fired at 0, cleared at 10, suppressed until 60 minutes after the first firing
+	events := alertEvents(sseMgr.messages)
+	if len(events) != 3 || events[2].Type != EventFired || !events[2].Timestamp.Equal(base.Add(time.Hour)) {
+		t.Errorf("Unexpected events with cooldown: %+v", events)
+	}
+}
+
+func TestNoDataRule(t *testing.T) {
+	mgr, sseMgr := newTestManager(t)
+	mgr.SetRule(Rule{ID: "uto-silent", StationID: "100908", NoDataFor: Duration(time.Hour)})
+
+	now := time.Now()
+	mgr.Evaluate(observations.WindObservation{StationID: "100908", Timestamp: now.Add(-30 * time.Minute)})
+
+	mgr.checkNoData(now)
+	if len(alertEvents(sseMgr.messages)) != 0 {
+		t.Fatal("Rule fired before the station was silent for an hour")
+	}
+
+	mgr.checkNoData(now.Add(31 * time.Minute))
+	events := alertEvents(sseMgr.messages)
+	if len(events) != 1 || events[0].Type != EventFired || events[0].StationName != "Utö" {
+		t.Fatalf("Expected no-data alert, got %+v", events)
+	}
+
+	mgr.Evaluate(observations.WindObservation{StationID: "100908", Timestamp: now.Add(35 * time.Minute)})
+	if events := alertEvents(sseMgr.messages); len(events) != 2 || events[1].Type != EventCleared {
+		t.Errorf("Expected no-data alert to clear on new data, got %+v", events)
+	}
+}
+
+func TestFreshnessRule(t *testing.T) {
+	mgr, sseMgr := newTestManager(t)
+	obsMgr := mgr.obsMgr.(*mockObservationManager)
+	obsMgr.freshness = map[string]observations.Freshness{"100908": observations.FreshnessLate}
+	if _, err := mgr.SetRule(Rule{ID: "uto-stale", StationID: "100908", Freshness: observations.FreshnessStale, For: Duration(10 * time.Minute)}); err != nil {
+		t.Fatalf("SetRule() error = %v", err)
+	}
+
+	now := time.Now()
+	change := func(freshness observations.Freshness, at time.Time) {
+		obsMgr.freshness["100908"] = freshness
+		mgr.EvaluateFreshness(observations.FreshnessEvent{StationID: "100908", Freshness: freshness, Timestamp: at})
+	}
+
+	mgr.checkNoData(now)
+	change(observations.FreshnessStale, now)
+	if len(alertEvents(sseMgr.messages)) != 0 {
+		t.Fatal("Rule fired before the station was stale for 10 minutes")
+	}
+
+	// Offline is worse than stale, and the periodic check fires once For has passed
+	change(observations.FreshnessOffline, now.Add(5*time.Minute))
+	mgr.checkNoData(now.Add(11 * time.Minute))
+	events := alertEvents(sseMgr.messages)
+	if len(events) != 1 || events[0].Type != EventFired || !strings.Contains(events[0].Message, "offline") {
+		t.Fatalf("Expected freshness alert, got %+v", events)
+	}
+
+	// Data arriving clears it, and new observations are not freshness rule input
+	mgr.Evaluate(observations.WindObservation{StationID: "100908", Timestamp: now.Add(12 * time.Minute)})
+	change(observations.FreshnessFresh, now.Add(12*time.Minute))
+	events = alertEvents(sseMgr.messages)
+	if len(events) != 2 || events[1].Type != EventCleared {
+		t.Errorf("Expected freshness alert to clear, got %+v", events)
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	mgr, _ := newTestManager(t)
+
+	invalid := []Rule{
+		{StationID: "unknown", MinGust: float(10)},
+		{StationID: "100996"},
+		{StationID: "100996", MinGust: float(10), NoDataFor: Duration(time.Hour)},
+		{StationID: "100996", DirectionFrom: float(100)},
+		{StationID: "100996", MinSpeed: float(10), MaxSpeed: float(5)},
+		{StationID: "100996", DirectionFrom: float(100), DirectionTo: float(400)},
+		{ID: "a/b", StationID: "100996", MinGust: float(10)},
+		{StationID: "100996", Freshness: "dusty"},
+		{StationID: "100996", Freshness: observations.FreshnessFresh},
+		{StationID: "100996", Freshness: observations.FreshnessStale, MinGust: float(10)},
+	}
+	for i, rule := range invalid {
+		if _, err := mgr.SetRule(rule); err == nil {
+			t.Errorf("Expected error for invalid rule %d: %+v", i, rule)
+		}
+	}
+
+	rule, err := mgr.SetRule(Rule{StationID: "100996", MinGust: float(10)})
+	if err != nil || rule.ID == "" || rule.Name != rule.ID {
+		t.Errorf("Expected generated ID and name, got %+v (%v)", rule, err)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	mgr.SetRule(Rule{ID: "gust", Name: "Strong gust", StationID: "101022", MinGust: float(15), For: Duration(20 * time.Minute)})
+	mgr.Evaluate(observations.WindObservation{StationID: "101022", Timestamp: time.Now(), WindGust: 18})
+	mgr.Evaluate(observations.WindObservation{StationID: "101022", Timestamp: time.Now().Add(20 * time.Minute), WindGust: 18})
+	mgr.SetRule(Rule{ID: "calm", StationID: "101022", MaxSpeed: float(2)})
+	if err := mgr.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	// A crash mid-write leaves torn files; the previous checkpoints are loaded instead
+	os.WriteFile(mgr.rulesFile, []byte(`[{"id": "gu`), 0644)
+	os.WriteFile(mgr.stateFile, []byte(`{"gust": {"sta`), 0644)
+
+	restarted := NewManager(stations.NewManager(), &mockSSEManager{}, &mockObservationManager{}, Config{
+		RulesFile: mgr.rulesFile,
+		StateFile: mgr.stateFile,
+	})
+	if err := restarted.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer restarted.Stop()
+
+	rule, exists := restarted.GetRule("gust")
+	if !exists || rule.Name != "Strong gust" || rule.For != Duration(20*time.Minute) {
+		t.Errorf("Rule not restored: %+v", rule)
+	}
+	if _, exists := restarted.GetRule("calm"); exists {
+		t.Error("Rule from the torn file should not be restored")
+	}
+	alerts := restarted.GetAlerts()
+	if len(alerts) != 1 || alerts[0].State.Status != StatusActive || alerts[0].State.FiredAt.IsZero() {
+		t.Errorf("Active state not restored: %+v", alerts)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, mgr, "")
+
+	body := `{"id":"harmaja","name":"Harmaja SW","station_id":"100996","min_speed":7,"direction_from":200,"direction_to":250,"for":"20m"}`
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/alerts/rules", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/alerts/rules", strings.NewReader(body)))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for duplicate rule, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/alerts/rules", strings.NewReader(`{"station_id":"100996"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for rule without conditions, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/alerts", nil))
+	var alerts []Alert
+	if err := json.NewDecoder(rec.Body).Decode(&alerts); err != nil || len(alerts) != 1 || alerts[0].Rule.For != Duration(20*time.Minute) {
+		t.Errorf("Unexpected alerts response: %+v (%v)", alerts, err)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/alerts?status=active", nil))
+	if strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("Expected no active alerts, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("DELETE", "/api/alerts/rules/harmaja", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/alerts/rules/harmaja", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after delete, got %d", rec.Code)
+	}
+}
+
+func TestHandlersAdminToken(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, mgr, "s3cret")
+
+	send := func(method, target, token, body string) int {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	body := `{"id":"harmaja","station_id":"100996","min_speed":7}`
+	if code := send("POST", "/api/alerts/rules", "", body); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 creating a rule without the token, got %d", code)
+	}
+	if _, exists := mgr.GetRule("harmaja"); exists {
+		t.Fatal("Unauthorized request created a rule")
+	}
+	if code := send("POST", "/api/alerts/rules", "s3cret", body); code != http.StatusCreated {
+		t.Fatalf("Expected 201 with the token, got %d", code)
+	}
+
+	// Reading stays open, changes need the token
+	if code := send("GET", "/api/alerts/rules/harmaja", "", ""); code != http.StatusOK {
+		t.Errorf("Expected rules readable without the token, got %d", code)
+	}
+	if code := send("PUT", "/api/alerts/rules/harmaja", "wrong", body); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 replacing a rule with a wrong token, got %d", code)
+	}
+	if code := send("DELETE", "/api/alerts/rules/harmaja", "", ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 deleting a rule without the token, got %d", code)
+	}
+	if code := send("DELETE", "/api/alerts/rules/harmaja", "s3cret", ""); code != http.StatusNoContent {
+		t.Errorf("Expected 204 deleting with the token, got %d", code)
+	}
+}
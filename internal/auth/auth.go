@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// RequireToken rejects requests without the admin bearer token. An empty token leaves
+// the handler open.
+func RequireToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !Authorized(r, token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="windz admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// RequireTokenForChanges is RequireToken for every method except GET and HEAD, so state
+// stays readable while changing it needs the admin token
+func RequireTokenForChanges(token string, next http.HandlerFunc) http.HandlerFunc {
+	protected := RequireToken(token, next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next(w, r)
+			return
+		}
+		protected(w, r)
+	}
+}
+
+// Authorized reports whether the request carries the admin bearer token, always true
+// when no token is configured
+func Authorized(r *http.Request, token string) bool {
+	if token == "" {
+		return true
+	}
+	provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
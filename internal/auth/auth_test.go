@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireToken(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		header  string
+		want    int
+	}{
+		{"no token configured", RequireToken("", ok), "POST", "", http.StatusOK},
+		{"missing token", RequireToken("s3cret", ok), "GET", "", http.StatusUnauthorized},
+		{"wrong token", RequireToken("s3cret", ok), "GET", "Bearer nope", http.StatusUnauthorized},
+		{"not a bearer token", RequireToken("s3cret", ok), "GET", "s3cret", http.StatusUnauthorized},
+		{"valid token", RequireToken("s3cret", ok), "GET", "Bearer s3cret", http.StatusOK},
+		{"read without token", RequireTokenForChanges("s3cret", ok), "GET", "", http.StatusOK},
+		{"change without token", RequireTokenForChanges("s3cret", ok), "DELETE", "", http.StatusUnauthorized},
+		{"change with token", RequireTokenForChanges("s3cret", ok), "POST", "Bearer s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		tt.handler(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package checkpoint
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// BackupSuffix is appended to the path of the previous checkpoint
+const BackupSuffix = ".bak"
+
+// tempPattern names in-progress checkpoints next to the target file
+const tempPattern = ".tmp-*"
+
+// Write atomically replaces the file at path with data. The data is written to a
+// temporary file in the same directory, fsynced and renamed over path, so a crash
+// leaves either the old or the new checkpoint in place. The previous checkpoint is
+// kept as path+BackupSuffix.
+func Write(path string, data []byte) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+tempPattern)
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// Keep the previous checkpoint as the backup
+	if err := os.Rename(path, path+BackupSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// Load reads the checkpoint at path and passes it to decode. When the file is
+// missing or decode rejects it, the backup is tried instead, except for files
+// refused with ErrUnsupportedVersion. It returns the path
+// that was loaded; the error wraps fs.ErrNotExist when neither file exists.
+// Temporary files left by an interrupted Write are removed.
+func Load(path string, decode func([]byte) error) (string, error) {
+	removeStale(path)
+
+	var errs []error
+	for _, candidate := range []string{path, path + BackupSuffix} {
+		data, err := os.ReadFile(candidate)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := decode(data); err != nil {
+			if errors.Is(err, ErrUnsupportedVersion) {
+				// Not damage: a newer release wrote it, and an older backup would be stale
+				return "", fmt.Errorf("%s: %w", candidate, err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", candidate, err))
+			continue
+		}
+		return candidate, nil
+	}
+
+	if errors.Is(errs[0], fs.ErrNotExist) && errors.Is(errs[1], fs.ErrNotExist) {
+		return "", fs.ErrNotExist
+	}
+	return "", errors.Join(errs...)
+}
+
+// removeStale deletes temporary files left next to path by an interrupted Write
+func removeStale(path string) {
+	matches, err := filepath.Glob(path + tempPattern)
+	if err != nil {
+		return
+	}
+	for _, match := range matches {
+		os.Remove(match)
+	}
+}
+
+// syncDir fsyncs a directory so a rename in it is durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,176 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func decodeInto(target *map[string]int) func([]byte) error {
+	return func(data []byte) error {
+		decoded := make(map[string]int)
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return err
+		}
+		*target = decoded
+		return nil
+	}
+}
+
+func TestWriteRotatesBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	if err := Write(path, []byte(`{"version":1}`)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := os.Stat(path + BackupSuffix); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("First write should not create a backup")
+	}
+
+	if err := Write(path, []byte(`{"version":2}`)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"version":2}` {
+		t.Errorf("Unexpected checkpoint %q", data)
+	}
+	if data, _ := os.ReadFile(path + BackupSuffix); string(data) != `{"version":1}` {
+		t.Errorf("Unexpected backup %q", data)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.tmp-*"))
+	if len(matches) != 0 {
+		t.Errorf("Temporary files left behind: %v", matches)
+	}
+}
+
+func TestLoadFallsBackToBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	Write(path, []byte(`{"version":1}`))
+	Write(path, []byte(`{"version":2}`))
+
+	// A torn primary is skipped in favour of the backup
+	os.WriteFile(path, []byte(`{"vers`), 0644)
+	os.WriteFile(path+".tmp-123", []byte(`{"version":3}`), 0644)
+
+	var state map[string]int
+	loaded, err := Load(path, decodeInto(&state))
+	if err != nil || loaded != path+BackupSuffix || state["version"] != 1 {
+		t.Fatalf("Load() = %q, %v, state %v; want backup", loaded, err, state)
+	}
+	if _, err := os.Stat(path + ".tmp-123"); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("Stale temporary file should be removed")
+	}
+
+	// Crash between the two renames: only the backup exists
+	os.Remove(path)
+	if loaded, err := Load(path, decodeInto(&state)); err != nil || loaded != path+BackupSuffix {
+		t.Errorf("Load() = %q, %v; want backup", loaded, err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	var state map[string]int
+
+	if _, err := Load(path, decodeInto(&state)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected not-exist error, got %v", err)
+	}
+
+	os.WriteFile(path, []byte(`garbage`), 0644)
+	os.WriteFile(path+BackupSuffix, []byte(`also garbage`), 0644)
+	if _, err := Load(path, decodeInto(&state)); err == nil || errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected decode error, got %v", err)
+	}
+	if state != nil {
+		t.Errorf("State should be untouched, got %v", state)
+	}
+}
+
+func TestSchemaMigrations(t *testing.T) {
+	// Version 1 renamed "n" to "count", version 2 doubled it
+	schema := Schema{
+		Format:  "test.counter",
+		Version: 2,
+		Migrations: map[int]Migration{
+			0: func(data json.RawMessage) (json.RawMessage, error) {
+				return json.RawMessage(strings.Replace(string(data), `"n"`, `"count"`, 1)), nil
+			},
+			1: func(data json.RawMessage) (json.RawMessage, error) {
+				var doc map[string]int
+				if err := json.Unmarshal(data, &doc); err != nil {
+					return nil, err
+				}
+				doc["count"] *= 2
+				return json.Marshal(doc)
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		version int
+	}{
+		{"legacy", `{"n": 21}`, 0},
+		{"previous", `{"format": "test.counter", "version": 1, "data": {"count": 21}}`, 1},
+		{"current", `{"format": "test.counter", "version": 2, "data": {"count": 42}}`, 2},
+	}
+	for _, tt := range tests {
+		var doc map[string]int
+		version, err := schema.Unmarshal([]byte(tt.data), &doc)
+		if err != nil || version != tt.version || doc["count"] != 42 {
+			t.Errorf("%s: Unmarshal() = %d, %v, doc %v", tt.name, version, err, doc)
+		}
+	}
+
+	data, err := schema.Marshal(map[string]int{"count": 42})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.Format != "test.counter" || envelope.Version != 2 {
+		t.Errorf("Unexpected envelope %s", data)
+	}
+
+	var doc map[string]int
+	if _, err := schema.Unmarshal([]byte(`{"format": "test.counter", "version": 3, "data": {}}`), &doc); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected future version to be refused, got %v", err)
+	}
+	if doc != nil {
+		t.Errorf("Refused document was decoded: %v", doc)
+	}
+	if _, err := schema.Unmarshal([]byte(`{"format": "other", "version": 1, "data": {}}`), &doc); err == nil {
+		t.Error("Expected format mismatch error")
+	}
+	gap := Schema{Format: "test.counter", Version: 2, Migrations: map[int]Migration{1: schema.Migrations[1]}}
+	if _, err := gap.Unmarshal([]byte(`{"n": 21}`), &doc); err == nil {
+		t.Error("Expected missing migration error")
+	}
+}
+
+func TestLoadRefusesFutureVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	older := Schema{Format: "test", Version: 1}
+	newer := Schema{Format: "test", Version: 2, Migrations: map[int]Migration{
+		1: func(data json.RawMessage) (json.RawMessage, error) { return data, nil },
+	}}
+
+	data, _ := older.Marshal(map[string]int{"a": 1})
+	Write(path, data)
+	data, _ = newer.Marshal(map[string]int{"a": 2})
+	Write(path, data)
+
+	// The older backup is not loaded in place of a newer file
+	var doc map[string]int
+	_, err := Load(path, func(data []byte) error {
+		_, err := older.Unmarshal(data, &doc)
+		return err
+	})
+	if !errors.Is(err, ErrUnsupportedVersion) || doc != nil {
+		t.Errorf("Load() error = %v, doc %v; want refusal", err, doc)
+	}
+}
@@ -0,0 +1,81 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupportedVersion is returned for files written by a newer format version
+var ErrUnsupportedVersion = errors.New("unsupported format version")
+
+// Envelope is the versioned wrapper around a persisted document
+type Envelope struct {
+	Format  string          `json:"format"`
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Migration upgrades a document by one version
+type Migration func(data json.RawMessage) (json.RawMessage, error)
+
+// Schema describes a versioned document format and how older versions are upgraded
+type Schema struct {
+	Format  string // Name stored in the envelope, checked on load
+	Version int    // Version written by Marshal
+
+	// Migrations are keyed by the version they upgrade from. Version 0 is a legacy
+	// file written before envelopes, holding the bare document.
+	Migrations map[int]Migration
+}
+
+// Marshal wraps v in an envelope of the current version
+func (s Schema) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(Envelope{
+		Format:  s.Format,
+		Version: s.Version,
+		Data:    data,
+	}, "", "  ")
+}
+
+// Unmarshal decodes a document of any supported version into v, running the
+// migrations from its version up to the current one. It returns the version the
+// document was stored in. Documents from a newer version are refused with an
+// error wrapping ErrUnsupportedVersion rather than decoded partially.
+func (s Schema) Unmarshal(raw []byte, v any) (int, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return 0, err
+	}
+
+	version, data := envelope.Version, envelope.Data
+	switch {
+	case envelope.Format == "" && envelope.Data == nil:
+		// Written before envelopes existed
+		version, data = 0, raw
+	case envelope.Format != s.Format:
+		return 0, fmt.Errorf("format %q, expected %q", envelope.Format, s.Format)
+	case version > s.Version:
+		return version, fmt.Errorf("%s version %d is newer than %d: %w", s.Format, version, s.Version, ErrUnsupportedVersion)
+	case version < 0:
+		return version, fmt.Errorf("%s version %d: %w", s.Format, version, ErrUnsupportedVersion)
+	}
+
+	for from := version; from < s.Version; from++ {
+		migrate, ok := s.Migrations[from]
+		if !ok {
+			return version, fmt.Errorf("no migration from %s version %d", s.Format, from)
+		}
+		migrated, err := migrate(data)
+		if err != nil {
+			return version, fmt.Errorf("migrating %s from version %d: %w", s.Format, from, err)
+		}
+		data = migrated
+	}
+
+	return version, json.Unmarshal(data, v)
+}
@@ -0,0 +1,59 @@
+package clock
+
+import "time"
+
+// Clock abstracts the current time and timers so polling and keepalive loops can
+// run against a fake clock in tests
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+
+	// NewTimer creates a timer that fires once after d
+	NewTimer(d time.Duration) Timer
+
+	// NewTicker creates a ticker that fires every d
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a single-shot timer created by a Clock
+type Timer interface {
+	// C returns the channel the timer fires on
+	C() <-chan time.Time
+
+	// Reset re-arms the timer to fire after d, discarding any unreceived fire
+	Reset(d time.Duration) bool
+
+	// Stop disarms the timer
+	Stop() bool
+}
+
+// Ticker is a repeating timer created by a Clock
+type Ticker interface {
+	// C returns the channel the ticker fires on
+	C() <-chan time.Time
+
+	// Stop turns the ticker off
+	Stop()
+}
+
+// System is the Clock backed by the time package
+var System Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) Timer { return systemTimer{time.NewTimer(d)} }
+
+func (systemClock) NewTicker(d time.Duration) Ticker { return systemTicker{time.NewTicker(d)} }
+
+type systemTimer struct{ t *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time        { return t.t.C }
+func (t systemTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+func (t systemTimer) Stop() bool                 { return t.t.Stop() }
+
+type systemTicker struct{ t *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time { return t.t.C }
+func (t systemTicker) Stop()               { t.t.Stop() }
@@ -0,0 +1,106 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func received(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case at := <-ch:
+		return at, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeTimer(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := NewFake(start)
+
+	timer := clk.NewTimer(time.Minute)
+	clk.Advance(59 * time.Second)
+	if _, ok := received(timer.C()); ok {
+		t.Fatal("Timer fired early")
+	}
+
+	clk.Advance(time.Second)
+	if at, ok := received(timer.C()); !ok || !at.Equal(start.Add(time.Minute)) {
+		t.Fatalf("Expected fire at one minute, got %v %v", at, ok)
+	}
+	if clk.Armed() != 0 {
+		t.Error("Fired timer should be disarmed")
+	}
+
+	// Reset discards an unreceived fire
+	timer.Reset(time.Second)
+	clk.Advance(time.Second)
+	if wasArmed := timer.Reset(time.Hour); wasArmed {
+		t.Error("Reset of a fired timer should report it was not armed")
+	}
+	if _, ok := received(timer.C()); ok {
+		t.Error("Reset should discard the stale fire")
+	}
+
+	if !timer.Stop() || clk.Armed() != 0 {
+		t.Error("Stop should disarm the timer")
+	}
+	clk.Advance(2 * time.Hour)
+	if _, ok := received(timer.C()); ok {
+		t.Error("Stopped timer fired")
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := NewFake(start)
+	ticker := clk.NewTicker(10 * time.Second)
+
+	clk.Advance(10 * time.Second)
+	if at, ok := received(ticker.C()); !ok || !at.Equal(start.Add(10*time.Second)) {
+		t.Fatalf("Expected tick at 10s, got %v %v", at, ok)
+	}
+
+	// Unreceived ticks are dropped, not queued
+	clk.Advance(time.Minute)
+	if at, ok := received(ticker.C()); !ok || !at.Equal(start.Add(20*time.Second)) {
+		t.Errorf("Expected first pending tick, got %v %v", at, ok)
+	}
+	if _, ok := received(ticker.C()); ok {
+		t.Error("Expected dropped ticks")
+	}
+
+	ticker.Stop()
+	if clk.Armed() != 0 {
+		t.Error("Stopped ticker should be disarmed")
+	}
+}
+
+func TestFakeAdvanceToNext(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := NewFake(start)
+	if clk.AdvanceToNext() {
+		t.Error("Expected false without timers")
+	}
+
+	late := clk.NewTimer(time.Hour)
+	early := clk.NewTimer(time.Minute)
+
+	done := make(chan struct{})
+	go func() {
+		clk.BlockUntil(2)
+		close(done)
+	}()
+	<-done
+
+	clk.AdvanceToNext()
+	if !clk.Now().Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected time at earliest deadline, got %v", clk.Now())
+	}
+	if _, ok := received(early.C()); !ok {
+		t.Error("Expected earliest timer to fire")
+	}
+	if _, ok := received(late.C()); ok {
+		t.Error("Later timer fired early")
+	}
+}
@@ -0,0 +1,192 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a manually advanced Clock. Timers and tickers fire, in deadline order,
+// only when Advance or AdvanceToNext moves time past their deadlines.
+type Fake struct {
+	mu      sync.Mutex
+	changed *sync.Cond // Broadcast whenever a timer is armed or disarmed
+	now     time.Time
+	timers  map[*fakeTimer]bool // Armed timers and tickers
+}
+
+// fakeTimer is a Timer, or the core of a Ticker, on a Fake clock
+type fakeTimer struct {
+	clock    *Fake
+	ch       chan time.Time
+	deadline time.Time
+	period   time.Duration // Zero for single-shot timers
+}
+
+// NewFake creates a fake clock set to now
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now, timers: make(map[*fakeTimer]bool)}
+	f.changed = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the fake current time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// NewTimer creates a timer that fires once time has advanced by d
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: f, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// NewTicker creates a ticker that fires every time d has elapsed
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	t := &fakeTimer{clock: f, ch: make(chan time.Time, 1), period: d}
+	f.mu.Lock()
+	t.deadline = f.now.Add(d)
+	f.arm(t)
+	f.mu.Unlock()
+	return fakeTicker{t}
+}
+
+// Advance moves time forward by d, firing every timer that falls due on the way
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	target := f.now.Add(d)
+	for {
+		next := f.earliest()
+		if next == nil || next.deadline.After(target) {
+			break
+		}
+		f.now = next.deadline
+		f.fire(next)
+	}
+	f.now = target
+}
+
+// AdvanceToNext moves time to the earliest timer deadline and fires the timers due
+// then. It returns false when no timer is armed.
+func (f *Fake) AdvanceToNext() bool {
+	f.mu.Lock()
+	next := f.earliest()
+	if next == nil {
+		f.mu.Unlock()
+		return false
+	}
+	d := next.deadline.Sub(f.now)
+	f.mu.Unlock()
+
+	f.Advance(max(d, 0))
+	return true
+}
+
+// Armed returns the number of timers and tickers waiting to fire
+func (f *Fake) Armed() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// BlockUntil waits until at least n timers and tickers are armed, which is how a
+// test knows the code under test has gone back to sleep
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.timers) < n {
+		f.changed.Wait()
+	}
+}
+
+// earliest returns the armed timer with the earliest deadline. Caller holds mu.
+func (f *Fake) earliest() *fakeTimer {
+	var next *fakeTimer
+	for t := range f.timers {
+		if next == nil || t.deadline.Before(next.deadline) {
+			next = t
+		}
+	}
+	return next
+}
+
+// fire delivers a tick without blocking, dropping it if the last one is unreceived
+// like time.Ticker does. Caller holds mu.
+func (f *Fake) fire(t *fakeTimer) {
+	select {
+	case t.ch <- f.now:
+	default:
+	}
+
+	if t.period > 0 {
+		t.deadline = t.deadline.Add(t.period)
+		return
+	}
+	delete(f.timers, t)
+	f.changed.Broadcast()
+}
+
+// arm registers a timer. Caller holds mu.
+func (f *Fake) arm(t *fakeTimer) {
+	f.timers[t] = true
+	f.changed.Broadcast()
+}
+
+// disarm unregisters a timer and reports whether it was armed. Caller holds mu.
+func (f *Fake) disarm(t *fakeTimer) bool {
+	armed := f.timers[t]
+	delete(f.timers, t)
+	f.changed.Broadcast()
+	return armed
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+// Reset re-arms the timer to fire after d, discarding any unreceived fire
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	f := t.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	armed := f.disarm(t)
+	drain(t.ch)
+	t.deadline = f.now.Add(d)
+	if d <= 0 {
+		f.fire(t)
+		return armed
+	}
+	f.arm(t)
+	return armed
+}
+
+// Stop disarms the timer or ticker
+func (t *fakeTimer) Stop() bool {
+	f := t.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	armed := f.disarm(t)
+	drain(t.ch)
+	return armed
+}
+
+// fakeTicker adapts fakeTimer to the Ticker interface
+type fakeTicker struct{ *fakeTimer }
+
+// Stop turns the ticker off
+func (t fakeTicker) Stop() { t.fakeTimer.Stop() }
+
+// drain discards an unreceived fire
+func drain(ch chan time.Time) {
+	select {
+	case <-ch:
+	default:
+	}
+}
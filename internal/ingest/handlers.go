@@ -0,0 +1,183 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"windz/internal/units"
+)
+
+// maxBodySize limits JSON uploads
+const maxBodySize = 1 << 20
+
+// wuTimeFormat is the layout of the Weather Underground dateutc parameter
+const wuTimeFormat = "2006-01-02 15:04:05"
+
+// wuMissing is the value Weather Underground uploads send for a missing sensor
+const wuMissing = -9999
+
+// RegisterHandlers registers the ingest handlers
+func RegisterHandlers(mux *http.ServeMux, mgr Manager) {
+	mux.HandleFunc("/api/ingest/{station_id}", handleIngest(mgr))
+}
+
+// handleIngest accepts JSON readings (POST) and Weather Underground / Ecowitt uploads (GET)
+func handleIngest(mgr Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stationID := r.PathValue("station_id")
+
+		switch r.Method {
+		case http.MethodPost:
+			handleJSONUpload(w, r, mgr, stationID)
+		case http.MethodGet:
+			handleWUUpload(w, r, mgr, stationID)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// handleJSONUpload accepts a reading or an array of readings authorized by a bearer token
+func handleJSONUpload(w http.ResponseWriter, r *http.Request, mgr Manager, stationID string) {
+	key, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !mgr.Authenticate(stationID, key) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="windz ingest"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "Invalid body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	readings, err := parseReadings(body)
+	if err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !ingest(w, mgr, stationID, readings) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(map[string]int{"accepted": len(readings)}); err != nil {
+		log.Printf("Error encoding ingest response: %v", err)
+	}
+}
+
+// parseReadings decodes a single reading object or an array of readings
+func parseReadings(body []byte) ([]Reading, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var readings []Reading
+		err := json.Unmarshal(body, &readings)
+		return readings, err
+	}
+
+	var reading Reading
+	if err := json.Unmarshal(body, &reading); err != nil {
+		return nil, err
+	}
+	return []Reading{reading}, nil
+}
+
+// handleWUUpload accepts the Weather Underground upload protocol, which Ecowitt and most
+// consumer stations can send to a custom server
+func handleWUUpload(w http.ResponseWriter, r *http.Request, mgr Manager, stationID string) {
+	query := r.URL.Query()
+	if id := query.Get("ID"); id != "" && id != stationID {
+		http.Error(w, "ID does not match the station", http.StatusBadRequest)
+		return
+	}
+	if !mgr.Authenticate(stationID, query.Get("PASSWORD")) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	reading, err := parseWUReading(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !ingest(w, mgr, stationID, []Reading{reading}) {
+		return
+	}
+
+	// Stations check for the same answer Weather Underground gives
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "success")
+}
+
+// parseWUReading reads the wind parameters of a Weather Underground upload
+func parseWUReading(query url.Values) (Reading, error) {
+	var reading Reading
+
+	if value := query.Get("dateutc"); value != "" && value != "now" {
+		timestamp, err := time.Parse(wuTimeFormat, value)
+		if err != nil {
+			return Reading{}, fmt.Errorf("invalid dateutc %q", value)
+		}
+		reading.Timestamp = timestamp
+	}
+
+	speed, err := parseWUValue(query, "windspeedmph", units.MilesPerHour.ToMS)
+	if err != nil {
+		return Reading{}, err
+	}
+	if speed == nil {
+		return Reading{}, fmt.Errorf("windspeedmph is required")
+	}
+	reading.WindSpeed = speed
+
+	if reading.WindGust, err = parseWUValue(query, "windgustmph", units.MilesPerHour.ToMS); err != nil {
+		return Reading{}, err
+	}
+	if reading.WindDirection, err = parseWUValue(query, "winddir", nil); err != nil {
+		return Reading{}, err
+	}
+	return reading, nil
+}
+
+// parseWUValue parses an optional numeric parameter and converts it when convert is set,
+// nil when missing or reported as wuMissing
+func parseWUValue(query url.Values, name string, convert func(float64) float64) (*float64, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", name, value)
+	}
+	if parsed == wuMissing {
+		return nil, nil
+	}
+	if convert != nil {
+		parsed = convert(parsed)
+	}
+	return &parsed, nil
+}
+
+// ingest hands readings to the manager and writes the error response if they are rejected.
+// Callers authenticate first, so unknown stations have already been answered with 401
+// and are not told apart from a wrong key.
+func ingest(w http.ResponseWriter, mgr Manager, stationID string, readings []Reading) bool {
+	if err := mgr.Ingest(stationID, readings); err != nil {
+		log.Printf("Rejected upload for ingest station %s: %v", stationID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package ingest
+
+import (
+	"errors"
+	"time"
+	"windz/internal/observations"
+)
+
+// SourceName is the source marker of stations fed by the ingest API
+const SourceName = "ingest"
+
+// ErrUnknownStation is returned for readings of a station that does not accept uploads
+var ErrUnknownStation = errors.New("unknown ingest station")
+
+// Manager defines the interface for readings uploaded by private weather stations. It is
+// the observation source of the stations it registers, so uploads take the same path
+// through history, store, SSE and alerts as FMI data.
+type Manager interface {
+	observations.Source
+
+	// Authenticate reports whether key is the upload key of an ingest station
+	Authenticate(stationID, key string) bool
+
+	// Ingest validates a station's readings and queues them for the observation manager
+	Ingest(stationID string, readings []Reading) error
+
+	// SetReceiveCallback sets a callback called after a station's readings were queued
+	SetReceiveCallback(callback func(stationID string))
+}
+
+// Station is a private station allowed to upload readings
+type Station struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Region    string  `json:"region"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Key       string  `json:"key,omitempty"` // Upload key, sent as bearer token or WU PASSWORD
+}
+
+// Reading is a single uploaded measurement in SI units
+type Reading struct {
+	Timestamp     time.Time `json:"timestamp"`                // Zero means the time of upload
+	WindSpeed     *float64  `json:"wind_speed"`               // m/s, required
+	WindGust      *float64  `json:"wind_gust,omitempty"`      // m/s, the speed when missing
+	WindDirection *float64  `json:"wind_direction,omitempty"` // Degrees
+}
@@ -0,0 +1,231 @@
+package ingest
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sync"
+	"time"
+	"windz/internal/clock"
+	"windz/internal/observations"
+	"windz/internal/stations"
+)
+
+// Validation limits and buffering
+const (
+	maxReadingAge   = 2 * time.Hour // Older readings fall outside the live polling window
+	maxClockSkew    = time.Minute   // Tolerated drift of the uploader's clock into the future
+	bufferRetention = 3 * time.Hour // How long readings stay available to the observation manager
+	maxWindSpeed    = 100.0         // m/s, the plausibility limit applied to FMI data as well
+	maxBatch        = 100           // Stations per Fetch; readings are in memory
+)
+
+// Config holds the ingest manager settings
+type Config struct {
+	Stations []Station
+	Clock    clock.Clock // Time source for validation and buffering (nil uses the system clock)
+}
+
+// manager implements the ingest Manager interface
+type manager struct {
+	stations map[string]Station
+	clock    clock.Clock
+
+	mu       sync.Mutex
+	readings map[string][]observations.FMIWindObservation // Buffered readings per station in time order
+
+	callbackMu      sync.RWMutex
+	receiveCallback func(stationID string)
+}
+
+// NewManager creates a new ingest manager and registers its stations with the station
+// manager, marked with the ingest source
+func NewManager(stationMgr stations.Manager, cfg Config) (Manager, error) {
+	if cfg.Clock == nil {
+		cfg.Clock = clock.System
+	}
+
+	m := &manager{
+		stations: make(map[string]Station),
+		clock:    cfg.Clock,
+		readings: make(map[string][]observations.FMIWindObservation),
+	}
+
+	for _, station := range cfg.Stations {
+		if station.Key == "" {
+			return nil, fmt.Errorf("ingest station %q has no upload key", station.ID)
+		}
+		err := stationMgr.AddStation(stations.Station{
+			ID:        station.ID,
+			Name:      station.Name,
+			Region:    station.Region,
+			Latitude:  station.Latitude,
+			Longitude: station.Longitude,
+			Source:    SourceName,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to register ingest station: %w", err)
+		}
+		m.stations[station.ID] = station
+	}
+
+	return m, nil
+}
+
+// LoadStations reads ingest station definitions from a JSON file
+func LoadStations(path string) ([]Station, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ingest stations file: %w", err)
+	}
+
+	var result []Station
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse ingest stations file: %w", err)
+	}
+	return result, nil
+}
+
+// Authenticate reports whether key is the upload key of an ingest station
+func (m *manager) Authenticate(stationID, key string) bool {
+	station, exists := m.stations[stationID]
+	if !exists || key == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(key), []byte(station.Key)) == 1
+}
+
+// SetReceiveCallback sets a callback called after a station's readings were queued
+func (m *manager) SetReceiveCallback(callback func(stationID string)) {
+	m.callbackMu.Lock()
+	defer m.callbackMu.Unlock()
+	m.receiveCallback = callback
+}
+
+// Ingest validates a station's readings and queues them for the observation manager.
+// Either every reading is accepted or none is.
+func (m *manager) Ingest(stationID string, readings []Reading) error {
+	if _, exists := m.stations[stationID]; !exists {
+		return ErrUnknownStation
+	}
+	if len(readings) == 0 {
+		return fmt.Errorf("no readings")
+	}
+
+	now := m.clock.Now()
+	samples := make([]observations.FMIWindObservation, len(readings))
+	for i, reading := range readings {
+		sample, err := validateReading(reading, now)
+		if err != nil {
+			return fmt.Errorf("reading %d: %w", i+1, err)
+		}
+		samples[i] = sample
+	}
+
+	m.mu.Lock()
+	m.readings[stationID] = mergeReadings(m.readings[stationID], samples, now.Add(-bufferRetention))
+	m.mu.Unlock()
+
+	m.callbackMu.RLock()
+	callback := m.receiveCallback
+	m.callbackMu.RUnlock()
+	if callback != nil {
+		callback(stationID)
+	}
+	return nil
+}
+
+// validateReading checks a reading's plausibility and converts it to a sample
+func validateReading(reading Reading, now time.Time) (observations.FMIWindObservation, error) {
+	timestamp := reading.Timestamp
+	if timestamp.IsZero() {
+		timestamp = now
+	}
+	timestamp = timestamp.UTC().Truncate(time.Second)
+	if timestamp.After(now.Add(maxClockSkew)) {
+		return observations.FMIWindObservation{}, fmt.Errorf("timestamp %s is in the future", timestamp.Format(time.RFC3339))
+	}
+	if timestamp.Before(now.Add(-maxReadingAge)) {
+		return observations.FMIWindObservation{}, fmt.Errorf("timestamp %s is older than %s", timestamp.Format(time.RFC3339), maxReadingAge)
+	}
+
+	if reading.WindSpeed == nil {
+		return observations.FMIWindObservation{}, fmt.Errorf("wind_speed is required")
+	}
+	sample := observations.FMIWindObservation{
+		Timestamp: timestamp,
+		WindSpeed: *reading.WindSpeed,
+		WindGust:  *reading.WindSpeed,
+	}
+	if reading.WindGust != nil {
+		sample.WindGust = *reading.WindGust
+	}
+	if reading.WindDirection != nil {
+		sample.WindDirection = *reading.WindDirection
+	}
+
+	if !validSpeed(sample.WindSpeed) {
+		return observations.FMIWindObservation{}, fmt.Errorf("wind_speed %v out of range", sample.WindSpeed)
+	}
+	if !validSpeed(sample.WindGust) {
+		return observations.FMIWindObservation{}, fmt.Errorf("wind_gust %v out of range", sample.WindGust)
+	}
+	if math.IsNaN(sample.WindDirection) || sample.WindDirection < 0 || sample.WindDirection > 360 {
+		return observations.FMIWindObservation{}, fmt.Errorf("wind_direction %v out of range", sample.WindDirection)
+	}
+	return sample, nil
+}
+
+func validSpeed(speed float64) bool {
+	return speed >= 0 && speed < maxWindSpeed // Also false for NaN
+}
+
+// mergeReadings adds samples to a station's buffer, replacing readings with the same
+// timestamp and dropping those before cutoff. The result is in time order.
+func mergeReadings(buffer, samples []observations.FMIWindObservation, cutoff time.Time) []observations.FMIWindObservation {
+	for _, sample := range samples {
+		i, found := slices.BinarySearchFunc(buffer, sample.Timestamp, func(obs observations.FMIWindObservation, ts time.Time) int {
+			return obs.Timestamp.Compare(ts)
+		})
+		if found {
+			buffer[i] = sample
+			continue
+		}
+		buffer = slices.Insert(buffer, i, sample)
+	}
+
+	keep := 0
+	for keep < len(buffer) && buffer[keep].Timestamp.Before(cutoff) {
+		keep++
+	}
+	return slices.Clone(buffer[keep:])
+}
+
+// Fetch returns the buffered readings of the stations within [start, end]
+func (m *manager) Fetch(stationIDs []string, start, end time.Time) (map[string][]observations.FMIWindObservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results := make(map[string][]observations.FMIWindObservation)
+	for _, stationID := range stationIDs {
+		for _, sample := range m.readings[stationID] {
+			if !sample.Timestamp.Before(start) && !sample.Timestamp.After(end) {
+				results[stationID] = append(results[stationID], sample)
+			}
+		}
+	}
+	return results, nil
+}
+
+// Cadence is unknown: uploaders report anywhere from every few seconds to every few minutes
+func (m *manager) Cadence(stationID string) time.Duration {
+	return 0
+}
+
+// MaxBatch returns how many stations one Fetch may ask for
+func (m *manager) MaxBatch() int {
+	return maxBatch
+}
@@ -0,0 +1,261 @@
+package ingest
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"windz/internal/clock"
+	"windz/internal/observations"
+	"windz/internal/sse"
+	"windz/internal/stations"
+)
+
+var pier = Station{ID: "pier", Name: "Club pier", Region: "Helsinki", Latitude: 60.1530, Longitude: 24.9650, Key: "s3cret"}
+
+func ptr(v float64) *float64 { return &v }
+
+func newTestManager(t *testing.T, clk clock.Clock) (Manager, stations.Manager) {
+	t.Helper()
+	stationMgr := stations.NewManager()
+	mgr, err := NewManager(stationMgr, Config{Stations: []Station{pier}, Clock: clk})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	return mgr, stationMgr
+}
+
+func TestNewManager(t *testing.T) {
+	_, stationMgr := newTestManager(t, nil)
+	station, exists := stationMgr.GetStation("pier")
+	if !exists || station.Source != SourceName || station.Name != "Club pier" {
+		t.Errorf("Expected the pier registered as an ingest station, got %+v (%v)", station, exists)
+	}
+
+	for name, station := range map[string]Station{
+		"no key":       {ID: "other", Name: "Other"},
+		"FMI conflict": {ID: "100996", Name: "Harmaja", Key: "x"},
+		"no name":      {ID: "other", Key: "x"},
+	} {
+		if _, err := NewManager(stations.NewManager(), Config{Stations: []Station{station}}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestIngest(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 30, 0, time.UTC)
+	clk := clock.NewFake(now)
+	mgr, _ := newTestManager(t, clk)
+
+	var received []string
+	mgr.SetReceiveCallback(func(stationID string) { received = append(received, stationID) })
+
+	if !mgr.Authenticate("pier", "s3cret") || mgr.Authenticate("pier", "wrong") || mgr.Authenticate("pier", "") || mgr.Authenticate("100996", "s3cret") {
+		t.Error("Unexpected authentication result")
+	}
+
+	invalid := map[string]Reading{
+		"no speed":        {WindGust: ptr(5)},
+		"negative speed":  {WindSpeed: ptr(-1)},
+		"huge gust":       {WindSpeed: ptr(5), WindGust: ptr(150)},
+		"NaN speed":       {WindSpeed: ptr(math.NaN())},
+		"bad direction":   {WindSpeed: ptr(5), WindDirection: ptr(361)},
+		"future":          {WindSpeed: ptr(5), Timestamp: now.Add(5 * time.Minute)},
+		"too old":         {WindSpeed: ptr(5), Timestamp: now.Add(-3 * time.Hour)},
+		"NaN direction":   {WindSpeed: ptr(5), WindDirection: ptr(math.NaN())},
+		"infinite speed:": {WindSpeed: ptr(math.Inf(1))},
+	}
+	for name, reading := range invalid {
+		if err := mgr.Ingest("pier", []Reading{{WindSpeed: ptr(3)}, reading}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if err := mgr.Ingest("100996", []Reading{{WindSpeed: ptr(3)}}); err != ErrUnknownStation {
+		t.Errorf("Expected ErrUnknownStation, got %v", err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("Rejected uploads must not be queued, got callbacks %v", received)
+	}
+
+	// Out-of-order readings are sorted, duplicates replaced and the gust defaults to the speed
+	err := mgr.Ingest("pier", []Reading{
+		{WindSpeed: ptr(4), WindGust: ptr(6), WindDirection: ptr(200), Timestamp: now.Add(-time.Minute)},
+		{WindSpeed: ptr(5), WindDirection: ptr(210)},
+		{WindSpeed: ptr(3), Timestamp: now.Add(-2 * time.Minute)},
+	})
+	if err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+	if err := mgr.Ingest("pier", []Reading{{WindSpeed: ptr(4.5), WindGust: ptr(7), Timestamp: now.Add(-time.Minute)}}); err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+	if strings.Join(received, ",") != "pier,pier" {
+		t.Errorf("Expected a callback per upload, got %v", received)
+	}
+
+	results, _ := mgr.Fetch([]string{"pier", "100996"}, now.Add(-90*time.Second), now)
+	got := results["pier"]
+	if len(got) != 2 || len(results) != 1 {
+		t.Fatalf("Expected two readings in the window, got %+v", results)
+	}
+	if got[0].WindSpeed != 4.5 || got[0].WindGust != 7 || got[0].WindDirection != 0 {
+		t.Errorf("Expected the replaced reading first, got %+v", got[0])
+	}
+	if !got[1].Timestamp.Equal(now.Truncate(time.Second)) || got[1].WindGust != 5 || got[1].WindDirection != 210 {
+		t.Errorf("Expected the upload time and speed as gust, got %+v", got[1])
+	}
+
+	// Readings leave the buffer after its retention
+	clk.Advance(bufferRetention + time.Second)
+	if err := mgr.Ingest("pier", []Reading{{WindSpeed: ptr(2)}}); err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+	results, _ = mgr.Fetch([]string{"pier"}, now.Add(-time.Hour), clk.Now())
+	if len(results["pier"]) != 1 {
+		t.Errorf("Expected old readings pruned, got %+v", results["pier"])
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	mgr, _ := newTestManager(t, nil)
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, mgr)
+	earlier := time.Now().UTC().Add(-2 * time.Minute).Format("2006-01-02+15:04:05")
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		token  string
+		body   string
+		status int
+		want   string
+	}{
+		{"JSON reading", "POST", "/api/ingest/pier", "s3cret", `{"wind_speed": 5.2, "wind_gust": 7.1, "wind_direction": 240}`, http.StatusAccepted, `"accepted":1`},
+		{"JSON array", "POST", "/api/ingest/pier", "s3cret", `[{"wind_speed": 5}, {"wind_speed": 6}]`, http.StatusAccepted, `"accepted":2`},
+		{"wrong token", "POST", "/api/ingest/pier", "nope", `{"wind_speed": 5}`, http.StatusUnauthorized, ""},
+		{"unknown station", "POST", "/api/ingest/100996", "s3cret", `{"wind_speed": 5}`, http.StatusUnauthorized, ""},
+		{"invalid JSON", "POST", "/api/ingest/pier", "s3cret", `{"wind_speed": "fast"}`, http.StatusBadRequest, ""},
+		{"invalid reading", "POST", "/api/ingest/pier", "s3cret", `{"wind_gust": 5}`, http.StatusBadRequest, "wind_speed is required"},
+		{"WU upload", "GET", "/api/ingest/pier?ID=pier&PASSWORD=s3cret&dateutc=" + earlier + "&windspeedmph=10&windgustmph=15&winddir=225&tempf=40", "", "", http.StatusOK, "success"},
+		{"WU now", "GET", "/api/ingest/pier?PASSWORD=s3cret&dateutc=now&windspeedmph=3", "", "", http.StatusOK, "success"},
+		{"WU wrong ID", "GET", "/api/ingest/pier?ID=other&PASSWORD=s3cret&windspeedmph=10", "", "", http.StatusBadRequest, ""},
+		{"WU wrong password", "GET", "/api/ingest/pier?PASSWORD=nope&windspeedmph=10", "", "", http.StatusUnauthorized, ""},
+		{"WU without wind", "GET", "/api/ingest/pier?PASSWORD=s3cret&tempf=40", "", "", http.StatusBadRequest, "windspeedmph is required"},
+		{"WU missing sensors", "GET", "/api/ingest/pier?PASSWORD=s3cret&windspeedmph=4&windgustmph=-9999&winddir=-9999", "", "", http.StatusOK, "success"},
+		{"WU missing wind", "GET", "/api/ingest/pier?PASSWORD=s3cret&windspeedmph=-9999", "", "", http.StatusBadRequest, "windspeedmph is required"},
+		{"WU bad number", "GET", "/api/ingest/pier?PASSWORD=s3cret&windspeedmph=x", "", "", http.StatusBadRequest, "invalid windspeedmph"},
+		{"method", "DELETE", "/api/ingest/pier", "s3cret", "", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.status || !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("Got %d %q, want %d containing %q", rec.Code, rec.Body.String(), tt.status, tt.want)
+			}
+		})
+	}
+
+	// mph are converted to m/s
+	results, _ := mgr.Fetch([]string{"pier"}, time.Now().Add(-time.Hour), time.Now().Add(time.Minute))
+	found := false
+	for _, obs := range results["pier"] {
+		if obs.WindDirection == 225 {
+			found = true
+			if math.Abs(obs.WindSpeed-4.4704) > 1e-9 || math.Abs(obs.WindGust-6.7056) > 1e-9 {
+				t.Errorf("Expected converted WU speeds, got %+v", obs)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("WU reading not buffered: %+v", results["pier"])
+	}
+}
+
+// emptySource stands in for FMI so the pipeline test makes no network requests
+type emptySource struct{}
+
+func (emptySource) Fetch(stationIDs []string, start, end time.Time) (map[string][]observations.FMIWindObservation, error) {
+	return map[string][]observations.FMIWindObservation{}, nil
+}
+
+func (emptySource) Cadence(stationID string) time.Duration { return 0 }
+
+func (emptySource) MaxBatch() int { return 20 }
+
+// countingSSE records broadcast messages
+type countingSSE struct {
+	sse.Manager
+	messages chan sse.Message
+}
+
+func (s *countingSSE) Broadcast(message sse.Message) {
+	select {
+	case s.messages <- message:
+	default:
+	}
+}
+
+func (s *countingSSE) Subscribers(stationID string) int { return 0 }
+
+func TestPipeline(t *testing.T) {
+	mgr, stationMgr := newTestManager(t, nil)
+	sseMgr := &countingSSE{Manager: sse.NewManager(), messages: make(chan sse.Message, 1000)}
+	dir := t.TempDir()
+	obsMgr := observations.NewManagerWithConfig(stationMgr, sseMgr, observations.Config{
+		StateFile:    filepath.Join(dir, "state.json"),
+		WindDataFile: filepath.Join(dir, "wind.json"),
+		Sources: map[string]observations.Source{
+			observations.SourceFMI: emptySource{},
+			SourceName:             mgr,
+		},
+	})
+	mgr.SetReceiveCallback(func(stationID string) { obsMgr.Refresh(stationID) })
+
+	latest := make(chan observations.WindObservation, 10)
+	obsMgr.AddObservationListener(func(obs observations.WindObservation) { latest <- obs })
+
+	if err := obsMgr.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer obsMgr.Stop()
+
+	if err := mgr.Ingest("pier", []Reading{{WindSpeed: ptr(6.5), WindGust: ptr(9), WindDirection: ptr(250)}}); err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+
+	// The upload is polled right away and takes the FMI data path
+	select {
+	case obs := <-latest:
+		if obs.StationID != "pier" || obs.StationName != "Club pier" || obs.WindSpeed != 6.5 || obs.WindGust != 9 {
+			t.Errorf("Unexpected observation %+v", obs)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Upload did not reach the observation listeners")
+	}
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case message := <-sseMgr.messages:
+			if message.Type == "data" && message.StationID == "pier" {
+				if history := obsMgr.GetHistory("pier", time.Now().Add(-time.Hour), time.Now()); len(history) != 1 {
+					t.Errorf("Expected the reading in history, got %+v", history)
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatal("Upload was not broadcast to SSE clients")
+		}
+	}
+}
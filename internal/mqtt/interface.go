@@ -0,0 +1,44 @@
+package mqtt
+
+import (
+	"context"
+	"windz/internal/sse"
+)
+
+// Manager defines the interface for the MQTT publisher
+type Manager interface {
+	// Start connects to the broker in the background and keeps reconnecting until stopped
+	Start(ctx context.Context) error
+
+	// Stop publishes the offline availability state and disconnects
+	Stop() error
+
+	// Enqueue queues a broadcast message for publishing without blocking
+	Enqueue(message sse.Message)
+
+	// Connected reports whether the broker connection is currently up
+	Connected() bool
+}
+
+// discoveryConfig is a Home Assistant MQTT discovery sensor configuration
+type discoveryConfig struct {
+	Name              string          `json:"name"`
+	UniqueID          string          `json:"unique_id"`
+	StateTopic        string          `json:"state_topic"`
+	ValueTemplate     string          `json:"value_template"`
+	UnitOfMeasurement string          `json:"unit_of_measurement,omitempty"`
+	DeviceClass       string          `json:"device_class,omitempty"`
+	StateClass        string          `json:"state_class,omitempty"`
+	Icon              string          `json:"icon,omitempty"`
+	AvailabilityTopic string          `json:"availability_topic"`
+	Device            discoveryDevice `json:"device"`
+}
+
+// discoveryDevice groups the sensors of one station in Home Assistant
+type discoveryDevice struct {
+	Identifiers   []string `json:"identifiers"`
+	Name          string   `json:"name"`
+	Manufacturer  string   `json:"manufacturer"`
+	Model         string   `json:"model"`
+	SuggestedArea string   `json:"suggested_area,omitempty"`
+}
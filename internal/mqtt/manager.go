@@ -0,0 +1,391 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"windz/internal/observations"
+	"windz/internal/sse"
+	"windz/internal/stations"
+)
+
+// Publisher defaults
+const (
+	DefaultTopicPrefix     = "windz"
+	DefaultDiscoveryPrefix = "homeassistant"
+	DefaultClientID        = "windz"
+	DefaultKeepAlive       = 60 * time.Second
+	DefaultReconnectDelay  = time.Second
+	DefaultQueueSize       = 256
+
+	maxReconnectDelay = time.Minute
+	maxKeepAlive      = 65535 * time.Second // CONNECT carries the keep alive as 16-bit seconds
+	dialTimeout       = 10 * time.Second
+	writeTimeout      = 10 * time.Second
+)
+
+// Config holds the MQTT publisher settings
+type Config struct {
+	Broker          string           // host:port, optionally prefixed with tcp:// or mqtt://
+	ClientID        string           // Empty uses the default
+	Username        string           // Optional
+	Password        string           // Optional
+	TopicPrefix     string           // Empty uses the default
+	DiscoveryPrefix string           // Home Assistant discovery prefix (empty uses the default, "-" disables)
+	KeepAlive       time.Duration    // Rounded down to whole seconds, at least one (0 uses the default)
+	ReconnectDelay  time.Duration    // Initial delay between connection attempts, doubled up to a minute (0 uses the default)
+	QueueSize       int              // 0 uses the default
+	Stations        stations.Manager // Optional; names each device's manufacturer after the station's source
+}
+
+// manager implements the MQTT Manager interface
+type manager struct {
+	address         string
+	clientID        string
+	username        string
+	password        string
+	topicPrefix     string
+	discoveryPrefix string
+	keepAlive       time.Duration
+	reconnectDelay  time.Duration
+	stations        stations.Manager
+
+	queue     chan sse.Message
+	connected atomic.Bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager creates a new MQTT publisher instance
+func NewManager(cfg Config) (Manager, error) {
+	address, err := brokerAddress(cfg.Broker)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ClientID == "" {
+		cfg.ClientID = DefaultClientID
+	}
+	if cfg.TopicPrefix == "" {
+		cfg.TopicPrefix = DefaultTopicPrefix
+	}
+	switch cfg.DiscoveryPrefix {
+	case "":
+		cfg.DiscoveryPrefix = DefaultDiscoveryPrefix
+	case "-":
+		cfg.DiscoveryPrefix = ""
+	}
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = DefaultKeepAlive
+	}
+	cfg.KeepAlive = min(max(cfg.KeepAlive.Truncate(time.Second), time.Second), maxKeepAlive)
+	if cfg.ReconnectDelay <= 0 {
+		cfg.ReconnectDelay = DefaultReconnectDelay
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+
+	return &manager{
+		address:         address,
+		clientID:        cfg.ClientID,
+		username:        cfg.Username,
+		password:        cfg.Password,
+		topicPrefix:     strings.TrimSuffix(cfg.TopicPrefix, "/"),
+		discoveryPrefix: strings.TrimSuffix(cfg.DiscoveryPrefix, "/"),
+		keepAlive:       cfg.KeepAlive,
+		reconnectDelay:  cfg.ReconnectDelay,
+		stations:        cfg.Stations,
+		queue:           make(chan sse.Message, cfg.QueueSize),
+	}, nil
+}
+
+// brokerAddress normalizes a broker URL to host:port
+func brokerAddress(broker string) (string, error) {
+	address := broker
+	for _, scheme := range []string{"tcp://", "mqtt://"} {
+		address = strings.TrimPrefix(address, scheme)
+	}
+	if address == "" || strings.Contains(address, "://") {
+		return "", fmt.Errorf("invalid MQTT broker %q", broker)
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "1883")
+	}
+	return address, nil
+}
+
+// Start connects to the broker in the background and keeps reconnecting until stopped
+func (m *manager) Start(ctx context.Context) error {
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.wg.Add(1)
+	go m.run(ctx)
+	log.Printf("MQTT publisher started for broker %s", m.address)
+	return nil
+}
+
+// Stop publishes the offline availability state and disconnects
+func (m *manager) Stop() error {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+	return nil
+}
+
+// Enqueue queues a broadcast message for publishing without blocking
+func (m *manager) Enqueue(message sse.Message) {
+	if message.Type != "data" && message.Type != "status" {
+		return
+	}
+	select {
+	case m.queue <- message:
+	default:
+		log.Printf("MQTT queue full, dropping %s message for station %s", message.Type, message.StationID)
+	}
+}
+
+// Connected reports whether the broker connection is currently up
+func (m *manager) Connected() bool {
+	return m.connected.Load()
+}
+
+// availabilityTopic is the retained online/offline topic, also the last will
+func (m *manager) availabilityTopic() string {
+	return m.topicPrefix + "/availability"
+}
+
+// run keeps a broker session alive, reconnecting with backoff until ctx is done
+func (m *manager) run(ctx context.Context) {
+	defer m.wg.Done()
+
+	delay := m.reconnectDelay
+	var pending *sse.Message // Message whose publish failed, retried after reconnecting
+	for {
+		conn, err := m.connect(ctx)
+		if err == nil {
+			log.Printf("MQTT connected to %s", m.address)
+			delay = m.reconnectDelay
+			m.connected.Store(true)
+			pending, err = m.session(ctx, conn, pending)
+			m.connected.Store(false)
+			conn.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("MQTT connection to %s lost: %v (retrying in %v)", m.address, err, delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// connect dials the broker and completes the CONNECT handshake
+func (m *manager) connect(ctx context.Context) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", m.address)
+	if err != nil {
+		return nil, err
+	}
+
+	connectPacket := encodeConnect(connectOptions{
+		clientID:    m.clientID,
+		username:    m.username,
+		password:    m.password,
+		keepAlive:   uint16(m.keepAlive / time.Second),
+		willTopic:   m.availabilityTopic(),
+		willPayload: []byte("offline"),
+		willRetain:  true,
+	})
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+	if _, err := conn.Write(connectPacket); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	header, body, err := readPacket(bufio.NewReader(conn))
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("reading CONNACK: %w", err)
+	}
+	if header&0xF0 != packetConnack {
+		conn.Close()
+		return nil, fmt.Errorf("expected CONNACK, got packet type %#x", header)
+	}
+	if err := checkConnack(body); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// session publishes queued messages over an established connection until it fails
+// or ctx is done. It returns the message that could not be published, if any.
+func (m *manager) session(ctx context.Context, conn net.Conn, pending *sse.Message) (*sse.Message, error) {
+	// The reader only consumes PINGRESP packets and detects broker disconnects
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(conn)
+		for {
+			if _, _, err := readPacket(reader); err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	write := func(packet []byte) error {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		_, err := conn.Write(packet)
+		return err
+	}
+
+	if err := write(encodePublish(m.availabilityTopic(), []byte("online"), true)); err != nil {
+		return pending, err
+	}
+
+	discovered := make(map[string]bool) // Discovery is republished on every connection
+	publish := func(message sse.Message) error {
+		for _, packet := range m.packets(message, discovered) {
+			if err := write(packet); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if pending != nil {
+		if err := publish(*pending); err != nil {
+			return pending, err
+		}
+	}
+
+	ping := time.NewTicker(m.keepAlive / 2)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			write(encodePublish(m.availabilityTopic(), []byte("offline"), true))
+			write(encodePacket(packetDisconnect, nil))
+			return nil, ctx.Err()
+		case err := <-readErr:
+			return nil, err
+		case <-ping.C:
+			if err := write(encodePacket(packetPingreq, nil)); err != nil {
+				return nil, err
+			}
+		case message := <-m.queue:
+			if err := publish(message); err != nil {
+				return &message, err
+			}
+		}
+	}
+}
+
+// packets returns the PUBLISH packets for a broadcast message, preceded by the
+// station's discovery configuration the first time it is seen on a connection
+func (m *manager) packets(message sse.Message, discovered map[string]bool) [][]byte {
+	var packets [][]byte
+
+	switch message.Type {
+	case "data":
+		obs, ok := message.Data.(observations.WindObservation)
+		if !ok {
+			return nil
+		}
+		if m.discoveryPrefix != "" && !discovered[obs.StationID] {
+			packets = append(packets, m.discoveryPackets(obs)...)
+			discovered[obs.StationID] = true
+		}
+		payload, err := json.Marshal(obs)
+		if err != nil {
+			log.Printf("Error encoding MQTT observation for station %s: %v", obs.StationID, err)
+			return packets
+		}
+		packets = append(packets, encodePublish(m.stationTopic(obs.StationID, "wind"), payload, true))
+
+	case "status":
+		payload, err := json.Marshal(message.Data)
+		if err != nil {
+			log.Printf("Error encoding MQTT status for station %s: %v", message.StationID, err)
+			return nil
+		}
+		packets = append(packets, encodePublish(m.stationTopic(message.StationID, "status"), payload, true))
+	}
+
+	return packets
+}
+
+// stationTopic returns <prefix>/<station_id>/<name>
+func (m *manager) stationTopic(stationID, name string) string {
+	return m.topicPrefix + "/" + stationID + "/" + name
+}
+
+// manufacturer names the maker of a station's device after the source feeding it
+func (m *manager) manufacturer(stationID string) string {
+	if m.stations != nil {
+		if station, ok := m.stations.GetStation(stationID); ok && station.Source != "" && station.Source != observations.SourceFMI {
+			return "Private station (" + station.Source + ")"
+		}
+	}
+	return "Finnish Meteorological Institute"
+}
+
+// discoveryPackets returns retained Home Assistant sensor configurations for a station
+func (m *manager) discoveryPackets(obs observations.WindObservation) [][]byte {
+	sensors := []struct {
+		key, name, unit, deviceClass, icon string
+	}{
+		{"wind_speed", "Wind speed", "m/s", "wind_speed", ""},
+		{"wind_gust", "Wind gust", "m/s", "wind_speed", ""},
+		{"max_gust_60m", "Max gust 60 min", "m/s", "wind_speed", ""},
+		{"wind_direction", "Wind direction", "°", "", "mdi:compass-outline"},
+	}
+
+	nodeID := m.clientID + "_" + obs.StationID
+	device := discoveryDevice{
+		Identifiers:   []string{nodeID},
+		Name:          obs.StationName,
+		Manufacturer:  m.manufacturer(obs.StationID),
+		Model:         "Weather station " + obs.StationID,
+		SuggestedArea: obs.Region,
+	}
+
+	var packets [][]byte
+	for _, sensor := range sensors {
+		config := discoveryConfig{
+			Name:              sensor.name,
+			UniqueID:          nodeID + "_" + sensor.key,
+			StateTopic:        m.stationTopic(obs.StationID, "wind"),
+			ValueTemplate:     "{{ value_json." + sensor.key + " | default(0) }}",
+			UnitOfMeasurement: sensor.unit,
+			DeviceClass:       sensor.deviceClass,
+			StateClass:        "measurement",
+			Icon:              sensor.icon,
+			AvailabilityTopic: m.availabilityTopic(),
+			Device:            device,
+		}
+		payload, err := json.Marshal(config)
+		if err != nil {
+			log.Printf("Error encoding MQTT discovery for station %s: %v", obs.StationID, err)
+			continue
+		}
+		topic := m.discoveryPrefix + "/sensor/" + nodeID + "/" + sensor.key + "/config"
+		packets = append(packets, encodePublish(topic, payload, true))
+	}
+	return packets
+}
@@ -0,0 +1,385 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"windz/internal/observations"
+	"windz/internal/sse"
+	"windz/internal/stations"
+)
+
+// publication is a PUBLISH packet received by the fake broker
+type publication struct {
+	topic   string
+	payload string
+	retain  bool
+}
+
+// fakeBroker is an in-process MQTT broker that records publications
+type fakeBroker struct {
+	t        *testing.T
+	listener net.Listener
+
+	mu          sync.Mutex
+	conns       []net.Conn
+	connects    []connectOptions
+	published   []publication
+	refuseCode  byte
+	disconnects int
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	b := &fakeBroker{t: t, listener: listener}
+	go b.accept()
+	t.Cleanup(func() {
+		listener.Close()
+		b.drop()
+	})
+	return b
+}
+
+func (b *fakeBroker) accept() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.serve(conn)
+	}
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	header, body, err := readPacket(reader)
+	if err != nil || header != packetConnect {
+		b.t.Errorf("Expected CONNECT, got %#x (%v)", header, err)
+		return
+	}
+	opts := parseConnect(b.t, body)
+
+	b.mu.Lock()
+	code := b.refuseCode
+	b.connects = append(b.connects, opts)
+	if code == 0 {
+		b.conns = append(b.conns, conn)
+	}
+	b.mu.Unlock()
+
+	conn.Write(encodePacket(packetConnack, []byte{0, code}))
+	if code != 0 {
+		return
+	}
+
+	for {
+		header, body, err := readPacket(reader)
+		if err != nil {
+			return
+		}
+		switch header & 0xF0 {
+		case packetPublish:
+			topic, payload, err := readString(body)
+			if err != nil {
+				b.t.Errorf("Malformed PUBLISH: %v", err)
+				return
+			}
+			b.mu.Lock()
+			b.published = append(b.published, publication{topic, string(payload), header&0x01 != 0})
+			b.mu.Unlock()
+		case packetPingreq:
+			conn.Write(encodePacket(packetPingresp, nil))
+		case packetDisconnect:
+			b.mu.Lock()
+			b.disconnects++
+			b.mu.Unlock()
+			return
+		}
+	}
+}
+
+// drop closes every client connection, as a restarting broker would
+func (b *fakeBroker) drop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, conn := range b.conns {
+		conn.Close()
+	}
+	b.conns = nil
+}
+
+func (b *fakeBroker) connectCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.connects)
+}
+
+// find returns the last publication on topic
+func (b *fakeBroker) find(topic string) (publication, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := len(b.published) - 1; i >= 0; i-- {
+		if b.published[i].topic == topic {
+			return b.published[i], true
+		}
+	}
+	return publication{}, false
+}
+
+func (b *fakeBroker) count(prefix string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, p := range b.published {
+		if strings.HasPrefix(p.topic, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func parseConnect(t *testing.T, body []byte) connectOptions {
+	t.Helper()
+	protocol, rest, err := readString(body)
+	if err != nil || protocol != "MQTT" || len(rest) < 4 || rest[0] != 4 {
+		t.Fatalf("Unexpected CONNECT header %q %v", protocol, rest)
+	}
+	flags := rest[1]
+	opts := connectOptions{keepAlive: uint16(rest[2])<<8 | uint16(rest[3])}
+	rest = rest[4:]
+
+	opts.clientID, rest, _ = readString(rest)
+	if flags&flagWill != 0 {
+		var payload string
+		opts.willTopic, rest, _ = readString(rest)
+		payload, rest, _ = readString(rest)
+		opts.willPayload = []byte(payload)
+		opts.willRetain = flags&flagWillRetain != 0
+	}
+	if flags&flagUsername != 0 {
+		opts.username, rest, _ = readString(rest)
+	}
+	if flags&flagPassword != 0 {
+		opts.password, _, _ = readString(rest)
+	}
+	return opts
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func startManager(t *testing.T, broker *fakeBroker) Manager {
+	t.Helper()
+	mgr, err := NewManager(Config{
+		Broker:         "tcp://" + broker.listener.Addr().String(),
+		Username:       "harbour",
+		Password:       "secret",
+		ReconnectDelay: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if err := mgr.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { mgr.Stop() })
+	return mgr
+}
+
+func windMessage(stationID string, speed float64) sse.Message {
+	return sse.Message{
+		Type:      "data",
+		StationID: stationID,
+		Data: observations.WindObservation{
+			StationID:     stationID,
+			StationName:   "Espoo Sorvalahti",
+			Region:        "Espoo",
+			Timestamp:     time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC),
+			WindSpeed:     speed,
+			WindGust:      speed + 3,
+			WindDirection: 225,
+		},
+	}
+}
+
+func TestPublishObservationsAndStatus(t *testing.T) {
+	broker := newFakeBroker(t)
+	mgr := startManager(t, broker)
+	waitFor(t, mgr.Connected)
+
+	broker.mu.Lock()
+	connect := broker.connects[0]
+	broker.mu.Unlock()
+	if connect.clientID != DefaultClientID || connect.username != "harbour" || connect.password != "secret" {
+		t.Errorf("Unexpected CONNECT: %+v", connect)
+	}
+	if connect.willTopic != "windz/availability" || string(connect.willPayload) != "offline" || !connect.willRetain {
+		t.Errorf("Unexpected last will: %+v", connect)
+	}
+
+	mgr.Enqueue(windMessage("101023", 7.5))
+	mgr.Enqueue(sse.Message{Type: "status", StationID: "101023", Data: map[string]interface{}{"interval": "1min"}})
+	mgr.Enqueue(sse.Message{Type: "trend", StationID: "101023"}) // Not published
+
+	waitFor(t, func() bool {
+		_, ok := broker.find("windz/101023/status")
+		return ok
+	})
+
+	wind, ok := broker.find("windz/101023/wind")
+	if !ok || !wind.retain {
+		t.Fatalf("Expected retained wind publication, got %+v", wind)
+	}
+	var obs observations.WindObservation
+	if err := json.Unmarshal([]byte(wind.payload), &obs); err != nil || obs.WindSpeed != 7.5 {
+		t.Errorf("Unexpected wind payload %q (%v)", wind.payload, err)
+	}
+
+	status, _ := broker.find("windz/101023/status")
+	if status.payload != `{"interval":"1min"}` || !status.retain {
+		t.Errorf("Unexpected status publication: %+v", status)
+	}
+	if availability, _ := broker.find("windz/availability"); availability.payload != "online" {
+		t.Errorf("Expected online availability, got %+v", availability)
+	}
+	if broker.count("windz/101023/trend") != 0 {
+		t.Error("Trend messages should not be published")
+	}
+}
+
+func TestHomeAssistantDiscovery(t *testing.T) {
+	broker := newFakeBroker(t)
+	mgr := startManager(t, broker)
+
+	mgr.Enqueue(windMessage("101023", 5))
+	mgr.Enqueue(windMessage("101023", 6))
+	waitFor(t, func() bool { return broker.count("windz/101023/wind") == 2 })
+
+	// Discovery is sent once per station and connection
+	if n := broker.count("homeassistant/sensor/windz_101023/"); n != 4 {
+		t.Fatalf("Expected 4 discovery configs, got %d", n)
+	}
+
+	publication, _ := broker.find("homeassistant/sensor/windz_101023/wind_gust/config")
+	var config discoveryConfig
+	if err := json.Unmarshal([]byte(publication.payload), &config); err != nil {
+		t.Fatalf("Invalid discovery payload: %v", err)
+	}
+	if config.StateTopic != "windz/101023/wind" || config.UniqueID != "windz_101023_wind_gust" ||
+		config.UnitOfMeasurement != "m/s" || config.AvailabilityTopic != "windz/availability" ||
+		config.Device.Name != "Espoo Sorvalahti" || !publication.retain {
+		t.Errorf("Unexpected discovery config: %+v", config)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	broker := newFakeBroker(t)
+	mgr := startManager(t, broker)
+
+	mgr.Enqueue(windMessage("101023", 5))
+	waitFor(t, func() bool { return broker.count("windz/101023/wind") == 1 })
+
+	broker.drop()
+	waitFor(t, func() bool { return broker.connectCount() == 2 && mgr.Connected() })
+
+	mgr.Enqueue(windMessage("101023", 9))
+	waitFor(t, func() bool { return broker.count("windz/101023/wind") == 2 })
+
+	// Discovery is republished on the new connection
+	if n := broker.count("homeassistant/sensor/windz_101023/wind_speed/config"); n != 2 {
+		t.Errorf("Expected discovery on both connections, got %d", n)
+	}
+}
+
+func TestRefusedConnection(t *testing.T) {
+	broker := newFakeBroker(t)
+	broker.refuseCode = 4
+	mgr := startManager(t, broker)
+
+	// Refused connections are retried with backoff
+	waitFor(t, func() bool { return broker.connectCount() >= 2 })
+	if mgr.Connected() {
+		t.Error("Refused connection reported as connected")
+	}
+}
+
+func TestStopDisconnects(t *testing.T) {
+	broker := newFakeBroker(t)
+	mgr := startManager(t, broker)
+	waitFor(t, mgr.Connected)
+
+	mgr.Stop()
+	waitFor(t, func() bool {
+		broker.mu.Lock()
+		defer broker.mu.Unlock()
+		return broker.disconnects == 1
+	})
+	if availability, _ := broker.find("windz/availability"); availability.payload != "offline" {
+		t.Errorf("Expected offline availability after Stop, got %+v", availability)
+	}
+}
+
+func TestNewManagerDefaults(t *testing.T) {
+	for keepAlive, want := range map[time.Duration]time.Duration{
+		0:                       DefaultKeepAlive,
+		time.Nanosecond:         time.Second,
+		2500 * time.Millisecond: 2 * time.Second,
+		24 * time.Hour:          maxKeepAlive,
+	} {
+		mgr, err := NewManager(Config{Broker: "localhost", KeepAlive: keepAlive})
+		if err != nil {
+			t.Fatalf("NewManager() error = %v", err)
+		}
+		if got := mgr.(*manager).keepAlive; got != want {
+			t.Errorf("KeepAlive %v became %v, want %v", keepAlive, got, want)
+		}
+	}
+
+	// Private stations are not presented as FMI devices
+	stationMgr := stations.NewManager()
+	if err := stationMgr.AddStation(stations.Station{ID: "pier", Name: "Club pier", Source: "ingest"}); err != nil {
+		t.Fatalf("AddStation() error = %v", err)
+	}
+	mgr, _ := NewManager(Config{Broker: "localhost", Stations: stationMgr})
+	if got := mgr.(*manager).manufacturer("pier"); got != "Private station (ingest)" {
+		t.Errorf("Unexpected manufacturer for an ingest station: %q", got)
+	}
+	if got := mgr.(*manager).manufacturer("101023"); got != "Finnish Meteorological Institute" {
+		t.Errorf("Unexpected manufacturer for an FMI station: %q", got)
+	}
+}
+
+func TestBrokerAddress(t *testing.T) {
+	tests := map[string]string{
+		"localhost":             "localhost:1883",
+		"tcp://broker.lan:1884": "broker.lan:1884",
+		"mqtt://192.168.1.10":   "192.168.1.10:1883",
+	}
+	for input, want := range tests {
+		if got, err := brokerAddress(input); err != nil || got != want {
+			t.Errorf("brokerAddress(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := brokerAddress("ssl://broker.lan"); err == nil {
+		t.Error("Expected error for unsupported scheme")
+	}
+}
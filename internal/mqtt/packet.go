@@ -0,0 +1,184 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MQTT 3.1.1 control packet types (upper nibble of the fixed header)
+const (
+	packetConnect    byte = 0x10
+	packetConnack    byte = 0x20
+	packetPublish    byte = 0x30
+	packetPingreq    byte = 0xC0
+	packetPingresp   byte = 0xD0
+	packetDisconnect byte = 0xE0
+)
+
+// CONNECT flags
+const (
+	flagCleanSession = 0x02
+	flagWill         = 0x04
+	flagWillRetain   = 0x20
+	flagPassword     = 0x40
+	flagUsername     = 0x80
+)
+
+// maxRemainingLength is the largest length the 4-byte encoding can carry
+const maxRemainingLength = 268435455
+
+// connectReturnCodes describes the CONNACK refusal codes
+var connectReturnCodes = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// connectOptions are the fields of a CONNECT packet
+type connectOptions struct {
+	clientID    string
+	username    string
+	password    string
+	keepAlive   uint16 // Seconds
+	willTopic   string
+	willPayload []byte
+	willRetain  bool
+}
+
+// encodeConnect builds a CONNECT packet with a clean session
+func encodeConnect(opts connectOptions) []byte {
+	var body []byte
+	body = appendString(body, "MQTT")
+	body = append(body, 4) // Protocol level 3.1.1
+
+	flags := byte(flagCleanSession)
+	if opts.willTopic != "" {
+		flags |= flagWill
+		if opts.willRetain {
+			flags |= flagWillRetain
+		}
+	}
+	if opts.username != "" {
+		flags |= flagUsername
+		if opts.password != "" {
+			flags |= flagPassword
+		}
+	}
+	body = append(body, flags)
+	body = binary.BigEndian.AppendUint16(body, opts.keepAlive)
+
+	body = appendString(body, opts.clientID)
+	if opts.willTopic != "" {
+		body = appendString(body, opts.willTopic)
+		body = appendBytes(body, opts.willPayload)
+	}
+	if opts.username != "" {
+		body = appendString(body, opts.username)
+		if opts.password != "" {
+			body = appendString(body, opts.password)
+		}
+	}
+	return encodePacket(packetConnect, body)
+}
+
+// encodePublish builds a QoS 0 PUBLISH packet
+func encodePublish(topic string, payload []byte, retain bool) []byte {
+	header := packetPublish
+	if retain {
+		header |= 0x01
+	}
+	body := appendString(nil, topic)
+	body = append(body, payload...)
+	return encodePacket(header, body)
+}
+
+// encodePacket prefixes body with the fixed header and remaining length
+func encodePacket(header byte, body []byte) []byte {
+	packet := []byte{header}
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+// readPacket reads one control packet and returns its fixed header byte and body
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7F) * multiplier
+		if digit&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+	if length > maxRemainingLength {
+		return 0, nil, errors.New("packet too large")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// checkConnack validates a CONNACK body
+func checkConnack(body []byte) error {
+	if len(body) != 2 {
+		return fmt.Errorf("malformed CONNACK of %d bytes", len(body))
+	}
+	if code := body[1]; code != 0 {
+		if reason, ok := connectReturnCodes[code]; ok {
+			return fmt.Errorf("connection refused: %s", reason)
+		}
+		return fmt.Errorf("connection refused: code %d", code)
+	}
+	return nil
+}
+
+// readString reads a length-prefixed string and returns the remaining bytes
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, io.ErrUnexpectedEOF
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", nil, io.ErrUnexpectedEOF
+	}
+	return string(b[2 : 2+n]), b[2+n:], nil
+}
+
+func appendString(b []byte, s string) []byte {
+	return appendBytes(b, []byte(s))
+}
+
+func appendBytes(b []byte, data []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	return append(b, data...)
+}
@@ -0,0 +1,209 @@
+package observations
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+	"windz/internal/units"
+)
+
+// RollingGustWindow is the window of the rolling maximum gust
+const RollingGustWindow = 60 * time.Minute
+
+// Aggregate summarises the observations of one time bucket
+type Aggregate struct {
+	Start           time.Time  `json:"start"`
+	End             time.Time  `json:"end"`
+	Count           int        `json:"count"`
+	MeanSpeed       float64    `json:"mean_speed"`
+	MinSpeed        float64    `json:"min_speed"`
+	MaxGust         float64    `json:"max_gust"`
+	MaxGust60m      float64    `json:"max_gust_60m"`                // Rolling maximum gust over the hour ending at End
+	MeanDirection   *float64   `json:"mean_direction"`              // Circular mean, null when calm throughout
+	DirectionStdDev *float64   `json:"direction_std_dev,omitempty"` // Circular standard deviation in degrees
+	Units           units.Unit `json:"units,omitempty"`             // Speed unit when converted with units=
+	Compass         string     `json:"compass,omitempty"`           // Compass point of the mean direction when converted
+}
+
+// aggregateLocation aligns daily buckets to Finnish local days
+var aggregateLocation = loadAggregateLocation()
+
+func loadAggregateLocation() *time.Location {
+	loc, err := time.LoadLocation("Europe/Helsinki")
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// parsePeriod maps an aggregate period name to its duration
+func parsePeriod(value string) (time.Duration, error) {
+	switch value {
+	case "", "10m":
+		return 10 * time.Minute, nil
+	case "1h":
+		return time.Hour, nil
+	case "1d":
+		return 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("invalid period %q (use 10m, 1h or 1d)", value)
+	}
+}
+
+// bucketStart returns the start of the bucket containing t
+func bucketStart(t time.Time, period time.Duration) time.Time {
+	if period >= 24*time.Hour {
+		local := t.In(aggregateLocation)
+		return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, aggregateLocation)
+	}
+	return t.Truncate(period)
+}
+
+// bucketEnd returns the end of the bucket starting at start
+func bucketEnd(start time.Time, period time.Duration) time.Time {
+	if period >= 24*time.Hour {
+		return start.AddDate(0, 0, 1) // Handles DST days of 23 and 25 hours
+	}
+	return start.Add(period)
+}
+
+// aggregateObservations groups time-ordered observations into period buckets. Only
+// samples from from onwards start buckets; earlier samples feed the rolling gust.
+// Samples flagged by quality control are left out.
+func aggregateObservations(observations []WindObservation, from time.Time, period time.Duration) []Aggregate {
+	result := []Aggregate{}
+	observations = unflagged(observations)
+
+	var current *Aggregate
+	var speedSum float64
+	var directions []float64
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.MeanSpeed = speedSum / float64(current.Count)
+		if mean, std, ok := circularStats(directions); ok {
+			current.MeanDirection = &mean
+			current.DirectionStdDev = &std
+		}
+		current.MaxGust60m = maxGustBetween(observations, current.End.Add(-RollingGustWindow), current.End)
+		result = append(result, *current)
+	}
+
+	for _, obs := range observations {
+		if obs.Timestamp.Before(from) {
+			continue
+		}
+
+		start := bucketStart(obs.Timestamp, period)
+		if current == nil || !current.Start.Equal(start) {
+			flush()
+			current = &Aggregate{Start: start, End: bucketEnd(start, period), MinSpeed: obs.WindSpeed}
+			speedSum = 0
+			directions = directions[:0]
+		}
+
+		current.Count++
+		speedSum += obs.WindSpeed
+		current.MinSpeed = math.Min(current.MinSpeed, obs.WindSpeed)
+		current.MaxGust = math.Max(current.MaxGust, obs.WindGust)
+		if obs.WindSpeed > 0 {
+			directions = append(directions, obs.WindDirection) // Direction is meaningless in calm
+		}
+	}
+	flush()
+
+	return result
+}
+
+// maxGustBetween returns the highest gust of the time-ordered observations in (from, to]
+func maxGustBetween(observations []WindObservation, from, to time.Time) float64 {
+	start := sort.Search(len(observations), func(i int) bool {
+		return observations[i].Timestamp.After(from)
+	})
+
+	maxGust := 0.0
+	for _, obs := range observations[start:] {
+		if obs.Timestamp.After(to) {
+			break
+		}
+		maxGust = math.Max(maxGust, obs.WindGust)
+	}
+	return maxGust
+}
+
+// circularStats returns the vector mean direction and the circular standard deviation
+// (both in degrees) of compass directions
+func circularStats(directions []float64) (float64, float64, bool) {
+	if len(directions) == 0 {
+		return 0, 0, false
+	}
+
+	var sinSum, cosSum float64
+	for _, dir := range directions {
+		rad := dir * math.Pi / 180
+		sinSum += math.Sin(rad)
+		cosSum += math.Cos(rad)
+	}
+
+	n := float64(len(directions))
+	mean := math.Atan2(sinSum/n, cosSum/n) * 180 / math.Pi
+	if mean < 0 {
+		mean += 360
+	}
+
+	// Mean resultant length: 1 when all directions agree, 0 when they cancel out
+	r := math.Hypot(sinSum, cosSum) / n
+	if r >= 1 {
+		return mean, 0, true
+	}
+	if r <= 0 {
+		return mean, 180, true
+	}
+	std := math.Sqrt(-2*math.Log(r)) * 180 / math.Pi
+	return mean, std, true
+}
+
+// handleStationAggregate handles the per-station aggregate endpoint
+func handleStationAggregate(w http.ResponseWriter, r *http.Request, mgr Manager, stationID string) {
+	conv, ok := parseConvention(w, r)
+	if !ok {
+		return
+	}
+
+	period, err := parsePeriod(r.URL.Query().Get("period"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	defaultSpan := 24 * time.Hour
+	if period >= 24*time.Hour {
+		defaultSpan = 7 * 24 * time.Hour
+	}
+	from, to, err := parseTimeRange(r, defaultSpan)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Start at a bucket boundary and include the hour before for the rolling gust
+	from = bucketStart(from, period)
+	history := mgr.GetHistory(stationID, from.Add(-RollingGustWindow), to)
+
+	aggregates := aggregateObservations(history, from, period)
+	for i := range aggregates {
+		aggregates[i] = aggregates[i].InUnits(conv)
+	}
+
+	if err := json.NewEncoder(w).Encode(aggregates); err != nil {
+		log.Printf("Error encoding aggregate response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
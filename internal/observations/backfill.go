@@ -0,0 +1,247 @@
+package observations
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"time"
+)
+
+// Backfill defaults
+const (
+	DefaultBackfillLookback = 24 * time.Hour
+	DefaultBackfillRate     = 6 // Source requests per minute
+
+	backfillChunk        = 6 * time.Hour    // Aligned window fetched per request
+	backfillDelay        = 30 * time.Minute // Leave recent data to live polling and late FMI publication
+	backfillInterval     = 15 * time.Minute // Time between backfill passes
+	backfillStartupDelay = 1 * time.Minute  // Let the first live poll run first
+)
+
+// fetchFunc fetches observations for a batch of stations fed by one source in a time window
+type fetchFunc func(source string, stationIDs []string, startTime, endTime time.Time) (map[string][]FMIWindObservation, error)
+
+// timeWindow is a half-open [start, end) interval
+type timeWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// chunkKey identifies an aligned backfill chunk of one station
+type chunkKey struct {
+	stationID string
+	start     int64 // Unix seconds of the chunk start
+}
+
+// backfiller fills gaps in stored history from the sources behind the live polling
+type backfiller struct {
+	mgr      *manager
+	fetch    fetchFunc
+	lookback time.Duration
+	spacing  time.Duration // Minimum time between requests (rate budget)
+
+	attempted map[chunkKey]time.Time // When a chunk was last fetched
+}
+
+// newBackfiller creates a backfill worker for the manager
+func newBackfiller(m *manager, fetch fetchFunc, lookback time.Duration, ratePerMinute int) *backfiller {
+	if ratePerMinute <= 0 {
+		ratePerMinute = DefaultBackfillRate
+	}
+	return &backfiller{
+		mgr:       m,
+		fetch:     fetch,
+		lookback:  lookback,
+		spacing:   time.Minute / time.Duration(ratePerMinute),
+		attempted: make(map[chunkKey]time.Time),
+	}
+}
+
+// run performs backfill passes until the context is cancelled
+func (b *backfiller) run(ctx context.Context, stopCh <-chan struct{}) {
+	timer := b.mgr.clock.NewTimer(backfillStartupDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C():
+			requests := b.pass(ctx, b.mgr.clock.Now())
+			if requests > 0 && b.mgr.debug {
+				log.Printf("Backfill pass made %d source requests", requests)
+			}
+			timer.Reset(backfillInterval)
+		case <-ctx.Done():
+			return
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// pass detects gaps for every station and fetches the missing chunks within the
+// rate budget. It returns the number of source requests made.
+func (b *backfiller) pass(ctx context.Context, now time.Time) int {
+	from := now.Add(-b.lookback)
+	to := now.Add(-backfillDelay)
+	if !from.Before(to) {
+		return 0
+	}
+
+	// Collect the aligned chunks each station is missing, per source
+	chunkStations := make(map[pollGroup][]string)
+	for _, station := range b.mgr.stationMgr.GetAllStations() {
+		history := b.mgr.GetHistory(station.ID, from, to)
+		timestamps := make([]time.Time, len(history))
+		for i, obs := range history {
+			timestamps[i] = obs.Timestamp
+		}
+
+		source := sourceName(station)
+		cadence := expectedCadence(timestamps, b.mgr.sourceCadence(station.ID))
+		for _, chunk := range b.missingChunks(station.ID, findGaps(timestamps, from, to, cadence), now) {
+			group := pollGroup{source: source, start: chunk}
+			chunkStations[group] = append(chunkStations[group], station.ID)
+		}
+	}
+
+	b.prune(from)
+
+	// Oldest chunks first so records fill in chronologically
+	chunks := make([]pollGroup, 0, len(chunkStations))
+	for chunk := range chunkStations {
+		chunks = append(chunks, chunk)
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		if chunks[i].start.Equal(chunks[j].start) {
+			return chunks[i].source < chunks[j].source
+		}
+		return chunks[i].start.Before(chunks[j].start)
+	})
+
+	requests := 0
+	unavailable := make(map[string]bool)
+	for _, chunk := range chunks {
+		if unavailable[chunk.source] {
+			continue
+		}
+		stationIDs := chunkStations[chunk]
+		start, end := maxTime(chunk.start, from), minTime(chunk.start.Add(backfillChunk), to)
+		batchSize := b.mgr.batchSize(chunk.source)
+
+		for i := 0; i < len(stationIDs); i += batchSize {
+			batch := stationIDs[i:min(i+batchSize, len(stationIDs))]
+
+			if requests > 0 {
+				wait := b.mgr.clock.NewTimer(b.spacing)
+				select {
+				case <-wait.C():
+				case <-ctx.Done():
+					wait.Stop()
+					return requests
+				}
+			}
+			requests++
+
+			results, err := b.fetch(chunk.source, batch, start, end)
+			if errors.Is(err, ErrUpstreamUnavailable) {
+				unavailable[chunk.source] = true
+				break
+			}
+			if err != nil {
+				log.Printf("Error backfilling %d stations for %s: %v", len(batch), start.Format(time.RFC3339), err)
+				continue
+			}
+
+			for _, stationID := range batch {
+				b.attempted[chunkKey{stationID, chunk.start.Unix()}] = now
+				b.mgr.recordHistory(stationID, results[stationID])
+			}
+		}
+	}
+
+	return requests
+}
+
+// missingChunks maps gap windows onto aligned chunks, skipping chunks that were
+// already fetched after their data should have been published
+func (b *backfiller) missingChunks(stationID string, gaps []timeWindow, now time.Time) []time.Time {
+	seen := make(map[time.Time]bool)
+	var result []time.Time
+
+	for _, gap := range gaps {
+		for chunk := gap.start.Truncate(backfillChunk); chunk.Before(gap.end); chunk = chunk.Add(backfillChunk) {
+			if seen[chunk] {
+				continue
+			}
+			seen[chunk] = true
+
+			if at, ok := b.attempted[chunkKey{stationID, chunk.Unix()}]; ok && at.After(chunk.Add(backfillChunk+backfillDelay)) {
+				continue // The source has nothing more for this chunk
+			}
+			result = append(result, chunk)
+		}
+	}
+	return result
+}
+
+// prune forgets attempts for chunks that have left the lookback window
+func (b *backfiller) prune(from time.Time) {
+	cutoff := from.Add(-backfillChunk).Unix()
+	for key := range b.attempted {
+		if key.start < cutoff {
+			delete(b.attempted, key)
+		}
+	}
+}
+
+// expectedCadence estimates a station's publication interval from its samples,
+// falling back to the source's hint
+func expectedCadence(timestamps []time.Time, hint time.Duration) time.Duration {
+	observations := make([]FMIWindObservation, len(timestamps))
+	for i, ts := range timestamps {
+		observations[i].Timestamp = ts
+	}
+
+	interval, ok := analyzeObservationIntervals(observations)
+	if !ok {
+		if hint > 0 {
+			return hint
+		}
+		return IntervalMedium // Most FMI stations publish every 10 minutes
+	}
+	return roundToStandardInterval(interval)
+}
+
+// findGaps returns the windows within [from, to) where at least one sample at the
+// given cadence is missing. Timestamps must be sorted.
+func findGaps(timestamps []time.Time, from, to time.Time, cadence time.Duration) []timeWindow {
+	tolerance := cadence + cadence/2
+	var gaps []timeWindow
+
+	prev := from.Add(-cadence) // Treat the window start as if a sample was just due
+	for _, ts := range timestamps {
+		if ts.Sub(prev) > tolerance {
+			gaps = append(gaps, timeWindow{start: maxTime(prev.Add(time.Second), from), end: ts})
+		}
+		prev = ts
+	}
+	if to.Sub(prev) > tolerance {
+		gaps = append(gaps, timeWindow{start: maxTime(prev.Add(time.Second), from), end: to})
+	}
+	return gaps
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,184 @@
+package observations
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"windz/internal/clock"
+)
+
+// Circuit breaker defaults
+const (
+	DefaultBreakerThreshold = 3                // Consecutive failed source requests that open the breaker
+	DefaultBreakerCooldown  = 1 * time.Minute  // Wait before the first probe request
+	maxBreakerCooldown      = 10 * time.Minute // Cap of the cooldown, which doubles after each failed probe
+)
+
+// ErrUpstreamUnavailable is returned for source requests rejected by the open breaker
+var ErrUpstreamUnavailable = errors.New("source unavailable, circuit breaker open")
+
+// BreakerState is the state of a source's circuit breaker
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Requests flow normally
+	BreakerOpen     BreakerState = "open"      // Requests are rejected until the cooldown passes
+	BreakerHalfOpen BreakerState = "half_open" // A single probe request decides whether to close
+)
+
+// UpstreamStatus reports the health of a source as seen by its circuit breaker
+type UpstreamStatus struct {
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	LastError           string       `json:"last_error,omitempty"`
+	Since               time.Time    `json:"since"`              // When the current state was entered
+	RetryAt             *time.Time   `json:"retry_at,omitempty"` // Next probe while open
+}
+
+// breaker is a circuit breaker around the requests to one source
+type breaker struct {
+	mu        sync.Mutex
+	clock     clock.Clock
+	threshold int
+	cooldown  time.Duration // Base cooldown
+
+	state    BreakerState
+	failures int
+	lastErr  string
+	since    time.Time
+	retryAt  time.Time
+	backoff  time.Duration // Current cooldown
+	probing  bool          // A half-open probe is in flight
+	onChange func(UpstreamStatus)
+}
+
+func newBreaker(clk clock.Clock, threshold int, cooldown time.Duration, onChange func(UpstreamStatus)) *breaker {
+	return &breaker{
+		clock:     clk,
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+		since:     clk.Now(),
+		backoff:   cooldown,
+		onChange:  onChange,
+	}
+}
+
+// allow reports whether a request may be sent now. Once the cooldown has passed
+// the breaker turns half-open and lets a single probe through.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	now := b.clock.Now()
+	var changed *UpstreamStatus
+	allowed := true
+
+	switch b.state {
+	case BreakerOpen:
+		if now.Before(b.retryAt) {
+			allowed = false
+			break
+		}
+		b.setState(BreakerHalfOpen, now)
+		b.probing = true
+		status := b.statusLocked()
+		changed = &status
+	case BreakerHalfOpen:
+		if b.probing {
+			allowed = false
+		}
+		b.probing = true
+	}
+	b.mu.Unlock()
+
+	if changed != nil {
+		b.onChange(*changed)
+	}
+	return allowed
+}
+
+// wait returns how long requests will keep being rejected, zero if allowed. While a
+// probe is in flight that is unknown, so it is the base cooldown; closing the breaker
+// ends the wait early through onChange.
+func (b *breaker) wait() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.state == BreakerOpen:
+		return max(b.retryAt.Sub(b.clock.Now()), 0)
+	case b.state == BreakerHalfOpen && b.probing:
+		return b.cooldown
+	default:
+		return 0
+	}
+}
+
+// success records a completed request and closes the breaker
+func (b *breaker) success() {
+	b.mu.Lock()
+	b.failures = 0
+	b.probing = false
+	if b.state == BreakerClosed {
+		b.mu.Unlock()
+		return
+	}
+	b.backoff = b.cooldown
+	b.setState(BreakerClosed, b.clock.Now())
+	status := b.statusLocked()
+	b.mu.Unlock()
+
+	b.onChange(status)
+}
+
+// failure records a failed request, opening the breaker after threshold failures
+// in a row or when a probe fails
+func (b *breaker) failure(err error) {
+	b.mu.Lock()
+	now := b.clock.Now()
+	b.failures++
+	b.lastErr = err.Error()
+	b.probing = false
+
+	switch {
+	case b.state == BreakerHalfOpen:
+		b.backoff = min(2*b.backoff, maxBreakerCooldown)
+	case b.state == BreakerClosed && b.failures >= b.threshold:
+	default:
+		b.mu.Unlock()
+		return
+	}
+	b.setState(BreakerOpen, now)
+	b.retryAt = now.Add(b.backoff)
+	status := b.statusLocked()
+	b.mu.Unlock()
+
+	b.onChange(status)
+}
+
+// status returns the current breaker status
+func (b *breaker) status() UpstreamStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.statusLocked()
+}
+
+// setState changes state. Caller holds mu.
+func (b *breaker) setState(state BreakerState, now time.Time) {
+	b.state = state
+	b.since = now
+}
+
+// statusLocked builds the status. Caller holds mu.
+func (b *breaker) statusLocked() UpstreamStatus {
+	status := UpstreamStatus{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastErr,
+		Since:               b.since,
+	}
+	if b.state == BreakerOpen {
+		retryAt := b.retryAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
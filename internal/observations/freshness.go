@@ -0,0 +1,219 @@
+package observations
+
+import (
+	"cmp"
+	"log"
+	"slices"
+	"time"
+	"windz/internal/sse"
+)
+
+// Freshness describes how current a station's latest observation is
+type Freshness string
+
+const (
+	FreshnessFresh   Freshness = "fresh"   // The newest observation is the one expected by now
+	FreshnessLate    Freshness = "late"    // The next observation is overdue
+	FreshnessStale   Freshness = "stale"   // Several observations are missing
+	FreshnessOffline Freshness = "offline" // No data for hours, or never
+)
+
+// freshnessLevels orders the freshness states from best to worst
+var freshnessLevels = []Freshness{FreshnessFresh, FreshnessLate, FreshnessStale, FreshnessOffline}
+
+// Freshness thresholds
+const (
+	defaultCadence         = IntervalMedium   // Assumed observation interval until one is learned
+	freshnessGrace         = 5 * time.Minute  // Publication delay tolerated before an observation is late
+	freshnessStaleCadences = 4                // Missed observations after which data is stale
+	minStaleAfter          = 30 * time.Minute // Fast stations are not stale after a few quiet minutes
+	minOfflineAfter        = 6 * time.Hour    // Data older than this is offline
+	freshnessCheckInterval = time.Minute      // How often transitions are looked for
+)
+
+// Valid reports whether f is a known freshness state
+func (f Freshness) Valid() bool {
+	return slices.Contains(freshnessLevels, f)
+}
+
+// AtLeast reports whether f is as out of date as level or worse
+func (f Freshness) AtLeast(level Freshness) bool {
+	return slices.Index(freshnessLevels, f) >= slices.Index(freshnessLevels, level)
+}
+
+// FreshnessEvent is broadcast as a "freshness" SSE event when a station's freshness changes
+type FreshnessEvent struct {
+	StationID       string    `json:"station_id"`
+	StationName     string    `json:"station_name"`
+	Freshness       Freshness `json:"freshness"`
+	Previous        Freshness `json:"previous"`
+	LastObservation time.Time `json:"last_observation"` // Zero when the station never reported
+	Age             string    `json:"age,omitempty"`
+	Timestamp       time.Time `json:"timestamp"` // When the change was detected
+}
+
+// classifyFreshness grades the age of a station's newest observation against its
+// cadence and publication lag
+func classifyFreshness(age, cadence, lag time.Duration) Freshness {
+	lateAfter := cadence + lag + freshnessGrace
+	staleAfter := max(freshnessStaleCadences*cadence, minStaleAfter) + lag
+	offlineAfter := max(minOfflineAfter, 2*staleAfter)
+
+	switch {
+	case age > offlineAfter:
+		return FreshnessOffline
+	case age > staleAfter:
+		return FreshnessStale
+	case age > lateAfter:
+		return FreshnessLate
+	default:
+		return FreshnessFresh
+	}
+}
+
+// learnCadence updates the observation interval from newly fetched samples. A batch
+// shows the interval directly; a single sample can only show a shorter one, as the
+// gap to the previous observation may span missing data.
+func learnCadence(state *PollingState, previous time.Time, observations []FMIWindObservation) {
+	if minInterval, ok := analyzeObservationIntervals(observations); ok {
+		state.Cadence = minInterval
+		return
+	}
+	if len(observations) == 0 || previous.IsZero() {
+		return
+	}
+	gap := observations[len(observations)-1].Timestamp.Sub(previous)
+	if gap > 30*time.Second && gap < 2*time.Hour && (state.Cadence == 0 || gap < state.Cadence) {
+		state.Cadence = gap
+	}
+}
+
+// GetFreshness returns how current a station's data is
+func (m *manager) GetFreshness(stationID string) Freshness {
+	freshness, _ := m.stationFreshness(stationID, m.clock.Now())
+	return freshness
+}
+
+// stationFreshness classifies a station and returns the timestamp of its newest
+// observation, zero when it never reported
+func (m *manager) stationFreshness(stationID string, now time.Time) (Freshness, time.Time) {
+	m.windDataMutex.RLock()
+	latest := m.windData[stationID].Timestamp
+	m.windDataMutex.RUnlock()
+
+	// The learned interval wins over the source's hint and the usual FMI interval
+	cadence, lag, seen := cmp.Or(m.sourceCadence(stationID), defaultCadence), time.Duration(0), now
+	m.pollingStatesMutex.RLock()
+	if state, exists := m.pollingStates[stationID]; exists {
+		cadence = cmp.Or(state.Cadence, cadence)
+		if state.Lag.ready() {
+			lag = time.Duration(state.Lag.Offset * float64(time.Second))
+		}
+		latest = maxTime(latest, state.LastObservation)
+		// Data is only known to be missing once a poll looked for it, so background
+		// polling does not age a station; beyond its interval the time counts regardless
+		seen = minTime(now, maxTime(state.LastPolled, now.Add(-m.backgroundInterval)))
+	}
+	m.pollingStatesMutex.RUnlock()
+
+	if latest.IsZero() {
+		return FreshnessOffline, latest
+	}
+	return classifyFreshness(seen.Sub(latest), cadence, lag), latest
+}
+
+// AddFreshnessListener registers a function called with every freshness change
+func (m *manager) AddFreshnessListener(listener func(FreshnessEvent)) {
+	m.listenersMu.Lock()
+	defer m.listenersMu.Unlock()
+
+	m.freshnessListeners = append(m.freshnessListeners, listener)
+}
+
+// runFreshness periodically re-grades every station so that stations going quiet
+// are noticed without new data
+func (m *manager) runFreshness() {
+	ticker := m.clock.NewTicker(freshnessCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			m.updateFreshness(m.stationIDs()...)
+		case <-m.ctx.Done():
+			return
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// stationIDs returns the IDs of all monitored stations
+func (m *manager) stationIDs() []string {
+	allStations := m.stationMgr.GetAllStations()
+	ids := make([]string, len(allStations))
+	for i, station := range allStations {
+		ids[i] = station.ID
+	}
+	return ids
+}
+
+// updateFreshness re-grades the given stations and announces the ones that changed.
+// The first grade of a station is recorded silently.
+func (m *manager) updateFreshness(stationIDs ...string) {
+	var events []FreshnessEvent
+
+	m.freshnessMu.Lock()
+	now := m.clock.Now()
+	for _, stationID := range stationIDs {
+		freshness, latest := m.stationFreshness(stationID, now)
+
+		previous, known := m.freshness[stationID]
+		m.freshness[stationID] = freshness
+		if !known || previous == freshness {
+			continue
+		}
+
+		event := FreshnessEvent{
+			StationID:   stationID,
+			StationName: stationID,
+			Freshness:   freshness,
+			Previous:    previous,
+			Timestamp:   now,
+		}
+		if station, exists := m.stationMgr.GetStation(stationID); exists {
+			event.StationName = station.Name
+		}
+		if !latest.IsZero() {
+			event.LastObservation = latest
+			event.Age = now.Sub(latest).Truncate(time.Minute).String()
+		}
+		events = append(events, event)
+	}
+	m.freshnessMu.Unlock()
+
+	for _, event := range events {
+		m.broadcastFreshness(event)
+	}
+}
+
+// broadcastFreshness announces a freshness change to SSE clients and listeners
+func (m *manager) broadcastFreshness(event FreshnessEvent) {
+	if m.debug || event.Freshness == FreshnessOffline || event.Previous == FreshnessOffline {
+		log.Printf("Station %s (%s) %s -> %s", event.StationName, event.StationID, event.Previous, event.Freshness)
+	}
+
+	m.sseMgr.Broadcast(sse.Message{
+		ID:        event.Timestamp.Unix(),
+		Type:      "freshness",
+		StationID: event.StationID,
+		Data:      event,
+	})
+
+	m.listenersMu.RLock()
+	listeners := m.freshnessListeners
+	m.listenersMu.RUnlock()
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
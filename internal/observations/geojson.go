@@ -0,0 +1,142 @@
+package observations
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"windz/internal/stations"
+	"windz/internal/units"
+)
+
+// GeoJSONFeatureCollection is a GeoJSON FeatureCollection (RFC 7946)
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// GeoJSONFeature is a single GeoJSON feature
+type GeoJSONFeature struct {
+	Type       string         `json:"type"`
+	ID         string         `json:"id,omitempty"`
+	Geometry   GeoJSONPoint   `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// GeoJSONPoint is a GeoJSON point geometry with [longitude, latitude] coordinates
+type GeoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// buildStationFeature combines station metadata with its latest observation, polling state
+// and freshness, with speeds in the convention's unit
+func buildStationFeature(station stations.Station, obs *WindObservation, state *PollingState, freshness Freshness, conv units.Convention) GeoJSONFeature {
+	properties := map[string]any{
+		"station_id": station.ID,
+		"name":       station.Name,
+		"region":     station.Region,
+		"freshness":  freshness,
+	}
+
+	if obs != nil {
+		converted := obs.InUnits(conv)
+		obs = &converted
+		properties["timestamp"] = obs.Timestamp
+		properties["wind_speed"] = obs.WindSpeed
+		properties["wind_gust"] = obs.WindGust
+		properties["wind_direction"] = obs.WindDirection
+		properties["updated_at"] = obs.UpdatedAt
+		if !conv.IsDefault() {
+			properties["units"] = obs.Units
+			properties["compass"] = obs.Compass
+		}
+	}
+
+	if state != nil {
+		properties["polling_interval"] = formatInterval(state.CurrentInterval)
+		properties["last_polled"] = state.LastPolled
+		properties["last_observation"] = state.LastObservation
+		properties["success_rate"] = state.SuccessRate
+	}
+
+	return GeoJSONFeature{
+		Type: "Feature",
+		ID:   station.ID,
+		Geometry: GeoJSONPoint{
+			Type:        "Point",
+			Coordinates: [2]float64{station.Longitude, station.Latitude},
+		},
+		Properties: properties,
+	}
+}
+
+// handleStationsGeoJSON returns all stations as GeoJSON points with live data
+func handleStationsGeoJSON(mgr Manager, stationMgr stations.Manager) http.HandlerFunc {
+	return handleGeoJSON(mgr, stationMgr, false)
+}
+
+// handleLatestGeoJSON returns stations that have observations as GeoJSON points
+func handleLatestGeoJSON(mgr Manager, stationMgr stations.Manager) http.HandlerFunc {
+	return handleGeoJSON(mgr, stationMgr, true)
+}
+
+func handleGeoJSON(mgr Manager, stationMgr stations.Manager, requireObservation bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		conv, ok := parseConvention(w, r)
+		if !ok {
+			return
+		}
+
+		// Apply the region filter the same way as /api/stations
+		var candidates []stations.Station
+		if region := query.Get("region"); region != "" {
+			candidates = stationMgr.GetStationsByRegion(region)
+		} else {
+			candidates = stationMgr.GetAllStations()
+		}
+
+		var bbox *stations.BBox
+		if value := query.Get("bbox"); value != "" {
+			parsed, err := stations.ParseBBox(value)
+			if err != nil {
+				http.Error(w, "Invalid bbox: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			bbox = &parsed
+		}
+
+		collection := GeoJSONFeatureCollection{
+			Type:     "FeatureCollection",
+			Features: []GeoJSONFeature{},
+		}
+
+		for _, station := range candidates {
+			if bbox != nil && !bbox.Contains(station.Latitude, station.Longitude) {
+				continue
+			}
+
+			var obsPtr *WindObservation
+			if obs, exists := mgr.GetLatestObservation(station.ID); exists {
+				obsPtr = &obs
+			} else if requireObservation {
+				continue
+			}
+
+			var statePtr *PollingState
+			if state, exists := mgr.GetPollingState(station.ID); exists {
+				statePtr = &state
+			}
+
+			collection.Features = append(collection.Features, buildStationFeature(station, obsPtr, statePtr, mgr.GetFreshness(station.ID), conv))
+		}
+
+		w.Header().Set("Content-Type", "application/geo+json")
+		if err := json.NewEncoder(w).Encode(collection); err != nil {
+			log.Printf("Error encoding GeoJSON response: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -2,17 +2,27 @@ package observations
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
+	"windz/internal/auth"
+	"windz/internal/stations"
 )
 
-// RegisterHandlers registers the observation HTTP handlers
-func RegisterHandlers(mux *http.ServeMux, mgr Manager) {
+// RegisterHandlers registers the observation HTTP handlers. When adminToken is set,
+// refresh requests require it as a bearer token.
+func RegisterHandlers(mux *http.ServeMux, mgr Manager, stationMgr stations.Manager, adminToken string) {
 	mux.HandleFunc("/api/observations", handleObservations(mgr))
 	mux.HandleFunc("/api/observations/latest", handleLatestObservations(mgr))
-	mux.HandleFunc("/api/observations/", handleStationObservation(mgr))
+	mux.HandleFunc("/api/observations/status", handleObservationStatus(mgr, stationMgr))
+	mux.HandleFunc("/api/observations/", handleStationObservation(mgr, adminToken))
+	mux.HandleFunc("/api/observations/latest.geojson", handleLatestGeoJSON(mgr, stationMgr))
+	mux.HandleFunc("/api/stations.geojson", handleStationsGeoJSON(mgr, stationMgr))
+	mux.HandleFunc("/api/groups/{id}/observations", handleGroupObservations(mgr, stationMgr))
 }
 
 // StationStatus represents station status for API responses
@@ -24,6 +34,9 @@ type StationStatus struct {
 	LastPolled      time.Time        `json:"last_polled"`
 	LastObservation time.Time        `json:"last_observation"`
 	SuccessRate     float64          `json:"success_rate"`
+	Freshness       Freshness        `json:"freshness"`
+	PublicationLag  *LagSummary      `json:"publication_lag,omitempty"`
+	QualityFlags    map[string]int   `json:"quality_flags,omitempty"` // Flagged samples per flag in the in-memory history
 	LatestData      *WindObservation `json:"latest_data,omitempty"`
 }
 
@@ -32,8 +45,16 @@ func handleObservations(mgr Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
+		conv, ok := parseConvention(w, r)
+		if !ok {
+			return
+		}
+
 		// Get all observations
 		observations := mgr.GetAllLatestObservations()
+		for stationID, obs := range observations {
+			observations[stationID] = obs.InUnits(conv)
+		}
 
 		if err := json.NewEncoder(w).Encode(observations); err != nil {
 			log.Printf("Error encoding observations response: %v", err)
@@ -48,13 +69,18 @@ func handleLatestObservations(mgr Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
+		conv, ok := parseConvention(w, r)
+		if !ok {
+			return
+		}
+
 		// Get all latest observations
 		observations := mgr.GetAllLatestObservations()
 
 		// Convert to slice for easier consumption
 		result := make([]WindObservation, 0, len(observations))
 		for _, obs := range observations {
-			result = append(result, obs)
+			result = append(result, obs.InUnits(conv))
 		}
 
 		if err := json.NewEncoder(w).Encode(result); err != nil {
@@ -65,28 +91,204 @@ func handleLatestObservations(mgr Manager) http.HandlerFunc {
 	}
 }
 
+// handleObservationStatus reports polling status and learned publication lag per station
+func handleObservationStatus(mgr Manager, stationMgr stations.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		conv, ok := parseConvention(w, r)
+		if !ok {
+			return
+		}
+
+		allStations := stationMgr.GetAllStations()
+		statuses := make([]StationStatus, 0, len(allStations))
+		for _, station := range allStations {
+			status := StationStatus{
+				ID:        station.ID,
+				Name:      station.Name,
+				Region:    station.Region,
+				Freshness: mgr.GetFreshness(station.ID),
+			}
+			if state, exists := mgr.GetPollingState(station.ID); exists {
+				status.PollingInterval = formatInterval(state.CurrentInterval)
+				status.LastPolled = state.LastPolled
+				status.LastObservation = state.LastObservation
+				status.SuccessRate = state.SuccessRate
+				status.PublicationLag = state.Lag.Summary()
+			}
+			status.QualityFlags = mgr.GetQualityFlags(station.ID)
+			if obs, exists := mgr.GetLatestObservation(station.ID); exists {
+				obs = obs.InUnits(conv)
+				status.LatestData = &obs
+			}
+			statuses = append(statuses, status)
+		}
+
+		if err := json.NewEncoder(w).Encode(statuses); err != nil {
+			log.Printf("Error encoding observation status response: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
 // handleStationObservation handles individual station observation lookup
-func handleStationObservation(mgr Manager) http.HandlerFunc {
+func handleStationObservation(mgr Manager, adminToken string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		// Extract station ID from path
+		// Extract station ID and optional sub-resource from path
 		path := strings.TrimPrefix(r.URL.Path, "/api/observations/")
-		if path == "" {
+		stationID, resource, _ := strings.Cut(path, "/")
+		if stationID == "" {
 			http.Error(w, "Station ID required", http.StatusBadRequest)
 			return
 		}
 
-		observation, exists := mgr.GetLatestObservation(path)
+		switch resource {
+		case "":
+		case "history":
+			handleStationHistory(w, r, mgr, stationID)
+			return
+		case "aggregate":
+			handleStationAggregate(w, r, mgr, stationID)
+			return
+		case "windrose", "windrose.svg":
+			handleStationWindRose(w, r, mgr, stationID, resource == "windrose.svg")
+			return
+		case "refresh":
+			auth.RequireToken(adminToken, func(w http.ResponseWriter, r *http.Request) {
+				handleStationRefresh(w, r, mgr, stationID)
+			})(w, r)
+			return
+		default:
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		conv, ok := parseConvention(w, r)
+		if !ok {
+			return
+		}
+
+		observation, exists := mgr.GetLatestObservation(stationID)
 		if !exists {
 			http.Error(w, "Observation not found", http.StatusNotFound)
 			return
 		}
 
-		if err := json.NewEncoder(w).Encode(observation); err != nil {
+		if err := json.NewEncoder(w).Encode(observation.InUnits(conv)); err != nil {
 			log.Printf("Error encoding observation response: %v", err)
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
 	}
 }
+
+// handleStationHistory handles the per-station observation history endpoint
+func handleStationHistory(w http.ResponseWriter, r *http.Request, mgr Manager, stationID string) {
+	from, to, err := parseTimeRange(r, 3*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conv, ok := parseConvention(w, r)
+	if !ok {
+		return
+	}
+
+	history := observationsInUnits(mgr.GetHistory(stationID, from, to), conv)
+
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		log.Printf("Error encoding history response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleStationRefresh schedules an immediate poll of a station (POST)
+func handleStationRefresh(w http.ResponseWriter, r *http.Request, mgr Manager, stationID string) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch err := mgr.Refresh(stationID); {
+	case errors.Is(err, ErrUnknownStation):
+		http.Error(w, "Station not found", http.StatusNotFound)
+		return
+	case errors.Is(err, ErrRefreshTooSoon):
+		w.Header().Set("Retry-After", strconv.Itoa(int(minPollGap.Seconds())))
+		http.Error(w, "Station was polled less than "+minPollGap.String()+" ago", http.StatusTooManyRequests)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "scheduled"}); err != nil {
+		log.Printf("Error encoding refresh response: %v", err)
+	}
+}
+
+// parseTimeRange reads the RFC 3339 from/to query parameters. Missing values default
+// to the last defaultSpan ending now.
+func parseTimeRange(r *http.Request, defaultSpan time.Duration) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+
+	to := time.Now()
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultSpan)
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+	return from, to, nil
+}
+
+// handleGroupObservations handles the latest observations for a station group
+func handleGroupObservations(mgr Manager, stationMgr stations.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		conv, ok := parseConvention(w, r)
+		if !ok {
+			return
+		}
+
+		groupStations, exists := stationMgr.GetGroupStations(r.PathValue("id"))
+		if !exists {
+			http.Error(w, "Group not found", http.StatusNotFound)
+			return
+		}
+
+		// Keep the group's station order; skip stations without data
+		result := make([]WindObservation, 0, len(groupStations))
+		for _, station := range groupStations {
+			if obs, ok := mgr.GetLatestObservation(station.ID); ok {
+				result = append(result, obs.InUnits(conv))
+			}
+		}
+
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			log.Printf("Error encoding group observations response: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
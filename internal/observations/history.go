@@ -0,0 +1,159 @@
+package observations
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultHistoryRetention is how long fetched samples are kept in memory per station
+const DefaultHistoryRetention = 24 * time.Hour
+
+// stationHistory is a bounded, time-ordered ring buffer of observations for one station
+type stationHistory struct {
+	buf         []WindObservation
+	head        int // Index of the oldest sample
+	size        int
+	retention   time.Duration
+	maxCapacity int            // Limit the buffer may grow to while every sample is within retention
+	flags       map[string]int // Samples in the buffer per quality flag
+}
+
+// newStationHistory creates a ring buffer sized for one-minute data over the retention
+// window. Stations reporting faster, such as ingest uploads every few seconds, grow it
+// up to one sample per second.
+func newStationHistory(retention time.Duration) *stationHistory {
+	capacity := int(retention/IntervalFast) + 64
+	return &stationHistory{
+		buf:         make([]WindObservation, capacity),
+		retention:   retention,
+		maxCapacity: max(int(retention/time.Second), capacity),
+	}
+}
+
+// at returns the i-th oldest sample
+func (h *stationHistory) at(i int) WindObservation {
+	return h.buf[(h.head+i)%len(h.buf)]
+}
+
+func (h *stationHistory) set(i int, obs WindObservation) {
+	h.buf[(h.head+i)%len(h.buf)] = obs
+}
+
+// setQuality replaces the flags of the i-th oldest sample
+func (h *stationHistory) setQuality(i int, quality string) {
+	obs := h.at(i)
+	h.tally(obs.Quality, -1)
+	h.tally(quality, 1)
+	obs.Quality = quality
+	h.set(i, obs)
+}
+
+// tally adds delta to the count of each flag in quality
+func (h *stationHistory) tally(quality string, delta int) {
+	if quality == "" {
+		return
+	}
+	if h.flags == nil {
+		h.flags = make(map[string]int)
+	}
+	for flag := range strings.SplitSeq(quality, ",") {
+		if h.flags[flag] += delta; h.flags[flag] <= 0 {
+			delete(h.flags, flag)
+		}
+	}
+}
+
+// search returns the index of the first sample not before t
+func (h *stationHistory) search(t time.Time) int {
+	return sort.Search(h.size, func(i int) bool {
+		return !h.at(i).Timestamp.Before(t)
+	})
+}
+
+// find returns the sample with the given timestamp
+func (h *stationHistory) find(t time.Time) (WindObservation, bool) {
+	if i := h.search(t); i < h.size && h.at(i).Timestamp.Equal(t) {
+		return h.at(i), true
+	}
+	return WindObservation{}, false
+}
+
+// add inserts a sample in timestamp order, replacing a sample with the same timestamp
+// and evicting samples that fall outside capacity or the retention window. It reports
+// whether the sample was new or changed an existing one. A replaced sample keeps its
+// quality flags unless the new one carries flags of its own.
+func (h *stationHistory) add(obs WindObservation) bool {
+	pos := h.search(obs.Timestamp)
+	if pos < h.size && h.at(pos).Timestamp.Equal(obs.Timestamp) {
+		old := h.at(pos)
+		if obs.Quality == "" {
+			obs.Quality = old.Quality
+		}
+		h.tally(old.Quality, -1)
+		h.tally(obs.Quality, 1)
+		h.set(pos, obs)
+		return old.WindSpeed != obs.WindSpeed || old.WindGust != obs.WindGust || old.WindDirection != obs.WindDirection
+	}
+
+	if h.size == len(h.buf) && len(h.buf) < h.maxCapacity {
+		h.grow()
+	}
+	if h.size == len(h.buf) {
+		if pos == 0 {
+			return false // Older than everything in a full buffer
+		}
+		h.tally(h.at(0).Quality, -1)
+		h.head = (h.head + 1) % len(h.buf)
+		h.size--
+		pos--
+	}
+
+	// Append, then shift into place (out-of-order inserts are rare)
+	h.size++
+	for i := h.size - 1; i > pos; i-- {
+		h.set(i, h.at(i-1))
+	}
+	h.set(pos, obs)
+	h.tally(obs.Quality, 1)
+
+	h.expire()
+	return true
+}
+
+// grow doubles the buffer up to maxCapacity, moving the oldest sample to the front.
+// Expired samples are dropped on every add, so a full buffer holds only retained ones.
+func (h *stationHistory) grow() {
+	buf := make([]WindObservation, min(2*len(h.buf), h.maxCapacity))
+	for i := range h.size {
+		buf[i] = h.at(i)
+	}
+	h.buf, h.head = buf, 0
+}
+
+// expire drops samples older than the retention window relative to the newest sample
+func (h *stationHistory) expire() {
+	if h.size == 0 {
+		return
+	}
+	cutoff := h.at(h.size - 1).Timestamp.Add(-h.retention)
+	for h.size > 0 && h.at(0).Timestamp.Before(cutoff) {
+		h.tally(h.at(0).Quality, -1)
+		h.buf[h.head] = WindObservation{}
+		h.head = (h.head + 1) % len(h.buf)
+		h.size--
+	}
+}
+
+// rangeQuery returns samples with from <= timestamp <= to in time order
+func (h *stationHistory) rangeQuery(from, to time.Time) []WindObservation {
+	result := []WindObservation{}
+	for i := h.search(from); i < h.size; i++ {
+		obs := h.at(i)
+		if obs.Timestamp.After(to) {
+			break
+		}
+		result = append(result, obs)
+	}
+	return result
+}
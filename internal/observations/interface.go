@@ -2,7 +2,15 @@ package observations
 
 import (
 	"context"
+	"errors"
 	"time"
+	"windz/internal/units"
+)
+
+// Errors returned by Refresh
+var (
+	ErrUnknownStation = errors.New("unknown station")
+	ErrRefreshTooSoon = errors.New("station was polled too recently")
 )
 
 // Manager defines the interface for observation polling and data management
@@ -21,28 +29,63 @@ type Manager interface {
 
 	// GetPollingState returns the current polling state for a station
 	GetPollingState(stationID string) (PollingState, bool)
+
+	// GetHistory returns the stored observations for a station within [from, to] in time order
+	GetHistory(stationID string, from, to time.Time) []WindObservation
+
+	// GetQualityFlags returns how many samples in a station's in-memory history carry each
+	// quality flag, nil when none are flagged
+	GetQualityFlags(stationID string) map[string]int
+
+	// AddObservationListener registers a function called with every new latest observation
+	AddObservationListener(listener func(WindObservation))
+
+	// Refresh schedules an immediate poll of a station. It returns ErrUnknownStation for
+	// unknown stations and ErrRefreshTooSoon when the station was polled too recently to
+	// poll again now; that poll follows once the minimum gap has passed.
+	Refresh(stationID string) error
+
+	// Wake re-evaluates the polling schedule, e.g. after stations or SSE clients change
+	Wake()
+
+	// GetFreshness returns how current a station's data is
+	GetFreshness(stationID string) Freshness
+
+	// AddFreshnessListener registers a function called when a station's freshness changes
+	AddFreshnessListener(listener func(FreshnessEvent))
+
+	// UpstreamStatus returns the state of the FMI circuit breaker
+	UpstreamStatus() UpstreamStatus
 }
 
 // WindObservation represents a wind observation from FMI
 type WindObservation struct {
-	StationID     string    `json:"station_id"`
-	StationName   string    `json:"station_name"`
-	Region        string    `json:"region"`
-	Timestamp     time.Time `json:"timestamp"`
-	WindSpeed     float64   `json:"wind_speed"`
-	WindGust      float64   `json:"wind_gust"`
-	WindDirection float64   `json:"wind_direction"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	StationID     string     `json:"station_id"`
+	StationName   string     `json:"station_name"`
+	Region        string     `json:"region"`
+	Timestamp     time.Time  `json:"timestamp"`
+	WindSpeed     float64    `json:"wind_speed"`
+	WindGust      float64    `json:"wind_gust"`
+	WindDirection float64    `json:"wind_direction"`
+	MaxGust60m    float64    `json:"max_gust_60m,omitempty"` // Rolling maximum gust over the last hour
+	Trend         *Trend     `json:"trend,omitempty"`
+	Quality       string     `json:"quality,omitempty"`   // Comma separated quality control flags, empty when the sample passed
+	Freshness     Freshness  `json:"freshness,omitempty"` // How current the station's data is, set on latest observations
+	Units         units.Unit `json:"units,omitempty"`     // Speed unit when converted with units=, m/s otherwise
+	Compass       string     `json:"compass,omitempty"`   // Compass point of the direction when converted
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 // PollingState represents the adaptive polling state for a station
 type PollingState struct {
-	StationID         string        `json:"station_id"`
-	CurrentInterval   time.Duration `json:"current_interval"`
-	ConsecutiveMisses int           `json:"consecutive_misses"`
-	LastPolled        time.Time     `json:"last_polled"`
-	LastObservation   time.Time     `json:"last_observation"`
-	SuccessRate       float64       `json:"success_rate"`
-	TotalPolls        int           `json:"total_polls"`
-	SuccessfulPolls   int           `json:"successful_polls"`
+	StationID         string          `json:"station_id"`
+	CurrentInterval   time.Duration   `json:"current_interval"`
+	Cadence           time.Duration   `json:"cadence,omitempty"` // Learned observation interval, zero until seen
+	ConsecutiveMisses int             `json:"consecutive_misses"`
+	LastPolled        time.Time       `json:"last_polled"`
+	LastObservation   time.Time       `json:"last_observation"`
+	SuccessRate       float64         `json:"success_rate"`
+	TotalPolls        int             `json:"total_polls"`
+	SuccessfulPolls   int             `json:"successful_polls"`
+	Lag               *PublicationLag `json:"publication_lag,omitempty"`
 }
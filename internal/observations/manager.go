@@ -2,16 +2,20 @@ package observations
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"maps"
+	"math"
 	"net/http"
-	"os"
 	"sync"
 	"time"
+	"windz/internal/checkpoint"
+	"windz/internal/clock"
 	"windz/internal/sse"
 	"windz/internal/stations"
-	"windz/pkg/fmi/observations"
+	"windz/internal/store"
 )
 
 // Polling intervals
@@ -22,21 +26,82 @@ const (
 	IntervalUltraSlow = 24 * time.Hour
 )
 
+// Config holds the observation manager settings
+type Config struct {
+	StateFile        string        // Polling state persistence file
+	WindDataFile     string        // Latest observation persistence file
+	Debug            bool          // Enable debug logging
+	HistoryRetention time.Duration // In-memory history window per station (0 uses the default)
+	Store            store.Store   // Durable observation store (nil disables persistence)
+	StoreRetention   time.Duration // How long stored observations are kept (0 keeps everything)
+	BackfillLookback time.Duration // How far back gaps are filled from FMI (0 disables backfill)
+	BackfillRate     int           // Backfill FMI requests per minute (0 uses the default)
+
+	TrendWindow             time.Duration // Window of the speed and direction trend (0 uses the default)
+	TrendSpeedThreshold     float64       // m/s per hour for building/dropping (0 uses the default)
+	TrendDirectionThreshold float64       // Degrees per hour for veering/backing (0 uses the default)
+	Clock                   clock.Clock   // Time source for polling and scheduling (nil uses the system clock)
+
+	CheckpointInterval time.Duration // How often state files are written while running (0 uses the default)
+
+	BreakerThreshold int           // Consecutive source failures that open its circuit breaker (0 uses the default)
+	BreakerCooldown  time.Duration // Wait before probing a source after its breaker opens (0 uses the default)
+
+	Sources map[string]Source // Observation sources by name; FMI is added under SourceFMI when missing
+
+	BackgroundInterval time.Duration // Polling interval of stations without SSE subscribers (0 uses the default)
+}
+
+// storeCompactionInterval is how often the observation store is compacted
+const storeCompactionInterval = 1 * time.Hour
+
+// DefaultBackgroundInterval is how often stations nobody is subscribed to are polled
+const DefaultBackgroundInterval = IntervalSlow
+
+// DefaultCheckpointInterval is how often polling state and latest observations are saved
+const DefaultCheckpointInterval = 5 * time.Minute
+
 // manager implements the Observations Manager interface
 type manager struct {
 	stationMgr   stations.Manager
 	sseMgr       sse.Manager
-	fmiClient    *http.Client
 	stateFile    string
 	windDataFile string
 	debug        bool
 
+	backgroundInterval time.Duration
+
+	checkpointInterval time.Duration
+	checkpointMu       sync.Mutex    // Serializes state file writes
+	checkpointDone     chan struct{} // Closed when the checkpoint loop has exited
+
 	// State management
 	windData      map[string]WindObservation
 	windDataMutex sync.RWMutex
 
+	history          map[string]*stationHistory
+	historyRetention time.Duration
+	historyMutex     sync.RWMutex
+
+	store          store.Store
+	storeRetention time.Duration
+
+	clock clock.Clock
+
+	backfill *backfiller
+	trend    trendConfig
+
+	listeners          []func(WindObservation)
+	freshnessListeners []func(FreshnessEvent)
+	listenersMu        sync.RWMutex
+
+	freshness   map[string]Freshness // Last announced freshness per station
+	freshnessMu sync.Mutex
+
 	pollingStates      map[string]*PollingState
 	pollingStatesMutex sync.RWMutex
+	schedule           *pollSchedule
+	upstreams          map[string]*upstream // Sources by name, each behind its own circuit breaker
 
 	// Polling control
 	ctx       context.Context
@@ -48,17 +113,98 @@ type manager struct {
 
 // NewManager creates a new observation manager instance
 func NewManager(stationMgr stations.Manager, sseMgr sse.Manager, stateFile, windDataFile string, debug bool) Manager {
-	return &manager{
-		stationMgr:    stationMgr,
-		sseMgr:        sseMgr,
-		fmiClient:     &http.Client{Timeout: 60 * time.Second},
-		stateFile:     stateFile,
-		windDataFile:  windDataFile,
-		debug:         debug,
-		windData:      make(map[string]WindObservation),
-		pollingStates: make(map[string]*PollingState),
-		stopCh:        make(chan struct{}),
+	return NewManagerWithConfig(stationMgr, sseMgr, Config{
+		StateFile:    stateFile,
+		WindDataFile: windDataFile,
+		Debug:        debug,
+	})
+}
+
+// NewManagerWithConfig creates a new observation manager instance from a full configuration
+func NewManagerWithConfig(stationMgr stations.Manager, sseMgr sse.Manager, cfg Config) Manager {
+	if cfg.HistoryRetention <= 0 {
+		cfg.HistoryRetention = DefaultHistoryRetention
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = clock.System
+	}
+
+	if cfg.CheckpointInterval <= 0 {
+		cfg.CheckpointInterval = DefaultCheckpointInterval
+	}
+	if cfg.BackgroundInterval <= 0 {
+		cfg.BackgroundInterval = DefaultBackgroundInterval
+	}
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = DefaultBreakerThreshold
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = DefaultBreakerCooldown
+	}
+
+	if cfg.TrendWindow <= 0 {
+		cfg.TrendWindow = DefaultTrendWindow
+	}
+	if cfg.TrendSpeedThreshold <= 0 {
+		cfg.TrendSpeedThreshold = DefaultTrendSpeedThreshold
+	}
+	if cfg.TrendDirectionThreshold <= 0 {
+		cfg.TrendDirectionThreshold = DefaultTrendDirectionThreshold
+	}
+
+	m := &manager{
+		stationMgr:         stationMgr,
+		sseMgr:             sseMgr,
+		stateFile:          cfg.StateFile,
+		windDataFile:       cfg.WindDataFile,
+		debug:              cfg.Debug,
+		backgroundInterval: cfg.BackgroundInterval,
+		checkpointInterval: cfg.CheckpointInterval,
+		windData:           make(map[string]WindObservation),
+		history:            make(map[string]*stationHistory),
+		historyRetention:   cfg.HistoryRetention,
+		store:              cfg.Store,
+		storeRetention:     cfg.StoreRetention,
+		clock:              cfg.Clock,
+		pollingStates:      make(map[string]*PollingState),
+		freshness:          make(map[string]Freshness),
+		schedule:           newPollSchedule(),
+		stopCh:             make(chan struct{}),
+		trend: trendConfig{
+			window:             cfg.TrendWindow,
+			speedThreshold:     cfg.TrendSpeedThreshold,
+			directionThreshold: cfg.TrendDirectionThreshold,
+		},
+	}
+
+	sources := maps.Clone(cfg.Sources)
+	if sources == nil {
+		sources = make(map[string]Source)
 	}
+	if sources[SourceFMI] == nil {
+		sources[SourceFMI] = NewFMISource(&http.Client{Timeout: 60 * time.Second})
+	}
+	m.upstreams = make(map[string]*upstream, len(sources))
+	for name, source := range sources {
+		m.upstreams[name] = &upstream{
+			source: source,
+			breaker: newBreaker(cfg.Clock, cfg.BreakerThreshold, cfg.BreakerCooldown, func(status UpstreamStatus) {
+				m.broadcastUpstream(name, status)
+			}),
+		}
+	}
+
+	if cfg.BackfillLookback > 0 {
+		// Without a store only the in-memory window can be checked for gaps
+		lookback := cfg.BackfillLookback
+		if cfg.Store == nil && lookback > cfg.HistoryRetention {
+			lookback = cfg.HistoryRetention
+		}
+		m.backfill = newBackfiller(m, m.fetchWindDataBatch, lookback, cfg.BackfillRate)
+	}
+
+	return m
 }
 
 // Start begins the observation polling process
@@ -73,12 +219,32 @@ func (m *manager) Start(ctx context.Context) error {
 	// Create context for this manager
 	m.ctx, m.cancel = context.WithCancel(ctx)
 
-	// Load persistent state
-	m.loadPollingStates()
-	m.loadWindData()
+	// Load persistent state. A file from a newer release stops the start, since the
+	// first checkpoint would otherwise replace it and rotate it out through the backup.
+	if err := m.loadPollingStates(); err != nil {
+		m.cancel()
+		return fmt.Errorf("loading polling states: %w", err)
+	}
+	if err := m.loadWindData(); err != nil {
+		m.cancel()
+		return fmt.Errorf("loading wind data: %w", err)
+	}
+	m.loadStoredHistory()
+
+	// Record the starting freshness before the first poll can change it
+	m.updateFreshness(m.stationIDs()...)
 
 	// Start polling scheduler
 	go m.runPollingScheduler()
+	m.checkpointDone = make(chan struct{})
+	go m.runCheckpoints()
+	go m.runFreshness()
+	if m.store != nil {
+		go m.runStoreCompaction()
+	}
+	if m.backfill != nil {
+		go m.backfill.run(m.ctx, m.stopCh)
+	}
 
 	m.isRunning = true
 	log.Println("Observation manager started")
@@ -99,9 +265,9 @@ func (m *manager) Stop() error {
 		m.cancel()
 	}
 
-	// Save state before stopping
-	m.savePollingStates()
-	m.saveWindData()
+	// Save state before stopping, once a periodic checkpoint can no longer follow
+	<-m.checkpointDone
+	m.saveState()
 
 	// Signal stop and wait
 	close(m.stopCh)
@@ -114,22 +280,30 @@ func (m *manager) Stop() error {
 // GetLatestObservation returns the latest observation for a specific station
 func (m *manager) GetLatestObservation(stationID string) (WindObservation, bool) {
 	m.windDataMutex.RLock()
-	defer m.windDataMutex.RUnlock()
-
 	obs, exists := m.windData[stationID]
+	m.windDataMutex.RUnlock()
+
+	if exists {
+		obs.Freshness, _ = m.stationFreshness(stationID, m.clock.Now())
+	}
 	return obs, exists
 }
 
 // GetAllLatestObservations returns all latest observations indexed by station ID
 func (m *manager) GetAllLatestObservations() map[string]WindObservation {
 	m.windDataMutex.RLock()
-	defer m.windDataMutex.RUnlock()
-
 	// Return a copy to prevent external modification
 	result := make(map[string]WindObservation)
 	for k, v := range m.windData {
 		result[k] = v
 	}
+	m.windDataMutex.RUnlock()
+
+	now := m.clock.Now()
+	for stationID, obs := range result {
+		obs.Freshness, _ = m.stationFreshness(stationID, now)
+		result[stationID] = obs
+	}
 	return result
 }
 
@@ -144,61 +318,287 @@ func (m *manager) GetPollingState(stationID string) (PollingState, bool) {
 	}
 
 	// Return a copy
-	return *state, true
+	return state.clone(), true
+}
+
+// GetHistory returns the stored observations for a station within [from, to] in time order.
+// Ranges reaching past the in-memory window are read from the store when one is configured.
+func (m *manager) GetHistory(stationID string, from, to time.Time) []WindObservation {
+	if m.store != nil && from.Before(m.clock.Now().Add(-m.historyRetention)) {
+		records, err := m.store.Range(stationID, from, to)
+		if err != nil {
+			log.Printf("Error reading stored history for station %s: %v", stationID, err)
+		} else {
+			return m.recordsToObservations(stationID, records)
+		}
+	}
+
+	m.historyMutex.RLock()
+	defer m.historyMutex.RUnlock()
+
+	h, exists := m.history[stationID]
+	if !exists {
+		return []WindObservation{}
+	}
+	return h.rangeQuery(from, to)
+}
+
+// recordHistory adds fetched samples to the station's in-memory history and
+// persists the new ones to the store
+func (m *manager) recordHistory(stationID string, observations []FMIWindObservation) {
+	if len(observations) == 0 {
+		return
+	}
+
+	station, exists := m.stationMgr.GetStation(stationID)
+	if !exists {
+		return
+	}
+	now := m.clock.Now()
+
+	var records []store.Record
+
+	m.historyMutex.Lock()
+	h, exists := m.history[stationID]
+	if !exists {
+		h = newStationHistory(m.historyRetention)
+		m.history[stationID] = h
+	}
+
+	added := make(map[time.Time]bool)
+	var earliest time.Time
+	for _, obs := range observations {
+		isNew := h.add(WindObservation{
+			StationID:     stationID,
+			StationName:   station.Name,
+			Region:        station.Region,
+			Timestamp:     obs.Timestamp,
+			WindSpeed:     obs.WindSpeed,
+			WindGust:      obs.WindGust,
+			WindDirection: obs.WindDirection,
+			UpdatedAt:     now,
+		})
+		if isNew {
+			added[obs.Timestamp] = true
+			if earliest.IsZero() || obs.Timestamp.Before(earliest) {
+				earliest = obs.Timestamp
+			}
+		}
+	}
+
+	changed := maps.Clone(added)
+	if from := h.search(earliest); len(added) > 0 && from < h.size {
+		// New samples can change the flags of the samples before them
+		for _, ts := range m.checkQuality(station, h, from) {
+			changed[ts] = true
+			earliest = minTime(earliest, ts)
+		}
+	}
+
+	for _, obs := range observations {
+		if _, kept := h.find(obs.Timestamp); added[obs.Timestamp] && !kept {
+			// Already outside the retention window, so stored without checks
+			records = append(records, store.Record{
+				StationID:     stationID,
+				Timestamp:     obs.Timestamp,
+				WindSpeed:     obs.WindSpeed,
+				WindGust:      obs.WindGust,
+				WindDirection: obs.WindDirection,
+			})
+			delete(added, obs.Timestamp)
+		}
+	}
+	for i := h.search(earliest); i < h.size; i++ {
+		if obs := h.at(i); changed[obs.Timestamp] {
+			records = append(records, store.Record{
+				StationID:     stationID,
+				Timestamp:     obs.Timestamp,
+				WindSpeed:     obs.WindSpeed,
+				WindGust:      obs.WindGust,
+				WindDirection: obs.WindDirection,
+				Quality:       obs.Quality,
+			})
+		}
+	}
+	m.historyMutex.Unlock()
+
+	if m.store != nil && len(records) > 0 {
+		if err := m.store.Append(records...); err != nil {
+			log.Printf("Error storing observations for station %s: %v", stationID, err)
+		}
+	}
+}
+
+// AddObservationListener registers a function called with every new latest observation
+func (m *manager) AddObservationListener(listener func(WindObservation)) {
+	m.listenersMu.Lock()
+	defer m.listenersMu.Unlock()
+
+	m.listeners = append(m.listeners, listener)
+}
+
+// rollingMaxGust returns the highest gust in the hour ending at the given time,
+// including the gust of the observation itself
+func (m *manager) rollingMaxGust(stationID string, at time.Time, gust float64) float64 {
+	m.historyMutex.RLock()
+	defer m.historyMutex.RUnlock()
+
+	if h, exists := m.history[stationID]; exists {
+		gust = math.Max(gust, maxGustBetween(unflagged(h.rangeQuery(at.Add(-RollingGustWindow), at)), at.Add(-RollingGustWindow), at))
+	}
+	return gust
+}
+
+// recordsToObservations converts stored records into API observations
+func (m *manager) recordsToObservations(stationID string, records []store.Record) []WindObservation {
+	station, _ := m.stationMgr.GetStation(stationID)
+
+	result := make([]WindObservation, 0, len(records))
+	for _, rec := range records {
+		result = append(result, WindObservation{
+			StationID:     stationID,
+			StationName:   station.Name,
+			Region:        station.Region,
+			Timestamp:     rec.Timestamp,
+			WindSpeed:     rec.WindSpeed,
+			WindGust:      rec.WindGust,
+			WindDirection: rec.WindDirection,
+			Quality:       rec.Quality,
+			UpdatedAt:     rec.Timestamp,
+		})
+	}
+	return result
 }
 
-// runPollingScheduler runs the main polling loop
+// runPollingScheduler polls stations as they become due, sleeping until the next
+// due time or until the schedule is woken
 func (m *manager) runPollingScheduler() {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+	m.resyncSchedule()
 
-	// Initial poll
-	m.pollDueStations()
+	timer := m.clock.NewTimer(m.pollDueStations())
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			m.pollDueStations()
+		case <-timer.C():
+		case <-m.schedule.wakeCh:
 		case <-m.ctx.Done():
 			return
 		case <-m.stopCh:
 			return
 		}
+
+		timer.Reset(m.pollDueStations())
+	}
+}
+
+// pollDueStations polls every station that is due and returns the time until the
+// next one is
+func (m *manager) pollDueStations() time.Duration {
+	for {
+		m.applyScheduleRequests()
+
+		now := m.clock.Now()
+		due := m.schedule.popDue(now)
+		if len(due) == 0 {
+			break
+		}
+
+		// While a source is unavailable its due stations wait until it can be probed
+		ready := due[:0]
+		for _, stationID := range due {
+			if wait := m.sourceWait(m.stationSource(stationID)); wait > 0 {
+				m.schedule.set(stationID, now.Add(wait))
+				continue
+			}
+			ready = append(ready, stationID)
+		}
+		m.pollStations(ready)
+	}
+
+	wait := idleScheduleWait
+	if next, ok := m.schedule.next(); ok {
+		wait = next.Sub(m.clock.Now())
 	}
+	return wait
 }
 
-// pollDueStations polls stations that are due for updates
-func (m *manager) pollDueStations() {
-	// Check if we have any SSE clients connected
-	hasSSEClients := m.sseMgr.HasClients()
+// sourceWait returns how long a source's circuit breaker keeps rejecting requests
+func (m *manager) sourceWait(source string) time.Duration {
+	if up, exists := m.upstreams[source]; exists {
+		return up.breaker.wait()
+	}
+	return 0
+}
 
-	// Get all stations from station manager
+// resyncSchedule recomputes every station's next poll from its polling state,
+// adding new stations and dropping removed ones
+func (m *manager) resyncSchedule() {
 	allStations := m.stationMgr.GetAllStations()
+	current := make(map[string]bool, len(allStations))
 
-	// Phase 1: Collect stations to poll (hold lock briefly)
 	m.pollingStatesMutex.Lock()
-	now := time.Now()
-	toPoll := []PollingState{} // Values, not pointers
-
-	// Determine which stations need polling
 	for _, station := range allStations {
-		state, exists := m.pollingStates[station.ID]
+		current[station.ID] = true
+		state := m.pollingStateLocked(station.ID)
+		m.schedule.set(station.ID, m.nextPoll(state))
+	}
+	m.pollingStatesMutex.Unlock()
 
-		if !exists {
-			state = &PollingState{
-				StationID:       station.ID,
-				CurrentInterval: IntervalFast,
-			}
-			m.pollingStates[station.ID] = state
+	for _, stationID := range m.schedule.stationIDs() {
+		if !current[stationID] {
+			m.schedule.remove(stationID)
 		}
+	}
+}
+
+// applyScheduleRequests handles pending Wake and Refresh calls
+func (m *manager) applyScheduleRequests() {
+	resync, forced := m.schedule.takeRequests()
+	if resync {
+		m.resyncSchedule()
+	}
+
+	now := m.clock.Now()
+	for stationID, at := range forced {
+		m.schedule.set(stationID, maxTime(at, now))
+	}
+}
+
+// pollingStateLocked returns a station's polling state, creating it if needed.
+// The caller must hold pollingStatesMutex.
+func (m *manager) pollingStateLocked(stationID string) *PollingState {
+	state, exists := m.pollingStates[stationID]
+	if !exists {
+		// Start fast to learn the cadence unless the source already knows it
+		interval := IntervalFast
+		if cadence := m.sourceCadence(stationID); cadence > 0 {
+			interval = roundToStandardInterval(cadence)
+		}
+		state = &PollingState{
+			StationID:       stationID,
+			CurrentInterval: interval,
+		}
+		m.pollingStates[stationID] = state
+	}
+	return state
+}
 
-		// Check if polling is due
-		effectiveInterval := getEffectivePollingInterval(state.CurrentInterval, hasSSEClients)
-		if now.Sub(state.LastPolled) >= effectiveInterval {
-			// Append a copy of the state
-			toPoll = append(toPoll, *state)
+// pollStations polls the given stations in batches and schedules their next polls
+func (m *manager) pollStations(stationIDs []string) {
+	known := make([]string, 0, len(stationIDs))
+	for _, stationID := range stationIDs {
+		if _, exists := m.stationMgr.GetStation(stationID); exists {
+			known = append(known, stationID)
 		}
 	}
+
+	// Phase 1: Collect states to poll (hold lock briefly)
+	m.pollingStatesMutex.Lock()
+	toPoll := make([]PollingState, 0, len(known)) // Values, not pointers
+	for _, stationID := range known {
+		toPoll = append(toPoll, m.pollingStateLocked(stationID).clone())
+	}
 	m.pollingStatesMutex.Unlock() // Release lock early!
 
 	if len(toPoll) == 0 {
@@ -210,9 +610,9 @@ func (m *manager) pollDueStations() {
 	}
 
 	// Phase 2: Process polling without holding lock
-	m.processBatchedPolling(toPoll, hasSSEClients)
+	m.processBatchedPolling(toPoll)
 
-	// Phase 3: Write back updated states
+	// Phase 3: Write back updated states and schedule the next polls
 	m.pollingStatesMutex.Lock()
 	for i := range toPoll {
 		if currentState, exists := m.pollingStates[toPoll[i].StationID]; exists {
@@ -221,27 +621,77 @@ func (m *manager) pollDueStations() {
 		}
 	}
 	m.pollingStatesMutex.Unlock()
+
+	// Stations whose source rejected them wait until it can be probed again
+	now := m.clock.Now()
+	for i := range toPoll {
+		next := m.nextPoll(&toPoll[i])
+		if wait := m.sourceWait(m.stationSource(toPoll[i].StationID)); wait > 0 && next.Before(now.Add(wait)) {
+			next = now.Add(wait)
+		}
+		m.schedule.set(toPoll[i].StationID, next)
+	}
+}
+
+// nextPoll returns when a station should be polled next, at its adaptive rate while
+// SSE clients are subscribed to it and at the background rate otherwise
+func (m *manager) nextPoll(state *PollingState) time.Time {
+	return nextPollTime(state, m.sseMgr.Subscribers(state.StationID) > 0, m.backgroundInterval)
+}
+
+// Refresh schedules an immediate poll of a station. A station polled within the last
+// minPollGap is polled when the gap ends and ErrRefreshTooSoon is returned, so repeated
+// refreshes never poll a source more often than the scheduler itself would.
+func (m *manager) Refresh(stationID string) error {
+	if _, exists := m.stationMgr.GetStation(stationID); !exists {
+		return ErrUnknownStation
+	}
+
+	m.pollingStatesMutex.RLock()
+	var earliest time.Time
+	if state, exists := m.pollingStates[stationID]; exists && !state.LastPolled.IsZero() {
+		earliest = state.LastPolled.Add(minPollGap)
+	}
+	m.pollingStatesMutex.RUnlock()
+
+	m.schedule.requestPoll(stationID, earliest)
+	if earliest.After(m.clock.Now()) {
+		return ErrRefreshTooSoon
+	}
+	return nil
+}
+
+// Wake re-evaluates the polling schedule, e.g. after stations or SSE clients change
+func (m *manager) Wake() {
+	m.schedule.requestResync()
+}
+
+// pollGroup identifies stations that can share a request: same source and time window
+type pollGroup struct {
+	source string
+	start  time.Time
 }
 
-// processBatchedPolling handles the batched FMI API requests
-func (m *manager) processBatchedPolling(toPoll []PollingState, hasSSEClients bool) {
-	const maxBatchSize = 20
-	endTime := time.Now()
+// processBatchedPolling handles the batched source requests
+func (m *manager) processBatchedPolling(toPoll []PollingState) {
+	endTime := m.clock.Now()
 	defaultStartTime := endTime.Add(-2 * time.Hour)
 
-	// Group stations by effective time windows (store indices)
-	timeWindowGroups := make(map[time.Time][]int)
+	// Group stations by source and effective time window (store indices)
+	groups := make(map[pollGroup][]int)
 
 	for i := range toPoll {
 		effectiveStartTime := defaultStartTime
 		if toPoll[i].LastObservation.After(defaultStartTime) {
 			effectiveStartTime = toPoll[i].LastObservation.Add(time.Second).Truncate(time.Second)
 		}
-		timeWindowGroups[effectiveStartTime] = append(timeWindowGroups[effectiveStartTime], i)
+		group := pollGroup{source: m.stationSource(toPoll[i].StationID), start: effectiveStartTime}
+		groups[group] = append(groups[group], i)
 	}
 
-	// Process each time window group in batches
-	for groupStartTime, indices := range timeWindowGroups {
+	// Process each group in batches
+	for group, indices := range groups {
+		maxBatchSize := m.batchSize(group.source)
 		for start := 0; start < len(indices); start += maxBatchSize {
 			end := start + maxBatchSize
 			if end > len(indices) {
@@ -255,9 +705,16 @@ func (m *manager) processBatchedPolling(toPoll []PollingState, hasSSEClients boo
 				stationIDs[j] = toPoll[idx].StationID
 			}
 
-			batchResults, err := m.fetchWindDataBatch(stationIDs, groupStartTime, endTime)
+			batchResults, err := m.fetchWindDataBatch(group.source, stationIDs, group.start, endTime)
+			if errors.Is(err, ErrUpstreamUnavailable) {
+				continue
+			}
 			if err != nil {
 				log.Printf("Error fetching wind data for batch: %v", err)
+				if m.sourceStatus(group.source).State != BreakerClosed {
+					// Freeze the states: a source outage says nothing about the stations
+					continue
+				}
 				// Mark all stations as failed
 				for _, idx := range batchIndices {
 					m.updateFailedPollingState(&toPoll[idx])
@@ -273,6 +730,9 @@ func (m *manager) processBatchedPolling(toPoll []PollingState, hasSSEClients boo
 					observations = []FMIWindObservation{}
 				}
 
+				// Keep every fetched sample, not just the latest
+				m.recordHistory(state.StationID, observations)
+
 				oldInterval := state.CurrentInterval
 				latestObs, hasData := m.updatePollingState(state, observations)
 
@@ -290,59 +750,7 @@ func (m *manager) processBatchedPolling(toPoll []PollingState, hasSSEClients boo
 	}
 }
 
-// fetchWindDataBatch fetches wind data for multiple stations
-func (m *manager) fetchWindDataBatch(stationIDs []string, startTime, endTime time.Time) (map[string][]FMIWindObservation, error) {
-	if len(stationIDs) == 0 {
-		return make(map[string][]FMIWindObservation), nil
-	}
-
-	query := observations.NewQuery("https://opendata.fmi.fi/wfs", m.fmiClient)
-
-	req := observations.Request{
-		StartTime:  startTime,
-		EndTime:    endTime,
-		StationIDs: stationIDs,
-		UseGzip:    true,
-	}
-
-	response, err := query.Execute(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch wind data: %w", err)
-	}
-
-	// Convert to our format
-	results := make(map[string][]FMIWindObservation)
-	for _, station := range response.Stations {
-		stationResults := make([]FMIWindObservation, 0, len(station.Observations))
-
-		for _, obs := range station.Observations {
-			windObs := FMIWindObservation{
-				Timestamp: obs.Timestamp,
-			}
-
-			if obs.WindSpeed != nil {
-				windObs.WindSpeed = *obs.WindSpeed
-			}
-			if obs.WindGust != nil {
-				windObs.WindGust = *obs.WindGust
-			}
-			if obs.WindDirection != nil {
-				windObs.WindDirection = *obs.WindDirection
-			}
-
-			// Only include valid observations
-			if windObs.WindSpeed >= 0 && windObs.WindSpeed < 100 {
-				stationResults = append(stationResults, windObs)
-			}
-		}
-
-		results[station.StationID] = stationResults
-	}
-
-	return results, nil
-}
-
-// FMIWindObservation represents wind data from FMI API
+// FMIWindObservation represents wind data as fetched from a source
 type FMIWindObservation struct {
 	Timestamp     time.Time
 	WindSpeed     float64
@@ -352,7 +760,18 @@ type FMIWindObservation struct {
 
 // updatePollingState updates polling state based on observation results
 func (m *manager) updatePollingState(state *PollingState, observations []FMIWindObservation) (FMIWindObservation, bool) {
-	state.LastPolled = time.Now()
+	now := m.clock.Now()
+
+	// Quick retries of a late publication do not count toward backing off
+	lateRetry := state.Lag != nil && state.Lag.Late && inPublicationWindow(state, now)
+
+	var newest time.Time
+	if len(observations) > 0 {
+		newest = observations[len(observations)-1].Timestamp
+	}
+	recordPublicationLag(state, len(observations) > 0, newest, now)
+
+	state.LastPolled = now
 	state.TotalPolls++
 
 	var lastObservation FMIWindObservation
@@ -361,6 +780,7 @@ func (m *manager) updatePollingState(state *PollingState, observations []FMIWind
 	if len(observations) > 0 {
 		hadData = true
 		lastObservation = observations[len(observations)-1]
+		learnCadence(state, state.LastObservation, observations)
 
 		state.SuccessfulPolls++
 		state.ConsecutiveMisses = 0
@@ -374,7 +794,9 @@ func (m *manager) updatePollingState(state *PollingState, observations []FMIWind
 			}
 		}
 	} else {
-		state.ConsecutiveMisses++
+		if !lateRetry {
+			state.ConsecutiveMisses++
+		}
 		state.SuccessRate = float64(state.SuccessfulPolls) / float64(state.TotalPolls)
 
 		if state.ConsecutiveMisses >= 2 {
@@ -390,7 +812,7 @@ func (m *manager) updatePollingState(state *PollingState, observations []FMIWind
 func (m *manager) updateFailedPollingState(state *PollingState) {
 	state.TotalPolls++
 	state.ConsecutiveMisses++
-	state.LastPolled = time.Now()
+	state.LastPolled = m.clock.Now()
 
 	if state.ConsecutiveMisses >= 3 {
 		state.CurrentInterval = getNextSlowerInterval(state.CurrentInterval)
@@ -412,13 +834,20 @@ func (m *manager) updateWindData(stationID string, obs FMIWindObservation) {
 		WindSpeed:     obs.WindSpeed,
 		WindGust:      obs.WindGust,
 		WindDirection: obs.WindDirection,
-		UpdatedAt:     time.Now(),
+		MaxGust60m:    m.rollingMaxGust(stationID, obs.Timestamp, obs.WindGust),
+		Trend:         m.stationTrend(stationID, obs.Timestamp),
+		Quality:       m.sampleQuality(stationID, obs.Timestamp),
+		UpdatedAt:     m.clock.Now(),
 	}
 
 	m.windDataMutex.Lock()
+	previousTrend := m.windData[stationID].Trend
 	m.windData[stationID] = windObs
 	m.windDataMutex.Unlock()
 
+	// Only the copies sent out carry the freshness, which changes with time
+	windObs.Freshness, _ = m.stationFreshness(stationID, m.clock.Now())
+
 	// Broadcast to SSE clients
 	m.sseMgr.Broadcast(sse.Message{
 		ID:        windObs.Timestamp.Unix(),
@@ -426,6 +855,57 @@ func (m *manager) updateWindData(stationID string, obs FMIWindObservation) {
 		StationID: stationID,
 		Data:      windObs,
 	})
+
+	m.broadcastTrendChange(windObs, previousTrend)
+
+	m.listenersMu.RLock()
+	listeners := m.listeners
+	m.listenersMu.RUnlock()
+	for _, listener := range listeners {
+		listener(windObs)
+	}
+
+	m.updateFreshness(stationID)
+}
+
+// UpstreamStatus returns the state of the FMI circuit breaker
+func (m *manager) UpstreamStatus() UpstreamStatus {
+	return m.sourceStatus(SourceFMI)
+}
+
+// sourceStatus returns the state of a source's circuit breaker
+func (m *manager) sourceStatus(source string) UpstreamStatus {
+	if up, exists := m.upstreams[source]; exists {
+		return up.breaker.status()
+	}
+	return UpstreamStatus{State: BreakerClosed}
+}
+
+// broadcastUpstream announces circuit breaker state changes. Only FMI's are sent to
+// SSE clients, as the upstream event reports FMI availability.
+func (m *manager) broadcastUpstream(source string, status UpstreamStatus) {
+	switch status.State {
+	case BreakerOpen:
+		log.Printf("Source %s circuit breaker open after %d failures, probing at %s: %s",
+			source, status.ConsecutiveFailures, status.RetryAt.Format(time.RFC3339), status.LastError)
+	default:
+		log.Printf("Source %s circuit breaker %s", source, status.State)
+	}
+
+	// Stations held back during the outage are due again
+	if status.State == BreakerClosed {
+		m.Wake()
+	}
+
+	if source != SourceFMI {
+		return
+	}
+
+	m.sseMgr.Broadcast(sse.Message{
+		ID:   status.Since.Unix(),
+		Type: "upstream",
+		Data: status,
+	})
 }
 
 // broadcastStatusUpdate broadcasts polling status changes
@@ -434,6 +914,7 @@ func (m *manager) broadcastStatusUpdate(state *PollingState) {
 		"interval":     formatInterval(state.CurrentInterval),
 		"success_rate": state.SuccessRate,
 		"last_polled":  state.LastPolled,
+		"freshness":    m.GetFreshness(state.StationID),
 	}
 
 	m.sseMgr.Broadcast(sse.Message{
@@ -497,9 +978,11 @@ func getNextSlowerInterval(current time.Duration) time.Duration {
 	}
 }
 
-func getEffectivePollingInterval(baseInterval time.Duration, hasSSEClients bool) time.Duration {
-	if !hasSSEClients {
-		return IntervalSlow // Save resources when no clients
+// getEffectivePollingInterval returns the adaptive interval of a station someone is
+// watching, and the background interval, or the slower adaptive one, otherwise
+func getEffectivePollingInterval(baseInterval time.Duration, subscribed bool, background time.Duration) time.Duration {
+	if !subscribed {
+		return max(baseInterval, background) // Save FMI requests for stations nobody watches
 	}
 	return baseInterval
 }
@@ -521,29 +1004,47 @@ func formatInterval(d time.Duration) string {
 
 // State persistence methods
 
-func (m *manager) loadPollingStates() {
-	data, err := os.ReadFile(m.stateFile)
+// loadPollingStates restores the polling states. It only returns an error for a file
+// refused with checkpoint.ErrUnsupportedVersion; other failures start with fresh state.
+func (m *manager) loadPollingStates() error {
+	var states map[string]*PollingState
+	var version int
+	loaded, err := checkpoint.Load(m.stateFile, func(data []byte) (err error) {
+		states, version, err = decodePollingStates(data)
+		return err
+	})
+	if errors.Is(err, checkpoint.ErrUnsupportedVersion) {
+		return err
+	}
 	if err != nil {
-		if !os.IsNotExist(err) {
+		if !errors.Is(err, fs.ErrNotExist) {
 			log.Printf("Error loading polling states: %v", err)
 		}
-		return
+		return nil
+	}
+	if loaded != m.stateFile {
+		log.Printf("Polling state file unreadable, recovered from %s", loaded)
+	}
+	if version < pollingStateSchema.Version {
+		log.Printf("Migrated polling states from format version %d to %d", version, pollingStateSchema.Version)
 	}
 
 	m.pollingStatesMutex.Lock()
 	defer m.pollingStatesMutex.Unlock()
 
-	if err := json.Unmarshal(data, &m.pollingStates); err != nil {
-		log.Printf("Error parsing polling states: %v", err)
-		return
+	for stationID, state := range states {
+		if state != nil {
+			m.pollingStates[stationID] = state
+		}
 	}
 
 	log.Printf("Loaded polling states for %d stations", len(m.pollingStates))
+	return nil
 }
 
 func (m *manager) savePollingStates() {
 	m.pollingStatesMutex.RLock()
-	data, err := json.MarshalIndent(m.pollingStates, "", "  ")
+	data, err := encodePollingStates(m.pollingStates)
 	m.pollingStatesMutex.RUnlock()
 
 	if err != nil {
@@ -551,34 +1052,144 @@ func (m *manager) savePollingStates() {
 		return
 	}
 
-	if err := os.WriteFile(m.stateFile, data, 0644); err != nil {
+	if err := checkpoint.Write(m.stateFile, data); err != nil {
 		log.Printf("Error saving polling states: %v", err)
 	}
 }
 
-func (m *manager) loadWindData() {
-	data, err := os.ReadFile(m.windDataFile)
+// loadStoredHistory seeds the in-memory history and latest observations from the store
+func (m *manager) loadStoredHistory() {
+	if m.store == nil {
+		return
+	}
+
+	to := m.clock.Now()
+	from := to.Add(-m.historyRetention)
+	loaded := 0
+
+	for _, stationID := range m.store.Stations() {
+		records, err := m.store.Range(stationID, from, to)
+		if err != nil {
+			log.Printf("Error loading stored history for station %s: %v", stationID, err)
+			continue
+		}
+		if len(records) == 0 {
+			continue
+		}
+		observations := m.recordsToObservations(stationID, records)
+
+		m.historyMutex.Lock()
+		h, exists := m.history[stationID]
+		if !exists {
+			h = newStationHistory(m.historyRetention)
+			m.history[stationID] = h
+		}
+		for _, obs := range observations {
+			h.add(obs)
+		}
+		m.historyMutex.Unlock()
+
+		// The store may be newer than the wind data file after a crash
+		latest := observations[len(observations)-1]
+		latest.MaxGust60m = maxGustBetween(observations, latest.Timestamp.Add(-RollingGustWindow), latest.Timestamp)
+		m.windDataMutex.Lock()
+		if current, exists := m.windData[stationID]; !exists || latest.Timestamp.After(current.Timestamp) {
+			m.windData[stationID] = latest
+		}
+		m.windDataMutex.Unlock()
+
+		loaded += len(records)
+	}
+
+	stats := m.store.Stats()
+	log.Printf("Loaded %d stored observations (%d records in %d segments, %d damaged regions skipped)",
+		loaded, stats.Records, stats.Segments, stats.CorruptSkipped)
+}
+
+// runStoreCompaction periodically drops expired and superseded records from the store
+func (m *manager) runStoreCompaction() {
+	ticker := m.clock.NewTicker(storeCompactionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			var cutoff time.Time
+			if m.storeRetention > 0 {
+				cutoff = m.clock.Now().Add(-m.storeRetention)
+			}
+			if err := m.store.Compact(cutoff); err != nil {
+				log.Printf("Error compacting observation store: %v", err)
+			}
+		case <-m.ctx.Done():
+			return
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// runCheckpoints periodically saves state so a crash loses at most one interval
+func (m *manager) runCheckpoints() {
+	defer close(m.checkpointDone)
+
+	ticker := m.clock.NewTicker(m.checkpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			m.saveState()
+		case <-m.ctx.Done():
+			return
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// saveState checkpoints the polling states and latest observations
+func (m *manager) saveState() {
+	m.checkpointMu.Lock()
+	defer m.checkpointMu.Unlock()
+
+	m.savePollingStates()
+	m.saveWindData()
+}
+
+// loadWindData restores the latest observations, returning an error only for a file
+// refused with checkpoint.ErrUnsupportedVersion
+func (m *manager) loadWindData() error {
+	var windData map[string]WindObservation
+	loaded, err := checkpoint.Load(m.windDataFile, func(data []byte) error {
+		_, err := windDataSchema.Unmarshal(data, &windData)
+		return err
+	})
+	if errors.Is(err, checkpoint.ErrUnsupportedVersion) {
+		return err
+	}
 	if err != nil {
-		if !os.IsNotExist(err) {
+		if !errors.Is(err, fs.ErrNotExist) {
 			log.Printf("Error loading wind data: %v", err)
 		}
-		return
+		return nil
+	}
+	if loaded != m.windDataFile {
+		log.Printf("Wind data file unreadable, recovered from %s", loaded)
 	}
 
 	m.windDataMutex.Lock()
 	defer m.windDataMutex.Unlock()
 
-	if err := json.Unmarshal(data, &m.windData); err != nil {
-		log.Printf("Error parsing wind data: %v", err)
-		return
-	}
+	maps.Copy(m.windData, windData)
 
 	log.Printf("Loaded wind data for %d stations", len(m.windData))
+	return nil
 }
 
 func (m *manager) saveWindData() {
 	m.windDataMutex.RLock()
-	data, err := json.MarshalIndent(m.windData, "", "  ")
+	data, err := windDataSchema.Marshal(m.windData)
 	m.windDataMutex.RUnlock()
 
 	if err != nil {
@@ -586,7 +1197,7 @@ func (m *manager) saveWindData() {
 		return
 	}
 
-	if err := os.WriteFile(m.windDataFile, data, 0644); err != nil {
+	if err := checkpoint.Write(m.windDataFile, data); err != nil {
 		log.Printf("Error saving wind data: %v", err)
 	}
 }
@@ -2,17 +2,37 @@ package observations
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+	"windz/internal/checkpoint"
+	"windz/internal/clock"
 	"windz/internal/sse"
 	"windz/internal/stations"
+	"windz/internal/store"
+	"windz/internal/units"
 )
 
 // mockSSEManager implements a mock SSE manager for testing
 type mockSSEManager struct {
-	clients   int
-	messages  []sse.Message
-	hasClient bool
+	mu         sync.Mutex
+	clients    int
+	messages   []sse.Message
+	hasClient  bool
+	subscribed map[string]int // Subscribers per station; nil means every client follows every station
 }
 
 func (m *mockSSEManager) AddClient(clientID string) <-chan sse.Message {
@@ -25,11 +45,23 @@ func (m *mockSSEManager) HasClients() bool {
 	return m.hasClient
 }
 
+func (m *mockSSEManager) Subscribers(stationID string) int {
+	if m.subscribed != nil {
+		return m.subscribed[stationID]
+	}
+	if m.hasClient {
+		return 1
+	}
+	return 0
+}
+
 func (m *mockSSEManager) ClientCount() int {
 	return m.clients
 }
 
 func (m *mockSSEManager) Broadcast(message sse.Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.messages = append(m.messages, message)
 }
 
@@ -46,6 +78,18 @@ func (m *mockSSEManager) SendToClient(clientID string, message sse.Message) {
 	m.messages = append(m.messages, message)
 }
 
+func (m *mockSSEManager) AddFilteredClient(clientID string, stationIDs []string) <-chan sse.Message {
+	return make(<-chan sse.Message)
+}
+
+func (m *mockSSEManager) SetGroupResolver(resolver func(groupID string) ([]string, bool)) {}
+
+func (m *mockSSEManager) ResolveGroup(groupID string) ([]string, bool) {
+	return nil, false
+}
+
+func (m *mockSSEManager) AddListener(listener func(sse.Message)) {}
+
 func TestNewManager(t *testing.T) {
 	stationMgr := stations.NewManager()
 	sseMgr := &mockSSEManager{}
@@ -191,11 +235,14 @@ func TestUtilityFunctions(t *testing.T) {
 	}
 
 	// Test effective polling interval
-	if getEffectivePollingInterval(IntervalFast, false) != IntervalSlow {
-		t.Error("Expected fallback to slow interval when no SSE clients")
+	if getEffectivePollingInterval(IntervalFast, false, IntervalSlow) != IntervalSlow {
+		t.Error("Expected fallback to background interval without subscribers")
+	}
+	if getEffectivePollingInterval(IntervalFast, true, IntervalSlow) != IntervalFast {
+		t.Error("Expected to maintain interval with subscribers")
 	}
-	if getEffectivePollingInterval(IntervalFast, true) != IntervalFast {
-		t.Error("Expected to maintain interval when SSE clients present")
+	if getEffectivePollingInterval(IntervalUltraSlow, false, IntervalSlow) != IntervalUltraSlow {
+		t.Error("Expected background polling never to be faster than the adaptive interval")
 	}
 }
 
@@ -228,3 +275,1658 @@ func TestAnalyzeObservationIntervals(t *testing.T) {
 		t.Errorf("Expected 10 minute interval, got %v", interval)
 	}
 }
+
+func TestGeoJSONHandlers(t *testing.T) {
+	stationMgr := stations.NewManager()
+	mgr := NewManager(stationMgr, &mockSSEManager{}, "test_state.json", "test_wind.json", false).(*manager)
+	mgr.windData["100996"] = WindObservation{StationID: "100996", WindSpeed: 7.5, WindGust: 9.1, WindDirection: 220}
+
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, mgr, stationMgr, "")
+
+	decode := func(url string) GeoJSONFeatureCollection {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status %d", url, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/geo+json" {
+			t.Errorf("%s: unexpected content type %q", url, ct)
+		}
+		var fc GeoJSONFeatureCollection
+		if err := json.NewDecoder(rec.Body).Decode(&fc); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", url, err)
+		}
+		return fc
+	}
+
+	all := decode("/api/stations.geojson")
+	if all.Type != "FeatureCollection" || len(all.Features) != 16 {
+		t.Errorf("Expected 16 station features, got %d", len(all.Features))
+	}
+
+	latest := decode("/api/observations/latest.geojson")
+	if len(latest.Features) != 1 {
+		t.Fatalf("Expected 1 observation feature, got %d", len(latest.Features))
+	}
+	feature := latest.Features[0]
+	if feature.Geometry.Coordinates != [2]float64{24.9758, 60.1042} {
+		t.Errorf("Expected [lon, lat] coordinates, got %v", feature.Geometry.Coordinates)
+	}
+	if feature.Properties["wind_speed"] != 7.5 {
+		t.Errorf("Expected wind_speed property 7.5, got %v", feature.Properties["wind_speed"])
+	}
+
+	if fc := decode("/api/stations.geojson?region=Helsinki%20Maritime"); len(fc.Features) != 1 {
+		t.Errorf("Expected 1 feature for region filter, got %d", len(fc.Features))
+	}
+	if fc := decode("/api/stations.geojson?bbox=24.0,59.8,25.0,60.2"); len(fc.Features) != 3 {
+		t.Errorf("Expected 3 features inside bbox, got %d", len(fc.Features))
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/stations.geojson?bbox=1,2,3", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid bbox, got %d", rec.Code)
+	}
+}
+
+func TestUnitsHandlers(t *testing.T) {
+	stationMgr := stations.NewManager()
+	mgr := NewManager(stationMgr, &mockSSEManager{}, "test_state.json", "test_wind.json", false).(*manager)
+	mgr.windData["100996"] = WindObservation{StationID: "100996", WindSpeed: 7.5, WindGust: 9.1, WindDirection: 220,
+		Trend: &Trend{SpeedRate: 2, Speed: TrendBuilding, Direction: TrendSteady}}
+
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, mgr, stationMgr, "")
+
+	get := func(url string) (int, WindObservation) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		var obs WindObservation
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&obs); err != nil {
+				t.Fatalf("%s: invalid JSON: %v", url, err)
+			}
+		}
+		return rec.Code, obs
+	}
+
+	_, obs := get("/api/observations/100996")
+	if obs.WindSpeed != 7.5 || obs.Units != "" || obs.Compass != "" {
+		t.Errorf("Expected m/s without conversion fields by default, got %+v", obs)
+	}
+
+	_, obs = get("/api/observations/100996?units=kn&lang=fi")
+	if math.Abs(obs.WindSpeed-14.579) > 0.001 || math.Abs(obs.Trend.SpeedRate-3.888) > 0.001 || obs.Units != units.Knots || obs.Compass != "LO" {
+		t.Errorf("Expected knots and a Finnish compass point, got %+v %+v", obs, obs.Trend)
+	}
+
+	_, obs = get("/api/observations/100996?units=bft")
+	if obs.WindSpeed != 4 || obs.WindGust != 5 || obs.Compass != "SW" {
+		t.Errorf("Expected Beaufort forces, got %+v", obs)
+	}
+
+	if code, _ := get("/api/observations/100996?units=knots"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid units, got %d", code)
+	}
+
+	// Conversion works on copies
+	if stored := mgr.windData["100996"]; stored.WindSpeed != 7.5 || stored.Trend.SpeedRate != 2 {
+		t.Errorf("Stored observation was modified: %+v", stored)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/observations/latest.geojson?units=kmh&lang=sv", nil))
+	var fc GeoJSONFeatureCollection
+	if err := json.NewDecoder(rec.Body).Decode(&fc); err != nil || len(fc.Features) != 1 {
+		t.Fatalf("Unexpected GeoJSON response: %v", err)
+	}
+	if properties := fc.Features[0].Properties; properties["wind_speed"] != 27.0 || properties["units"] != "kmh" || properties["compass"] != "SV" {
+		t.Errorf("Expected converted GeoJSON properties, got %v", properties)
+	}
+}
+
+func TestStationHistory(t *testing.T) {
+	base := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	h := newStationHistory(time.Hour)
+
+	// In-order, duplicate and out-of-order inserts keep time order without duplicates
+	for _, minute := range []int{0, 10, 30, 20, 30} {
+		h.add(WindObservation{Timestamp: base.Add(time.Duration(minute) * time.Minute), WindSpeed: float64(minute)})
+	}
+	all := h.rangeQuery(base, base.Add(time.Hour))
+	if len(all) != 4 {
+		t.Fatalf("Expected 4 samples, got %d", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if !all[i].Timestamp.After(all[i-1].Timestamp) {
+			t.Error("History should be in strictly increasing time order")
+		}
+	}
+
+	// Range bounds are inclusive
+	if got := h.rangeQuery(base.Add(10*time.Minute), base.Add(20*time.Minute)); len(got) != 2 {
+		t.Errorf("Expected 2 samples in range, got %d", len(got))
+	}
+
+	// Flag counts follow replaced and re-flagged samples
+	h.add(WindObservation{Timestamp: base.Add(10 * time.Minute), Quality: FlagSpike})
+	h.setQuality(0, FlagStuck+","+FlagSpike)
+	if h.flags[FlagSpike] != 2 || h.flags[FlagStuck] != 1 {
+		t.Errorf("Unexpected flag counts %v", h.flags)
+	}
+
+	// Samples outside the retention window are evicted
+	h.add(WindObservation{Timestamp: base.Add(75 * time.Minute)})
+	if got := h.rangeQuery(base, base.Add(2*time.Hour)); len(got) != 3 || got[0].Timestamp != base.Add(20*time.Minute) {
+		t.Errorf("Expected samples before 12:15 to be expired, got %d samples", len(got))
+	}
+	if len(h.flags) != 0 {
+		t.Errorf("Expected flags of expired samples to be dropped, got %v", h.flags)
+	}
+
+	// Stations reporting every few seconds keep the whole retention window
+	fast := newStationHistory(time.Hour)
+	for i := range 720 {
+		fast.add(WindObservation{Timestamp: base.Add(time.Duration(i) * 5 * time.Second), WindSpeed: float64(i)})
+	}
+	if got := fast.rangeQuery(base, base.Add(time.Hour)); len(got) != 720 || got[0].WindSpeed != 0 || got[719].WindSpeed != 719 {
+		t.Errorf("Expected an hour of 5 second samples, got %d", len(got))
+	}
+	for i := range 3600 {
+		fast.add(WindObservation{Timestamp: base.Add(time.Hour + time.Duration(i)*time.Second/2)})
+	}
+	if len(fast.buf) != 3600 || fast.size != 3600 {
+		t.Errorf("Expected the buffer capped at one sample per second, got %d of %d", fast.size, len(fast.buf))
+	}
+
+	// A full buffer keeps the newest samples
+	small := &stationHistory{buf: make([]WindObservation, 3), retention: 24 * time.Hour}
+	for i := 0; i < 5; i++ {
+		small.add(WindObservation{Timestamp: base.Add(time.Duration(i) * time.Minute)})
+	}
+	got := small.rangeQuery(base, base.Add(time.Hour))
+	if len(got) != 3 || got[0].Timestamp != base.Add(2*time.Minute) {
+		t.Errorf("Expected newest 3 samples in full buffer, got %+v", got)
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	stationMgr := stations.NewManager()
+	mgr := NewManager(stationMgr, &mockSSEManager{}, "test_state.json", "test_wind.json", false).(*manager)
+
+	now := time.Now().Truncate(time.Minute)
+	mgr.recordHistory("100996", []FMIWindObservation{
+		{Timestamp: now.Add(-20 * time.Minute), WindSpeed: 5},
+		{Timestamp: now.Add(-10 * time.Minute), WindSpeed: 6},
+		{Timestamp: now, WindSpeed: 7},
+	})
+
+	history := mgr.GetHistory("100996", now.Add(-15*time.Minute), now)
+	if len(history) != 2 || history[0].WindSpeed != 6 || history[0].StationName != "Harmaja" {
+		t.Errorf("Unexpected history: %+v", history)
+	}
+
+	if len(mgr.GetHistory("101023", now.Add(-time.Hour), now)) != 0 {
+		t.Error("Expected empty history for station without samples")
+	}
+
+	// HTTP endpoint
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, mgr, stationMgr, "")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/observations/100996/history", nil))
+	var result []WindObservation
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil || len(result) != 3 {
+		t.Errorf("Expected 3 samples from history endpoint, got %d (%v)", len(result), err)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/observations/100996/history?from=yesterday", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid from, got %d", rec.Code)
+	}
+}
+
+func TestStoredHistory(t *testing.T) {
+	stationMgr := stations.NewManager()
+	st, err := store.Open(t.TempDir(), store.Options{})
+	if err != nil {
+		t.Fatalf("store.Open() error = %v", err)
+	}
+	defer st.Close()
+
+	cfg := Config{StateFile: "test_state.json", WindDataFile: "test_wind.json", HistoryRetention: time.Hour, Store: st}
+	mgr := NewManagerWithConfig(stationMgr, &mockSSEManager{}, cfg).(*manager)
+
+	now := time.Now().Truncate(time.Minute)
+	samples := []FMIWindObservation{
+		{Timestamp: now.Add(-3 * time.Hour), WindSpeed: 4},
+		{Timestamp: now.Add(-10 * time.Minute), WindSpeed: 6},
+		{Timestamp: now, WindSpeed: 7},
+	}
+	mgr.recordHistory("100996", samples)
+	mgr.recordHistory("100996", samples) // Re-fetching the same samples must not duplicate them
+
+	if stats := st.Stats(); stats.Records != 3 {
+		t.Errorf("Expected 3 stored records, got %d", stats.Records)
+	}
+
+	// Ranges older than the in-memory window come from the store
+	history := mgr.GetHistory("100996", now.Add(-4*time.Hour), now)
+	if len(history) != 3 || history[0].WindSpeed != 4 || history[0].StationName != "Harmaja" {
+		t.Errorf("Unexpected stored history: %+v", history)
+	}
+
+	// A fresh manager seeds history and latest data from the store
+	restarted := NewManagerWithConfig(stationMgr, &mockSSEManager{}, cfg).(*manager)
+	restarted.loadStoredHistory()
+
+	if got := restarted.GetHistory("100996", now.Add(-30*time.Minute), now); len(got) != 2 {
+		t.Errorf("Expected 2 seeded samples, got %d", len(got))
+	}
+	latest, exists := restarted.GetLatestObservation("100996")
+	if !exists || latest.WindSpeed != 7 {
+		t.Errorf("Expected latest observation from store, got %+v", latest)
+	}
+}
+
+func TestFindGaps(t *testing.T) {
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(2 * time.Hour)
+
+	// Ten-minute cadence with samples 00:40-01:00 missing and nothing after 01:30
+	var timestamps []time.Time
+	for ts := from; ts.Before(from.Add(90 * time.Minute)); ts = ts.Add(10 * time.Minute) {
+		if ts.Hour() == 0 && ts.Minute() >= 40 || ts.Equal(from.Add(time.Hour)) {
+			continue
+		}
+		timestamps = append(timestamps, ts)
+	}
+
+	if cadence := expectedCadence(timestamps, 0); cadence != IntervalMedium {
+		t.Errorf("expectedCadence() = %v, expected %v", cadence, IntervalMedium)
+	}
+	if cadence := expectedCadence(timestamps[:1], IntervalFast); cadence != IntervalFast {
+		t.Errorf("expectedCadence() = %v, expected the source hint %v", cadence, IntervalFast)
+	}
+
+	gaps := findGaps(timestamps, from, to, IntervalMedium)
+	if len(gaps) != 2 {
+		t.Fatalf("Expected 2 gaps, got %+v", gaps)
+	}
+	if !gaps[0].start.Equal(from.Add(30*time.Minute+time.Second)) || !gaps[0].end.Equal(from.Add(70*time.Minute)) {
+		t.Errorf("Unexpected first gap: %+v", gaps[0])
+	}
+	if !gaps[1].end.Equal(to) {
+		t.Errorf("Unexpected trailing gap: %+v", gaps[1])
+	}
+
+	if gaps := findGaps(nil, from, to, IntervalMedium); len(gaps) != 1 || !gaps[0].start.Equal(from) {
+		t.Errorf("Expected whole window as gap without samples, got %+v", gaps)
+	}
+}
+
+func TestBackfillPass(t *testing.T) {
+	stationMgr := stations.NewManager()
+	mgr := NewManagerWithConfig(stationMgr, &mockSSEManager{}, Config{
+		StateFile:    "test_state.json",
+		WindDataFile: "test_wind.json",
+	}).(*manager)
+
+	now := time.Now().Truncate(time.Minute)
+	requests := 0
+	fetch := func(source string, stationIDs []string, start, end time.Time) (map[string][]FMIWindObservation, error) {
+		requests++
+		if source != SourceFMI {
+			t.Errorf("Unexpected source %q", source)
+		}
+		if len(stationIDs) > fmiMaxBatch {
+			t.Errorf("Batch of %d stations exceeds limit", len(stationIDs))
+		}
+		if end.Sub(start) > backfillChunk {
+			t.Errorf("Chunk %v exceeds %v", end.Sub(start), backfillChunk)
+		}
+		results := make(map[string][]FMIWindObservation)
+		for _, id := range stationIDs {
+			// Harmaja has nothing upstream; everyone else reports every 10 minutes
+			if id == "100996" {
+				continue
+			}
+			for ts := start.Truncate(IntervalMedium).Add(IntervalMedium); ts.Before(end); ts = ts.Add(IntervalMedium) {
+				results[id] = append(results[id], FMIWindObservation{Timestamp: ts, WindSpeed: 5})
+			}
+		}
+		return results, nil
+	}
+
+	b := newBackfiller(mgr, fetch, 12*time.Hour, 60000)
+	first := b.pass(context.Background(), now)
+	if first == 0 {
+		t.Fatal("Expected backfill requests for empty history")
+	}
+
+	history := mgr.GetHistory("101023", now.Add(-12*time.Hour), now.Add(-backfillDelay))
+	if len(history) < 60 {
+		t.Errorf("Expected backfilled history, got %d samples", len(history))
+	}
+
+	// Filled stations need nothing more; the empty station's old chunks are not retried
+	requests = 0
+	b.pass(context.Background(), now.Add(time.Minute))
+	if requests >= first {
+		t.Errorf("Expected fewer requests on second pass, got %d (first %d)", requests, first)
+	}
+}
+
+func TestCircularStats(t *testing.T) {
+	// Directions around north must not average to south
+	mean, std, ok := circularStats([]float64{350, 10})
+	if !ok || math.Abs(mean) > 1e-9 && math.Abs(mean-360) > 1e-9 {
+		t.Errorf("circularStats(350, 10) mean = %v, expected 0", mean)
+	}
+	if std < 9 || std > 11 {
+		t.Errorf("circularStats(350, 10) std = %v, expected about 10", std)
+	}
+
+	if mean, std, _ := circularStats([]float64{270, 270, 270}); math.Abs(mean-270) > 1e-9 || std != 0 {
+		t.Errorf("Identical directions gave mean %v std %v", mean, std)
+	}
+
+	if _, _, ok := circularStats(nil); ok {
+		t.Error("Expected no direction for empty input")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	stationMgr := stations.NewManager()
+	mgr := NewManager(stationMgr, &mockSSEManager{}, "test_state.json", "test_wind.json", false).(*manager)
+
+	base := time.Now().Truncate(time.Hour).Add(-2 * time.Hour)
+	var samples []FMIWindObservation
+	for i := 0; i < 12; i++ {
+		// Speed alternates 4 and 6 m/s, direction 350° and 10°
+		speed, direction := 4.0, 350.0
+		if i%2 == 1 {
+			speed, direction = 6, 10
+		}
+		samples = append(samples, FMIWindObservation{
+			Timestamp:     base.Add(time.Duration(i) * 10 * time.Minute),
+			WindSpeed:     speed,
+			WindGust:      float64(8 + i),
+			WindDirection: direction,
+		})
+	}
+	mgr.recordHistory("100996", samples)
+
+	aggregates := aggregateObservations(mgr.GetHistory("100996", base.Add(-time.Hour), base.Add(3*time.Hour)), base, time.Hour)
+	if len(aggregates) != 2 {
+		t.Fatalf("Expected 2 hourly buckets, got %d", len(aggregates))
+	}
+
+	first := aggregates[0]
+	if first.Count != 6 || first.MeanSpeed != 5 || first.MinSpeed != 4 || first.MaxGust != 13 {
+		t.Errorf("Unexpected first bucket: %+v", first)
+	}
+	if first.MeanDirection == nil || math.Abs(*first.MeanDirection) > 1e-6 && math.Abs(*first.MeanDirection-360) > 1e-6 {
+		t.Errorf("Expected circular mean near north, got %v", first.MeanDirection)
+	}
+	if aggregates[1].MaxGust60m != 19 {
+		t.Errorf("Expected rolling max gust 19, got %v", aggregates[1].MaxGust60m)
+	}
+
+	// Latest observation carries the rolling maximum
+	mgr.updateWindData("100996", FMIWindObservation{Timestamp: samples[11].Timestamp, WindSpeed: 6, WindGust: 5})
+	if obs, _ := mgr.GetLatestObservation("100996"); obs.MaxGust60m != 19 {
+		t.Errorf("Expected latest MaxGust60m 19, got %v", obs.MaxGust60m)
+	}
+
+	// HTTP endpoint
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, mgr, stationMgr, "")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/observations/100996/aggregate?period=1h", nil))
+	var result []Aggregate
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil || len(result) != 2 {
+		t.Errorf("Expected 2 buckets from aggregate endpoint, got %d (%v)", len(result), err)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/observations/100996/aggregate?period=5m", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid period, got %d", rec.Code)
+	}
+}
+
+func TestWindRose(t *testing.T) {
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	observations := []WindObservation{
+		{Timestamp: base, WindSpeed: 0.2, WindDirection: 90},                      // Calm
+		{Timestamp: base.Add(time.Minute), WindSpeed: 3, WindDirection: 355},      // N, 2-4
+		{Timestamp: base.Add(2 * time.Minute), WindSpeed: 7, WindDirection: 10},   // N, 6-8
+		{Timestamp: base.Add(3 * time.Minute), WindSpeed: 15, WindDirection: 225}, // SW, 12+
+	}
+
+	rose := computeWindRose(observations, 16, DefaultWindRoseBins)
+	if rose.Total != 4 || rose.Calm != 1 || rose.CalmPercent != 25 {
+		t.Errorf("Unexpected totals: total %d calm %d (%.1f%%)", rose.Total, rose.Calm, rose.CalmPercent)
+	}
+	if len(rose.Bins) != len(DefaultWindRoseBins)+1 || rose.Bins[len(rose.Bins)-1].Max != nil {
+		t.Errorf("Expected open-ended top bin, got %+v", rose.Bins)
+	}
+	if rose.Counts[0][1] != 1 || rose.Counts[0][3] != 1 || rose.Counts[10][6] != 1 {
+		t.Errorf("Unexpected counts: %v", rose.Counts)
+	}
+	if rose.Frequencies[10][6] != 25 {
+		t.Errorf("Expected SW 12+ frequency 25%%, got %v", rose.Frequencies[10][6])
+	}
+
+	// HTTP endpoints
+	stationMgr := stations.NewManager()
+	mgr := NewManager(stationMgr, &mockSSEManager{}, "test_state.json", "test_wind.json", false).(*manager)
+	mgr.recordHistory("100996", []FMIWindObservation{{Timestamp: time.Now().Add(-time.Hour), WindSpeed: 5, WindDirection: 180}})
+
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, mgr, stationMgr, "")
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/observations/100996/windrose?sectors=8&bins=3,6", nil))
+	var result WindRose
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil || result.Total != 1 || result.Counts[4][1] != 1 {
+		t.Errorf("Unexpected wind rose response: %+v (%v)", result, err)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/observations/100996/windrose.svg", nil))
+	if rec.Header().Get("Content-Type") != "image/svg+xml" || !strings.HasPrefix(rec.Body.String(), "<svg") {
+		t.Errorf("Expected SVG response, got %q", rec.Header().Get("Content-Type"))
+	}
+
+	// Bin edges are read and reported in the requested unit
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/observations/100996/windrose?units=kn&bins=8,12", nil))
+	result = WindRose{}
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil || result.Units != units.Knots || result.Counts[8][1] != 1 || *result.Bins[1].Max != 12 {
+		t.Errorf("Unexpected wind rose in knots: %+v (%v)", result, err)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/observations/100996/windrose?units=bft", nil))
+	result = WindRose{}
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil || result.Counts[8][2] != 1 || result.Bins[2].Min != 3 {
+		t.Errorf("Expected force 3 in the default Beaufort bins: %+v (%v)", result, err)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/observations/100996/windrose.svg?units=kmh&lang=fi", nil))
+	if svg := rec.Body.String(); !strings.Contains(svg, "5–10 km/h") || !strings.Contains(svg, ">E</text>") || !strings.Contains(svg, ">P</text>") {
+		t.Errorf("Expected km/h legend and Finnish compass points in the SVG")
+	}
+
+	for _, query := range []string{"sectors=7", "bins=5,3", "bins=abc", "units=bft&bins=2.5", "units=fast"} {
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/observations/100996/windrose?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestComputeTrend(t *testing.T) {
+	cfg := trendConfig{window: 30 * time.Minute, speedThreshold: 2, directionThreshold: 30}
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// Building 6 m/s per hour while veering through north at 60°/h
+	var observations []WindObservation
+	for i := 0; i <= 30; i++ {
+		observations = append(observations, WindObservation{
+			Timestamp:     base.Add(time.Duration(i) * time.Minute),
+			WindSpeed:     5 + 0.1*float64(i),
+			WindGust:      8 + 0.1*float64(i),
+			WindDirection: math.Mod(350+float64(i), 360),
+		})
+	}
+
+	trend := computeTrend(observations, cfg)
+	if trend == nil {
+		t.Fatal("Expected a trend")
+	}
+	if math.Abs(trend.SpeedRate-6) > 1e-6 || math.Abs(trend.GustRate-6) > 1e-6 || trend.Speed != TrendBuilding {
+		t.Errorf("Unexpected speed trend: %+v", trend)
+	}
+	if math.Abs(trend.DirectionRate-60) > 1e-6 || trend.Direction != TrendVeering {
+		t.Errorf("Unexpected direction trend: %+v", trend)
+	}
+
+	// Reversed samples: dropping and backing
+	reversed := make([]WindObservation, len(observations))
+	for i, obs := range observations {
+		obs.Timestamp = observations[len(observations)-1-i].Timestamp
+		reversed[len(observations)-1-i] = obs
+	}
+	if trend := computeTrend(reversed, cfg); trend.Speed != TrendDropping || trend.Direction != TrendBacking {
+		t.Errorf("Expected dropping and backing, got %+v", trend)
+	}
+
+	if computeTrend(observations[:2], cfg) != nil {
+		t.Error("Expected no trend from two samples")
+	}
+}
+
+func TestTrendEvent(t *testing.T) {
+	sseMgr := &mockSSEManager{}
+	mgr := NewManager(stations.NewManager(), sseMgr, "test_state.json", "test_wind.json", false).(*manager)
+
+	base := time.Now().Truncate(time.Minute).Add(-time.Hour)
+	var samples []FMIWindObservation
+	for i := 0; i <= 30; i++ {
+		samples = append(samples, FMIWindObservation{Timestamp: base.Add(time.Duration(i) * time.Minute), WindSpeed: 5, WindDirection: 200})
+	}
+	mgr.recordHistory("100996", samples)
+	mgr.updateWindData("100996", samples[30])
+
+	// Steady wind: no trend event
+	for _, msg := range sseMgr.messages {
+		if msg.Type == "trend" {
+			t.Fatalf("Unexpected trend event for steady wind: %+v", msg)
+		}
+	}
+
+	// Wind picks up sharply over the next ten minutes
+	var rising []FMIWindObservation
+	for i := 1; i <= 10; i++ {
+		rising = append(rising, FMIWindObservation{Timestamp: samples[30].Timestamp.Add(time.Duration(i) * time.Minute), WindSpeed: 5 + float64(i), WindDirection: 200})
+	}
+	mgr.recordHistory("100996", rising)
+	mgr.updateWindData("100996", rising[9])
+
+	obs, _ := mgr.GetLatestObservation("100996")
+	if obs.Trend == nil || obs.Trend.Speed != TrendBuilding {
+		t.Fatalf("Expected building trend on latest observation, got %+v", obs.Trend)
+	}
+
+	var events []TrendEvent
+	for _, msg := range sseMgr.messages {
+		if msg.Type == "trend" {
+			events = append(events, msg.Data.(TrendEvent))
+		}
+	}
+	if len(events) != 1 || events[0].PreviousSpeed != TrendSteady || events[0].Trend.Speed != TrendBuilding {
+		t.Errorf("Expected one steady -> building trend event, got %+v", events)
+	}
+}
+
+func TestPollSchedule(t *testing.T) {
+	s := newPollSchedule()
+	now := time.Now()
+
+	s.set("a", now.Add(time.Minute))
+	s.set("b", now.Add(-time.Second))
+	s.set("c", now.Add(10*time.Second)) // Within the batch window of b
+	s.set("d", now.Add(30*time.Second))
+
+	if next, _ := s.next(); !next.Equal(now.Add(-time.Second)) {
+		t.Errorf("Expected earliest due time first, got %v", next)
+	}
+
+	due := s.popDue(now)
+	if len(due) != 2 || due[0] != "b" || due[1] != "c" {
+		t.Errorf("Expected b and c to be batched, got %v", due)
+	}
+
+	// Rescheduling replaces the existing entry
+	s.set("a", now.Add(-time.Minute))
+	s.remove("d")
+	if due := s.popDue(now); len(due) != 1 || due[0] != "a" {
+		t.Errorf("Expected only a due, got %v", due)
+	}
+	if _, ok := s.next(); ok {
+		t.Error("Expected empty schedule")
+	}
+	if due := s.popDue(now); due != nil {
+		t.Errorf("Expected nothing due, got %v", due)
+	}
+
+	// Requests wake the scheduler once and are consumed
+	s.requestPoll("a", now.Add(time.Minute))
+	s.requestPoll("a", now)
+	s.requestResync()
+	select {
+	case <-s.wakeCh:
+	default:
+		t.Error("Expected wake signal")
+	}
+	resync, forced := s.takeRequests()
+	if !resync || len(forced) != 1 || !forced["a"].Equal(now) {
+		t.Errorf("Unexpected requests: %v %v", resync, forced)
+	}
+	if resync, forced := s.takeRequests(); resync || len(forced) != 0 {
+		t.Errorf("Expected requests to be cleared, got %v %v", resync, forced)
+	}
+}
+
+func TestResyncSchedule(t *testing.T) {
+	stationMgr := stations.NewManager()
+	sseMgr := &mockSSEManager{hasClient: true}
+	mgr := NewManager(stationMgr, sseMgr, "test_state.json", "test_wind.json", false).(*manager)
+
+	lastPolled := time.Now().Add(-30 * time.Second)
+	mgr.pollingStates["100996"] = &PollingState{StationID: "100996", CurrentInterval: IntervalFast, LastPolled: lastPolled}
+	mgr.pollingStates["removed"] = &PollingState{StationID: "removed", CurrentInterval: IntervalFast}
+	mgr.schedule.set("removed", time.Now())
+
+	mgr.resyncSchedule()
+
+	if len(mgr.schedule.items) != len(stationMgr.GetAllStations()) {
+		t.Fatalf("Expected every station scheduled, got %d", len(mgr.schedule.items))
+	}
+	if _, exists := mgr.schedule.items["removed"]; exists {
+		t.Error("Unknown station should be dropped from the schedule")
+	}
+	if due := mgr.schedule.items["100996"].due; !due.Equal(lastPolled.Add(IntervalFast)) {
+		t.Errorf("Expected due one interval after last poll, got %v", due)
+	}
+
+	// Without clients every station falls back to the slow interval
+	sseMgr.hasClient = false
+	mgr.resyncSchedule()
+	if due := mgr.schedule.items["100996"].due; !due.Equal(lastPolled.Add(IntervalSlow)) {
+		t.Errorf("Expected slow interval without clients, got %v", due)
+	}
+
+	// Only the stations clients are subscribed to keep their adaptive rate
+	mgr.backgroundInterval = 2 * time.Hour
+	mgr.pollingStates["101022"] = &PollingState{StationID: "101022", CurrentInterval: IntervalFast, LastPolled: lastPolled}
+	sseMgr.subscribed = map[string]int{"101022": 1}
+	mgr.resyncSchedule()
+	if due := mgr.schedule.items["101022"].due; !due.Equal(lastPolled.Add(IntervalFast)) {
+		t.Errorf("Expected subscribed station at its adaptive interval, got %v", due)
+	}
+	if due := mgr.schedule.items["100996"].due; !due.Equal(lastPolled.Add(2 * time.Hour)) {
+		t.Errorf("Expected unsubscribed station at the background interval, got %v", due)
+	}
+
+	// A refresh makes the station due immediately
+	if err := mgr.Refresh("unknown"); err != ErrUnknownStation {
+		t.Errorf("Expected ErrUnknownStation, got %v", err)
+	}
+	if err := mgr.Refresh("100996"); err != nil {
+		t.Fatalf("Expected Refresh to accept a known station, got %v", err)
+	}
+	mgr.applyScheduleRequests()
+	if due := mgr.schedule.popDue(time.Now()); !slices.Contains(due, "100996") {
+		t.Errorf("Expected refreshed station to be due, got %v", due)
+	}
+
+	// Refreshing a station polled moments ago waits for the minimum gap
+	recent := time.Now().Add(-10 * time.Second)
+	mgr.pollingStates["101022"].LastPolled = recent
+	for range 5 {
+		if err := mgr.Refresh("101022"); err != ErrRefreshTooSoon {
+			t.Fatalf("Expected ErrRefreshTooSoon, got %v", err)
+		}
+	}
+	mgr.applyScheduleRequests()
+	if due := mgr.schedule.items["101022"].due; !due.Equal(recent.Add(minPollGap)) {
+		t.Errorf("Expected the refresh deferred to the end of the gap, got %v", due)
+	}
+}
+
+func TestRefreshHandler(t *testing.T) {
+	mgr := NewManager(stations.NewManager(), &mockSSEManager{}, "test_state.json", "test_wind.json", false).(*manager)
+	mgr.pollingStates["101022"] = &PollingState{StationID: "101022", CurrentInterval: IntervalFast, LastPolled: time.Now()}
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, mgr, stations.NewManager(), "s3cret")
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{"POST", "/api/observations/100996/refresh", http.StatusAccepted},
+		{"GET", "/api/observations/100996/refresh", http.StatusMethodNotAllowed},
+		{"POST", "/api/observations/unknown/refresh", http.StatusNotFound},
+		{"POST", "/api/observations/101022/refresh", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/observations/100996/refresh", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without the admin token, got %d", rec.Code)
+	}
+}
+
+func TestPublicationLag(t *testing.T) {
+	state := &PollingState{StationID: "100996", CurrentInterval: IntervalMedium}
+	poll := func(at, newest time.Time, hadData bool) {
+		recordPublicationLag(state, hadData, newest, at)
+		state.LastPolled = at
+		if hadData {
+			state.LastObservation = newest
+		}
+	}
+
+	// Seed samples while polling on the regular interval
+	base := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	poll(base.Add(6*time.Minute), base, true)
+	poll(base.Add(14*time.Minute+30*time.Second), base.Add(10*time.Minute), true)
+	if nextPollTime(state, true, IntervalSlow) != state.LastPolled.Add(IntervalMedium) {
+		t.Error("Expected regular interval before enough samples")
+	}
+	poll(base.Add(25*time.Minute), base.Add(20*time.Minute), true)
+
+	if !state.Lag.ready() || state.Lag.Offset != 270 {
+		t.Fatalf("Expected offset seeded from the smallest lag, got %+v", state.Lag)
+	}
+
+	// Polls are aligned to the expected publication, only with clients connected
+	expected := base.Add(30*time.Minute + 270*time.Second + publicationMargin)
+	if next := nextPollTime(state, true, IntervalSlow); !next.Equal(expected) {
+		t.Errorf("Expected aligned poll at %v, got %v", expected, next)
+	}
+	if next := nextPollTime(state, false, IntervalSlow); !next.Equal(state.LastPolled.Add(IntervalSlow)) {
+		t.Errorf("Expected slow interval without clients, got %v", next)
+	}
+
+	// A first-try hit probes earlier next time
+	poll(expected, base.Add(30*time.Minute), true)
+	if state.Lag.Hits != 1 || state.Lag.Offset != 255 {
+		t.Errorf("Expected offset reduced after hit, got %+v", state.Lag)
+	}
+
+	// A miss is retried after a minute and the retry sets the offset
+	expected = nextPollTime(state, true, IntervalSlow)
+	poll(expected, time.Time{}, false)
+	if !state.Lag.Late || state.Lag.Misses != 1 {
+		t.Errorf("Expected late publication after miss, got %+v", state.Lag)
+	}
+	retry := nextPollTime(state, true, IntervalSlow)
+	if !retry.Equal(expected.Add(lateRetry)) {
+		t.Errorf("Expected retry one minute later, got %v", retry)
+	}
+	poll(retry, base.Add(40*time.Minute), true)
+	if state.Lag.Late || state.Lag.Offset != retry.Sub(base.Add(40*time.Minute)).Seconds() {
+		t.Errorf("Expected offset from retry, got %+v", state.Lag)
+	}
+
+	// A publication missing for a whole interval falls back to the regular interval
+	poll(expectedPublication(state).Add(IntervalMedium), time.Time{}, false)
+	if next := nextPollTime(state, true, IntervalSlow); !next.Equal(state.LastPolled.Add(IntervalMedium)) {
+		t.Errorf("Expected regular interval after the late window, got %v", next)
+	}
+
+	summary := state.Lag.Summary()
+	if summary.Samples != 5 || summary.MinSeconds != 270 || summary.MedianSeconds != 300 ||
+		summary.P90Seconds != 360 || summary.Misses != 1 || !summary.Aligned {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
+
+func TestLateRetryDoesNotBackOff(t *testing.T) {
+	mgr := NewManager(stations.NewManager(), &mockSSEManager{}, "test_state.json", "test_wind.json", false).(*manager)
+
+	now := time.Now()
+	state := &PollingState{
+		StationID:       "100996",
+		CurrentInterval: IntervalMedium,
+		LastObservation: now.Add(-15 * time.Minute),
+		LastPolled:      now.Add(-time.Minute),
+		Lag:             &PublicationLag{Recent: []float64{240, 250, 260}, Offset: 240, Late: true},
+	}
+	for range 3 {
+		mgr.updatePollingState(state, nil)
+	}
+	if state.CurrentInterval != IntervalMedium || state.ConsecutiveMisses != 0 {
+		t.Errorf("Late retries should not back off, got %+v", state)
+	}
+}
+
+func TestObservationStatus(t *testing.T) {
+	stationMgr := stations.NewManager()
+	mgr := NewManager(stationMgr, &mockSSEManager{}, "test_state.json", "test_wind.json", false).(*manager)
+	mgr.pollingStates["100996"] = &PollingState{
+		StationID:       "100996",
+		CurrentInterval: IntervalMedium,
+		Lag:             &PublicationLag{Recent: []float64{300, 200, 250}, Offset: 200},
+	}
+
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, mgr, stationMgr, "")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/observations/status", nil))
+
+	var statuses []StationStatus
+	if err := json.NewDecoder(rec.Body).Decode(&statuses); err != nil {
+		t.Fatalf("Invalid status response: %v", err)
+	}
+	if len(statuses) != len(stationMgr.GetAllStations()) {
+		t.Fatalf("Expected a status per station, got %d", len(statuses))
+	}
+	for _, status := range statuses {
+		if status.ID != "100996" {
+			continue
+		}
+		lag := status.PublicationLag
+		if status.PollingInterval != "10m" || lag == nil || lag.MedianSeconds != 250 || lag.OffsetSeconds != 200 {
+			t.Errorf("Unexpected status: %+v %+v", status, lag)
+		}
+	}
+}
+
+// simStation is a station served by fakeFMI
+type simStation struct {
+	cadence time.Duration // Observation interval, zero for a silent station
+	lag     time.Duration // Delay before an observation is published
+	from    time.Time     // First observation time, zero for always
+}
+
+// fakeFMI is an FMI WFS endpoint that generates multipointcoverage responses for
+// simulated stations, publishing each observation lag after its timestamp
+type fakeFMI struct {
+	clock    *clock.Fake
+	stations map[string]simStation
+
+	mu       sync.Mutex
+	polls    map[string][]time.Time
+	down     bool // Respond 503 to every request
+	requests int
+}
+
+func (f *fakeFMI) setDown(down bool) {
+	f.mu.Lock()
+	f.down = down
+	f.mu.Unlock()
+}
+
+func (f *fakeFMI) requestCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func (f *fakeFMI) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.requests++
+	down := f.down
+	f.mu.Unlock()
+	if down {
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Status:     "503 Service Unavailable",
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader("maintenance")),
+			Request:    req,
+		}, nil
+	}
+
+	query := req.URL.Query()
+	start, _ := time.Parse(time.RFC3339, query.Get("starttime"))
+	end, _ := time.Parse(time.RFC3339, query.Get("endtime"))
+	now := f.clock.Now()
+
+	var locations, points, positions, values strings.Builder
+	for i, stationID := range query["fmisid"] {
+		f.mu.Lock()
+		f.polls[stationID] = append(f.polls[stationID], now)
+		f.mu.Unlock()
+
+		lat, lon := 60+float64(i)/100, 25.0
+		fmt.Fprintf(&locations, `<member><Location><identifier>%s</identifier><name codeSpace="http://xml.fmi.fi/namespace/locationcode/name">S%s</name></Location></member>`, stationID, stationID)
+		fmt.Fprintf(&points, `<pointMember><Point><name>S%s</name><pos>%.5f %.5f </pos></Point></pointMember>`, stationID, lat, lon)
+
+		station := f.stations[stationID]
+		if station.cadence == 0 {
+			continue
+		}
+		ts := start.Truncate(station.cadence)
+		for ; !ts.After(end); ts = ts.Add(station.cadence) {
+			if ts.Before(start) || ts.Before(station.from) || ts.Add(station.lag).After(now) {
+				continue
+			}
+			fmt.Fprintf(&positions, "%.5f %.5f %d\n", lat, lon, ts.Unix())
+			values.WriteString("5.0 7.5 180.0\n")
+		}
+	}
+
+	body := fmt.Sprintf(`<FeatureCollection><member><GridSeriesObservation>
+<observedProperty href="https://opendata.fmi.fi/meta?param=windspeedms,windgust,winddirection"/>
+<featureOfInterest><SF_SpatialSamplingFeature><sampledFeature><LocationCollection>%s</LocationCollection></sampledFeature>
+<shape><MultiPoint>%s</MultiPoint></shape></SF_SpatialSamplingFeature></featureOfInterest>
+<result><MultiPointCoverage><domainSet><SimpleMultiPoint><positions>%s</positions></SimpleMultiPoint></domainSet>
+<rangeSet><DataBlock><doubleOrNilReasonTupleList>%s</doubleOrNilReasonTupleList></DataBlock></rangeSet></MultiPointCoverage></result>
+</GridSeriesObservation></member></FeatureCollection>`, locations.String(), points.String(), positions.String(), values.String())
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// pollsBetween counts the polls of a station in [from, to)
+func (f *fakeFMI) pollsBetween(stationID string, from, to time.Time) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, at := range f.polls[stationID] {
+		if !at.Before(from) && at.Before(to) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPollingSimulation24h(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	fmi := &fakeFMI{
+		clock: clk,
+		stations: map[string]simStation{
+			"101023": {cadence: time.Minute, lag: time.Minute},                                      // Fast station
+			"100996": {cadence: 10 * time.Minute, lag: 4 * time.Minute},                             // Standard station
+			"105392": {cadence: 10 * time.Minute, lag: 3 * time.Minute, from: start.Add(time.Hour)}, // Comes online after an hour
+			// Every other station stays silent
+		},
+		polls: make(map[string][]time.Time),
+	}
+
+	sseMgr := &mockSSEManager{hasClient: true}
+	dir := t.TempDir()
+	mgr := NewManagerWithConfig(stations.NewManager(), sseMgr, Config{
+		StateFile:    filepath.Join(dir, "state.json"),
+		WindDataFile: filepath.Join(dir, "wind.json"),
+		Clock:        clk,
+	}).(*manager)
+	mgr.upstreams[SourceFMI].source = NewFMISource(&http.Client{Transport: fmi})
+
+	interval := func(stationID string) time.Duration {
+		state, _ := mgr.GetPollingState(stationID)
+		return state.CurrentInterval
+	}
+
+	noClientsFrom, noClientsTo := start.Add(12*time.Hour), start.Add(14*time.Hour)
+	checkpoints := map[time.Duration]func(){
+		3 * time.Hour: func() {
+			if got := interval("101023"); got != IntervalFast {
+				t.Errorf("Fast station interval = %v, want 1m", got)
+			}
+			if got := interval("100996"); got != IntervalMedium {
+				t.Errorf("Standard station not demoted to 10m, got %v", got)
+			}
+			if got := interval("105392"); got != IntervalMedium {
+				t.Errorf("Revived station not promoted to 10m, got %v", got)
+			}
+			if got := interval("101022"); got != IntervalUltraSlow {
+				t.Errorf("Silent station not demoted to 24h, got %v", got)
+			}
+		},
+	}
+
+	if err := mgr.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	end := start.Add(24 * time.Hour)
+	for clk.Now().Before(end) {
+		clk.BlockUntil(3) // The scheduler is asleep next to the checkpoint and freshness tickers
+		now := clk.Now()
+		sseMgr.hasClient = now.Before(noClientsFrom) || !now.Before(noClientsTo)
+		for at, check := range checkpoints {
+			if !now.Before(start.Add(at)) {
+				check()
+				delete(checkpoints, at)
+			}
+		}
+		clk.AdvanceToNext()
+	}
+	clk.BlockUntil(3)
+	mgr.Stop()
+
+	// Without clients the fast station drops to hourly polls
+	if n := fmi.pollsBetween("101023", noClientsFrom.Add(time.Minute), noClientsTo); n > 3 {
+		t.Errorf("Expected hourly polls without clients, got %d in two hours", n)
+	}
+	if n := fmi.pollsBetween("101023", start.Add(16*time.Hour), start.Add(17*time.Hour)); n < 55 {
+		t.Errorf("Expected minute polls after clients return, got %d in an hour", n)
+	}
+
+	// The standard station is polled about once per observation, just after publication
+	if n := fmi.pollsBetween("100996", start.Add(18*time.Hour), start.Add(19*time.Hour)); n < 6 || n > 9 {
+		t.Errorf("Expected publication-aligned polls of the standard station, got %d in an hour", n)
+	}
+	state, _ := mgr.GetPollingState("100996")
+	if !state.Lag.ready() || state.Lag.Offset < 180 || state.Lag.Offset > 330 {
+		t.Errorf("Expected learned lag near 4 minutes, got %+v", state.Lag)
+	}
+
+	if obs, ok := mgr.GetLatestObservation("101023"); !ok || obs.Timestamp.Before(end.Add(-3*time.Minute)) {
+		t.Errorf("Expected fresh fast station data at the end, got %+v", obs)
+	}
+
+	// Reporting stations turn fresh once, slow polling without clients does not age
+	// them, and silent stations stay offline without events
+	if state, _ := mgr.GetPollingState("101023"); state.Cadence != time.Minute {
+		t.Errorf("Expected 1m cadence, got %v", state.Cadence)
+	}
+	if state, _ := mgr.GetPollingState("100996"); state.Cadence != 10*time.Minute {
+		t.Errorf("Expected 10m cadence, got %v", state.Cadence)
+	}
+	changes := make(map[string][]string)
+	for _, message := range sseMgr.messages {
+		if message.Type == "freshness" {
+			event := message.Data.(FreshnessEvent)
+			changes[event.StationID] = append(changes[event.StationID], string(event.Previous)+"->"+string(event.Freshness))
+		}
+	}
+	for _, stationID := range []string{"101023", "100996", "105392"} {
+		if got := strings.Join(changes[stationID], ","); got != "offline->fresh" {
+			t.Errorf("Station %s freshness changes %q, want offline->fresh", stationID, got)
+		}
+	}
+	if len(changes) != 3 || mgr.GetFreshness("101022") != FreshnessOffline {
+		t.Errorf("Unexpected freshness changes %v", changes)
+	}
+}
+
+func TestCheckpoints(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	cfg := Config{
+		StateFile:          filepath.Join(dir, "state.json"),
+		WindDataFile:       filepath.Join(dir, "wind.json"),
+		Clock:              clk,
+		CheckpointInterval: time.Minute,
+	}
+	mgr := NewManagerWithConfig(stations.NewManager(), &mockSSEManager{}, cfg).(*manager)
+	mgr.upstreams[SourceFMI].source = NewFMISource(&http.Client{Transport: &fakeFMI{clock: clk, polls: make(map[string][]time.Time)}})
+
+	if err := mgr.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer mgr.Stop()
+	clk.BlockUntil(3)
+
+	// A running manager writes its state without waiting for Stop
+	mgr.windDataMutex.Lock()
+	mgr.windData["101022"] = WindObservation{StationID: "101022", WindSpeed: 7.5, Timestamp: clk.Now()}
+	mgr.windDataMutex.Unlock()
+	clk.Advance(time.Minute)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(cfg.WindDataFile)
+		if strings.Contains(string(data), "101022") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Wind data was not checkpointed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// A torn primary file falls back to the previous checkpoint
+	mgr.saveState()
+	if err := os.WriteFile(cfg.WindDataFile, []byte(`{"101022": {"station_id"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cfg.StateFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := NewManagerWithConfig(stations.NewManager(), &mockSSEManager{}, cfg).(*manager)
+	restarted.loadPollingStates()
+	restarted.loadWindData()
+	if obs, ok := restarted.GetLatestObservation("101022"); !ok || obs.WindSpeed != 7.5 {
+		t.Errorf("Expected observation recovered from backup, got %+v (%v)", obs, ok)
+	}
+	if len(restarted.pollingStates) == 0 {
+		t.Error("Expected polling states recovered from backup")
+	}
+}
+
+func TestStateFileVersions(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{StateFile: filepath.Join(dir, "state.json"), WindDataFile: filepath.Join(dir, "wind.json")}
+
+	// Files written before versioning store intervals in nanoseconds
+	legacyStates := `{"101022": {"station_id": "101022", "current_interval": 600000000000, "consecutive_misses": 2,
+		"last_polled": "2026-03-01T12:00:00Z", "last_observation": "2026-03-01T11:50:00Z", "total_polls": 5}}`
+	legacyWind := `{"101022": {"station_id": "101022", "wind_speed": 6.1, "timestamp": "2026-03-01T11:50:00Z"}}`
+	os.WriteFile(cfg.StateFile, []byte(legacyStates), 0644)
+	os.WriteFile(cfg.WindDataFile, []byte(legacyWind), 0644)
+
+	mgr := NewManagerWithConfig(stations.NewManager(), &mockSSEManager{}, cfg).(*manager)
+	mgr.loadPollingStates()
+	mgr.loadWindData()
+	state, ok := mgr.GetPollingState("101022")
+	if !ok || state.CurrentInterval != IntervalMedium || state.ConsecutiveMisses != 2 || state.TotalPolls != 5 {
+		t.Fatalf("Legacy polling state not migrated: %+v", state)
+	}
+	if obs, ok := mgr.GetLatestObservation("101022"); !ok || obs.WindSpeed != 6.1 {
+		t.Fatalf("Legacy wind data not loaded: %+v", obs)
+	}
+
+	// Saved files carry the current version and a readable interval
+	mgr.saveState()
+	data, _ := os.ReadFile(cfg.StateFile)
+	var envelope struct {
+		Format  string                                `json:"format"`
+		Version int                                   `json:"version"`
+		Data    map[string]map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.Version != pollingStateSchema.Version {
+		t.Fatalf("Unexpected state file %s (%v)", data, err)
+	}
+	if interval := string(envelope.Data["101022"]["current_interval"]); interval != `"10m0s"` {
+		t.Errorf("Expected duration string interval, got %s", interval)
+	}
+
+	// A file from a newer release is refused, not loaded partially or from the backup
+	future := []byte(`{"format": "windz.polling_state", "version": 99, "data": {"101022": {}}}`)
+	os.WriteFile(cfg.StateFile, future, 0644)
+	restarted := NewManagerWithConfig(stations.NewManager(), &mockSSEManager{}, cfg).(*manager)
+	if err := restarted.loadPollingStates(); !errors.Is(err, checkpoint.ErrUnsupportedVersion) {
+		t.Errorf("Expected unsupported version error, got %v", err)
+	}
+	if _, ok := restarted.GetPollingState("101022"); ok {
+		t.Error("Future version state file should not be loaded")
+	}
+
+	// and the manager refuses to start rather than checkpoint over it
+	if err := restarted.Start(context.Background()); !errors.Is(err, checkpoint.ErrUnsupportedVersion) {
+		t.Errorf("Expected Start to fail on a future version file, got %v", err)
+	}
+	restarted.Stop()
+	if data, _ := os.ReadFile(cfg.StateFile); string(data) != string(future) {
+		t.Errorf("Future version state file was rewritten: %s", data)
+	}
+	if data, _ := os.ReadFile(cfg.StateFile + checkpoint.BackupSuffix); string(data) == string(future) {
+		t.Error("Future version state file was rotated to the backup")
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	var changes []BreakerState
+	b := newBreaker(clk, 3, time.Minute, func(status UpstreamStatus) { changes = append(changes, status.State) })
+	fail := errors.New("HTTP 503")
+
+	// Failures below the threshold and a success in between keep it closed
+	b.failure(fail)
+	b.failure(fail)
+	b.success()
+	b.failure(fail)
+	b.failure(fail)
+	if !b.allow() || b.status().State != BreakerClosed {
+		t.Fatalf("Breaker should stay closed, got %+v", b.status())
+	}
+
+	b.failure(fail)
+	if b.allow() || b.wait() != time.Minute {
+		t.Fatalf("Breaker should be open for a minute, got %+v", b.status())
+	}
+
+	// After the cooldown a single probe goes through; its failure doubles the cooldown
+	clk.Advance(time.Minute)
+	if !b.allow() || b.allow() {
+		t.Fatal("Expected exactly one half-open probe")
+	}
+	b.failure(fail)
+	if b.wait() != 2*time.Minute {
+		t.Errorf("Expected doubled cooldown, got %v", b.wait())
+	}
+
+	clk.Advance(2 * time.Minute)
+	if !b.allow() {
+		t.Fatal("Expected probe after the doubled cooldown")
+	}
+	if b.wait() != time.Minute {
+		t.Errorf("Expected the base cooldown as wait while the probe is in flight, got %v", b.wait())
+	}
+	b.success()
+	if status := b.status(); status.State != BreakerClosed || status.ConsecutiveFailures != 0 || status.RetryAt != nil {
+		t.Errorf("Expected closed breaker after a good probe, got %+v", status)
+	}
+
+	want := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if !slices.Equal(changes, want) {
+		t.Errorf("State changes = %v, want %v", changes, want)
+	}
+}
+
+func TestUpstreamOutage(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	fmi := &fakeFMI{clock: clk, polls: make(map[string][]time.Time), down: true}
+	sseMgr := &mockSSEManager{hasClient: true}
+	mgr := NewManagerWithConfig(stations.NewManager(), sseMgr, Config{
+		StateFile:    "test_state.json",
+		WindDataFile: "test_wind.json",
+		Clock:        clk,
+	}).(*manager)
+	mgr.upstreams[SourceFMI].source = NewFMISource(&http.Client{Transport: fmi})
+	mgr.resyncSchedule()
+
+	// An hour of outage: only the requests that open the breaker and the probes reach FMI
+	end := clk.Now().Add(time.Hour)
+	for clk.Now().Before(end) {
+		clk.Advance(max(mgr.pollDueStations(), time.Second))
+	}
+	if n := fmi.requestCount(); n > 12 {
+		t.Errorf("Expected few requests during the outage, got %d", n)
+	}
+	if status := mgr.UpstreamStatus(); status.State != BreakerOpen || status.LastError == "" {
+		t.Errorf("Expected open breaker, got %+v", status)
+	}
+	for _, stationID := range mgr.schedule.stationIDs() {
+		if state, _ := mgr.GetPollingState(stationID); state.CurrentInterval != IntervalFast {
+			t.Fatalf("Station %s demoted during the outage: %+v", stationID, state)
+		}
+	}
+
+	// Recovery closes the breaker on the next probe and polling resumes
+	fmi.setDown(false)
+	clk.Advance(mgr.pollDueStations())
+	mgr.pollDueStations()
+	if status := mgr.UpstreamStatus(); status.State != BreakerClosed {
+		t.Fatalf("Expected closed breaker after recovery, got %+v", status)
+	}
+	if state, _ := mgr.GetPollingState("101022"); state.LastPolled.Before(end) {
+		t.Errorf("Expected station polled after recovery, got %+v", state)
+	}
+
+	var states []BreakerState
+	for _, message := range sseMgr.messages {
+		if message.Type == "upstream" {
+			states = append(states, message.Data.(UpstreamStatus).State)
+		}
+	}
+	if len(states) < 3 || states[0] != BreakerOpen || states[len(states)-1] != BreakerClosed {
+		t.Errorf("Unexpected upstream events: %v", states)
+	}
+}
+
+// blockingSource holds every Fetch until released
+type blockingSource struct {
+	started chan struct{}
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (s *blockingSource) Fetch(stationIDs []string, start, end time.Time) (map[string][]FMIWindObservation, error) {
+	s.calls.Add(1)
+	s.started <- struct{}{}
+	<-s.release
+	return map[string][]FMIWindObservation{}, nil
+}
+
+func (s *blockingSource) Cadence(stationID string) time.Duration { return 0 }
+
+func (s *blockingSource) MaxBatch() int { return 20 }
+
+func TestProbeInFlight(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	mgr := NewManagerWithConfig(stations.NewManager(), &mockSSEManager{hasClient: true}, Config{
+		StateFile:    "test_state.json",
+		WindDataFile: "test_wind.json",
+		Clock:        clk,
+	}).(*manager)
+	source := &blockingSource{started: make(chan struct{}, 10), release: make(chan struct{})}
+	mgr.upstreams[SourceFMI].source = source
+	mgr.resyncSchedule()
+
+	// Open the breaker and let the cooldown pass
+	for range DefaultBreakerThreshold {
+		mgr.upstreams[SourceFMI].breaker.failure(errors.New("HTTP 503"))
+	}
+	clk.Advance(DefaultBreakerCooldown)
+
+	// Another caller, such as the backfiller, takes the half-open probe
+	probeDone := make(chan error)
+	go func() {
+		_, err := mgr.fetchWindDataBatch(SourceFMI, []string{"101022"}, clk.Now().Add(-time.Hour), clk.Now())
+		probeDone <- err
+	}()
+	<-source.started
+
+	// The scheduler defers the due stations instead of retrying them in a loop
+	waits := make(chan time.Duration)
+	go func() { waits <- mgr.pollDueStations() }()
+	select {
+	case wait := <-waits:
+		if wait <= 0 {
+			t.Errorf("Expected the scheduler to sleep while the probe is in flight, got %v", wait)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Scheduler kept polling while the probe was in flight")
+	}
+	if calls := source.calls.Load(); calls != 1 {
+		t.Errorf("Expected only the probe to reach the source, got %d fetches", calls)
+	}
+
+	// A good probe closes the breaker and the stations are due again
+	close(source.release)
+	if err := <-probeDone; err != nil {
+		t.Fatalf("Probe error = %v", err)
+	}
+	mgr.pollDueStations()
+	if calls := source.calls.Load(); calls != 2 {
+		t.Errorf("Expected the stations polled after the probe succeeded, got %d fetches", calls)
+	}
+}
+
+// extraStations adds stations to the built-in ones
+type extraStations struct {
+	stations.Manager
+	extra []stations.Station
+}
+
+func (m *extraStations) GetAllStations() []stations.Station {
+	return append(m.Manager.GetAllStations(), m.extra...)
+}
+
+func (m *extraStations) GetStation(stationID string) (stations.Station, bool) {
+	for _, station := range m.extra {
+		if station.ID == stationID {
+			return station, true
+		}
+	}
+	return m.Manager.GetStation(stationID)
+}
+
+// fakeSource is a Source reporting every ten minutes with a one minute lag
+type fakeSource struct {
+	clock   clock.Clock
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (s *fakeSource) Fetch(stationIDs []string, start, end time.Time) (map[string][]FMIWindObservation, error) {
+	s.mu.Lock()
+	s.batches = append(s.batches, stationIDs)
+	s.mu.Unlock()
+
+	results := make(map[string][]FMIWindObservation)
+	for _, stationID := range stationIDs {
+		for ts := start.Truncate(IntervalMedium); !ts.After(end); ts = ts.Add(IntervalMedium) {
+			if !ts.Before(start) && !ts.Add(time.Minute).After(s.clock.Now()) {
+				results[stationID] = append(results[stationID], FMIWindObservation{Timestamp: ts, WindSpeed: 4, WindGust: 6, WindDirection: 90})
+			}
+		}
+	}
+	return results, nil
+}
+
+func (s *fakeSource) Cadence(stationID string) time.Duration { return IntervalMedium }
+
+func (s *fakeSource) MaxBatch() int { return 2 }
+
+func TestSources(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	stationMgr := &extraStations{Manager: stations.NewManager()}
+	for _, id := range []string{"club1", "club2", "club3"} {
+		stationMgr.extra = append(stationMgr.extra, stations.Station{ID: id, Name: "Club " + id, Region: "Helsinki", Source: "club"})
+	}
+	stationMgr.extra = append(stationMgr.extra, stations.Station{ID: "orphan", Name: "Orphan", Source: "missing"})
+
+	fmi := &fakeFMI{clock: clk, polls: make(map[string][]time.Time), down: true}
+	club := &fakeSource{clock: clk}
+	mgr := NewManagerWithConfig(stationMgr, &mockSSEManager{hasClient: true}, Config{
+		StateFile:    "test_state.json",
+		WindDataFile: "test_wind.json",
+		Clock:        clk,
+		Sources:      map[string]Source{"club": club},
+	}).(*manager)
+	mgr.upstreams[SourceFMI].source = NewFMISource(&http.Client{Transport: fmi})
+	mgr.resyncSchedule()
+
+	// The source's cadence hint sets the starting interval and freshness threshold
+	if state, _ := mgr.GetPollingState("club1"); state.CurrentInterval != IntervalMedium {
+		t.Errorf("Expected hinted 10m interval, got %v", state.CurrentInterval)
+	}
+	if state, _ := mgr.GetPollingState("101022"); state.CurrentInterval != IntervalFast {
+		t.Errorf("Expected FMI stations to start fast, got %v", state.CurrentInterval)
+	}
+
+	// An FMI outage does not hold back the other source
+	end := clk.Now().Add(time.Hour)
+	for clk.Now().Before(end) {
+		clk.Advance(max(mgr.pollDueStations(), time.Second))
+	}
+	if status := mgr.UpstreamStatus(); status.State != BreakerOpen {
+		t.Errorf("Expected open FMI breaker, got %+v", status)
+	}
+	if status := mgr.sourceStatus("club"); status.State != BreakerClosed {
+		t.Errorf("Expected closed club breaker, got %+v", status)
+	}
+	obs, ok := mgr.GetLatestObservation("club2")
+	if !ok || obs.StationName != "Club club2" || obs.WindSpeed != 4 || obs.Timestamp.Before(end.Add(-11*time.Minute)) {
+		t.Errorf("Expected current club data, got %+v (%v)", obs, ok)
+	}
+	if got := mgr.GetFreshness("club2"); got != FreshnessFresh {
+		t.Errorf("Expected fresh club station, got %s", got)
+	}
+
+	club.mu.Lock()
+	for _, batch := range club.batches {
+		if len(batch) > 2 || slices.ContainsFunc(batch, func(id string) bool { return !strings.HasPrefix(id, "club") }) {
+			t.Errorf("Unexpected club batch %v", batch)
+		}
+	}
+	club.mu.Unlock()
+	for stationID := range fmi.polls {
+		if strings.HasPrefix(stationID, "club") {
+			t.Errorf("Club station %s requested from FMI", stationID)
+		}
+	}
+
+	// A station naming an unconfigured source fails without affecting the others
+	if _, ok := mgr.GetLatestObservation("orphan"); ok {
+		t.Error("Expected no data for a station with an unknown source")
+	}
+	if state, _ := mgr.GetPollingState("orphan"); state.SuccessRate != 0 || state.LastPolled.IsZero() {
+		t.Errorf("Expected failed polls of the orphan station, got %+v", state)
+	}
+}
+
+func TestQualityControl(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.Options{})
+	if err != nil {
+		t.Fatalf("store.Open() error = %v", err)
+	}
+	defer st.Close()
+	mgr := NewManagerWithConfig(stations.NewManager(), &mockSSEManager{}, Config{
+		StateFile:    "test_state.json",
+		WindDataFile: "test_wind.json",
+		Store:        st,
+	}).(*manager)
+
+	base := time.Now().Truncate(time.Hour).Add(-3 * time.Hour)
+	sample := func(minute int, speed, gust, direction float64) FMIWindObservation {
+		return FMIWindObservation{Timestamp: base.Add(time.Duration(minute) * time.Minute), WindSpeed: speed, WindGust: gust, WindDirection: direction}
+	}
+	quality := func(stationID string, minute int) string {
+		return mgr.sampleQuality(stationID, base.Add(time.Duration(minute)*time.Minute))
+	}
+
+	// A spike is only known once the next sample is back at the earlier level
+	mgr.recordHistory("100996", []FMIWindObservation{sample(0, 5, 7, 200), sample(10, 16, 18, 210)})
+	if q := quality("100996", 10); q != "" {
+		t.Errorf("Newest sample flagged before its successor arrived: %q", q)
+	}
+	mgr.recordHistory("100996", []FMIWindObservation{sample(20, 5.5, 7.5, 205), sample(30, 6, 4, 200)})
+	if q := quality("100996", 10); q != FlagSpike {
+		t.Errorf("Expected spike, got %q", q)
+	}
+	if q := quality("100996", 30); q != FlagGustBelowSpeed {
+		t.Errorf("Expected gust below speed, got %q", q)
+	}
+
+	// A sample after a long gap is checked against the one before it
+	mgr.recordHistory("100996", []FMIWindObservation{sample(180, 6, 8, 200)})
+	if q := quality("100996", 180); q != "" {
+		t.Errorf("Sample after a gap flagged: %q", q)
+	}
+
+	// The re-flagged sample is stored with its flags, and aggregates leave it out
+	records, _ := st.Range("100996", base, base.Add(time.Hour))
+	if len(records) != 4 || records[1].Quality != FlagSpike {
+		t.Errorf("Expected stored spike flag, got %+v", records)
+	}
+	aggregates := aggregateObservations(mgr.GetHistory("100996", base, base.Add(time.Hour)), base, time.Hour)
+	if len(aggregates) != 1 || aggregates[0].Count != 2 || aggregates[0].MaxGust != 7.5 {
+		t.Errorf("Expected flagged samples excluded from aggregates, got %+v", aggregates)
+	}
+
+	// Identical speed for over an hour, and one direction while the speed climbs
+	var stuck, frozen []FMIWindObservation
+	for minute := 0; minute <= 70; minute += 10 {
+		stuck = append(stuck, sample(minute, 4.2, 6+float64(minute)/10, 180+float64(minute)))
+		frozen = append(frozen, sample(minute, 3+float64(minute)/10, 5+float64(minute)/10, 90))
+	}
+	mgr.recordHistory("101022", stuck)
+	mgr.recordHistory("101023", frozen)
+	if q := quality("101022", 0); q != FlagStuck {
+		t.Errorf("Expected stuck, got %q", q)
+	}
+	if q := quality("101023", 70); q != FlagDirectionFrozen {
+		t.Errorf("Expected frozen direction, got %q", q)
+	}
+
+	// A jump that the station 6.5 km away does not see
+	mgr.recordHistory("151028", []FMIWindObservation{sample(0, 6, 8, 200), sample(10, 6.5, 8.5, 200), sample(20, 6, 8, 200)})
+	mgr.recordHistory("105392", []FMIWindObservation{sample(0, 6, 8, 200), sample(10, 19, 22, 200)})
+	if q := quality("105392", 10); q != FlagNeighbourJump {
+		t.Errorf("Expected neighbour jump, got %q", q)
+	}
+	if q := quality("151028", 10); q != "" {
+		t.Errorf("Steady neighbour flagged: %q", q)
+	}
+
+	mgr.updateWindData("105392", sample(10, 19, 22, 200))
+	if obs, _ := mgr.GetLatestObservation("105392"); obs.Quality != FlagNeighbourJump {
+		t.Errorf("Expected flags on the latest observation, got %+v", obs)
+	}
+	if counts := countFlags(mgr.GetHistory("101022", base, base.Add(2*time.Hour))); counts[FlagStuck] != 8 {
+		t.Errorf("Unexpected flag counts %v", counts)
+	}
+
+	// The running counts follow flags as they are set, cleared and expire
+	for _, stationID := range []string{"101022", "105392", "151028"} {
+		if got, want := mgr.GetQualityFlags(stationID), countFlags(mgr.GetHistory(stationID, base.Add(-time.Hour), base.Add(3*time.Hour))); !maps.Equal(got, want) {
+			t.Errorf("GetQualityFlags(%s) = %v, expected %v", stationID, got, want)
+		}
+	}
+}
+
+func TestFreshness(t *testing.T) {
+	tests := []struct {
+		age, cadence, lag time.Duration
+		want              Freshness
+	}{
+		{2 * time.Minute, time.Minute, 0, FreshnessFresh},
+		{10 * time.Minute, time.Minute, 0, FreshnessLate},
+		{45 * time.Minute, time.Minute, 0, FreshnessStale},
+		{7 * time.Hour, time.Minute, 0, FreshnessOffline},
+		{18 * time.Minute, 10 * time.Minute, 4 * time.Minute, FreshnessFresh},
+		{25 * time.Minute, 10 * time.Minute, 4 * time.Minute, FreshnessLate},
+		{50 * time.Minute, 10 * time.Minute, 4 * time.Minute, FreshnessStale},
+		{3 * time.Hour, time.Hour, 0, FreshnessLate},
+		{7 * time.Hour, time.Hour, 0, FreshnessStale},
+	}
+	for _, tt := range tests {
+		if got := classifyFreshness(tt.age, tt.cadence, tt.lag); got != tt.want {
+			t.Errorf("classifyFreshness(%v, %v, %v) = %s, want %s", tt.age, tt.cadence, tt.lag, got, tt.want)
+		}
+	}
+
+	// The cadence is learned from a batch; a single sample only lowers it
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	state := &PollingState{}
+	learnCadence(state, time.Time{}, []FMIWindObservation{{Timestamp: start}, {Timestamp: start.Add(10 * time.Minute)}, {Timestamp: start.Add(20 * time.Minute)}})
+	learnCadence(state, start.Add(20*time.Minute), []FMIWindObservation{{Timestamp: start.Add(50 * time.Minute)}})
+	if state.Cadence != 10*time.Minute {
+		t.Errorf("Expected 10m cadence, got %v", state.Cadence)
+	}
+	record, _ := newPollingStateRecord(state).state()
+	if record.Cadence != state.Cadence {
+		t.Errorf("Cadence not persisted: %+v", record)
+	}
+
+	clk := clock.NewFake(start)
+	sseMgr := &mockSSEManager{}
+	mgr := NewManagerWithConfig(stations.NewManager(), sseMgr, Config{
+		StateFile:    "test_state.json",
+		WindDataFile: "test_wind.json",
+		Clock:        clk,
+	}).(*manager)
+	var events []FreshnessEvent
+	mgr.AddFreshnessListener(func(event FreshnessEvent) { events = append(events, event) })
+
+	// Stations are first graded silently, then every change is announced
+	mgr.updateFreshness("100996", "101022")
+	mgr.updateWindData("100996", FMIWindObservation{Timestamp: start, WindSpeed: 5, WindGust: 7, WindDirection: 200})
+	if obs, _ := mgr.GetLatestObservation("100996"); obs.Freshness != FreshnessFresh {
+		t.Errorf("Expected fresh latest observation, got %+v", obs)
+	}
+	for _, after := range []time.Duration{20 * time.Minute, time.Hour, 7 * time.Hour} {
+		clk.Advance(start.Add(after).Sub(clk.Now()))
+		mgr.updateFreshness("100996", "101022")
+	}
+
+	// A station that was not polled since its last observation is not aged by the wait
+	mgr.pollingStates["101023"] = &PollingState{StationID: "101023", LastPolled: clk.Now(), LastObservation: clk.Now().Add(-time.Minute)}
+	clk.Advance(50 * time.Minute)
+	if freshness := mgr.GetFreshness("101023"); freshness != FreshnessFresh {
+		t.Errorf("Expected station awaiting its next poll to be fresh, got %s", freshness)
+	}
+	clk.Advance(2 * time.Hour)
+	if freshness := mgr.GetFreshness("101023"); freshness != FreshnessStale {
+		t.Errorf("Expected station unpolled for hours to be stale, got %s", freshness)
+	}
+
+	var sequence []string
+	for _, event := range events {
+		if event.StationID != "100996" {
+			t.Errorf("Unexpected event for a station that never reported: %+v", event)
+		}
+		sequence = append(sequence, string(event.Freshness))
+	}
+	if strings.Join(sequence, ",") != "fresh,late,stale,offline" {
+		t.Errorf("Unexpected freshness sequence %v", sequence)
+	}
+	if last := events[len(events)-1]; last.Previous != FreshnessStale || last.Age != "7h0m0s" || last.StationName != "Harmaja" {
+		t.Errorf("Unexpected offline event %+v", last)
+	}
+	if all := mgr.GetAllLatestObservations(); all["100996"].Freshness != FreshnessOffline {
+		t.Errorf("Expected offline station, got %+v", all["100996"])
+	}
+	if mgr.GetFreshness("101022") != FreshnessOffline {
+		t.Error("A station without data should be offline")
+	}
+
+	broadcast := 0
+	for _, message := range sseMgr.messages {
+		switch message.Type {
+		case "freshness":
+			broadcast++
+		case "data":
+			if obs := message.Data.(WindObservation); obs.Freshness != FreshnessFresh {
+				t.Errorf("Expected freshness on data events, got %+v", obs)
+			}
+		}
+	}
+	if broadcast != len(events) {
+		t.Errorf("Expected %d freshness events, got %d", len(events), broadcast)
+	}
+}
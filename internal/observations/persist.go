@@ -0,0 +1,138 @@
+package observations
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"windz/internal/checkpoint"
+)
+
+// pollingStateSchema is the format of the polling state file. Version 1 stores
+// intervals as duration strings instead of nanoseconds.
+var pollingStateSchema = checkpoint.Schema{
+	Format:  "windz.polling_state",
+	Version: 1,
+	Migrations: map[int]checkpoint.Migration{
+		0: migratePollingStateV0,
+	},
+}
+
+// windDataSchema is the format of the latest observation file
+var windDataSchema = checkpoint.Schema{
+	Format:  "windz.wind_data",
+	Version: 1,
+	Migrations: map[int]checkpoint.Migration{
+		0: func(data json.RawMessage) (json.RawMessage, error) { return data, nil }, // Same layout, only wrapped
+	},
+}
+
+// pollingStateRecord is the stored form of a PollingState, kept separate so the
+// file format only changes through a new schema version
+type pollingStateRecord struct {
+	StationID         string          `json:"station_id"`
+	CurrentInterval   string          `json:"current_interval"`
+	Cadence           string          `json:"cadence,omitempty"`
+	ConsecutiveMisses int             `json:"consecutive_misses"`
+	LastPolled        time.Time       `json:"last_polled"`
+	LastObservation   time.Time       `json:"last_observation"`
+	SuccessRate       float64         `json:"success_rate"`
+	TotalPolls        int             `json:"total_polls"`
+	SuccessfulPolls   int             `json:"successful_polls"`
+	Lag               *PublicationLag `json:"publication_lag,omitempty"`
+}
+
+func newPollingStateRecord(state *PollingState) pollingStateRecord {
+	record := pollingStateRecord{
+		StationID:         state.StationID,
+		CurrentInterval:   state.CurrentInterval.String(),
+		ConsecutiveMisses: state.ConsecutiveMisses,
+		LastPolled:        state.LastPolled,
+		LastObservation:   state.LastObservation,
+		SuccessRate:       state.SuccessRate,
+		TotalPolls:        state.TotalPolls,
+		SuccessfulPolls:   state.SuccessfulPolls,
+		Lag:               state.Lag,
+	}
+	if state.Cadence > 0 {
+		record.Cadence = state.Cadence.String()
+	}
+	return record
+}
+
+func (r pollingStateRecord) state() (*PollingState, error) {
+	interval, err := time.ParseDuration(r.CurrentInterval)
+	if err != nil {
+		return nil, fmt.Errorf("station %s: invalid interval %q", r.StationID, r.CurrentInterval)
+	}
+	var cadence time.Duration
+	if r.Cadence != "" {
+		if cadence, err = time.ParseDuration(r.Cadence); err != nil {
+			return nil, fmt.Errorf("station %s: invalid cadence %q", r.StationID, r.Cadence)
+		}
+	}
+	return &PollingState{
+		StationID:         r.StationID,
+		CurrentInterval:   interval,
+		Cadence:           cadence,
+		ConsecutiveMisses: r.ConsecutiveMisses,
+		LastPolled:        r.LastPolled,
+		LastObservation:   r.LastObservation,
+		SuccessRate:       r.SuccessRate,
+		TotalPolls:        r.TotalPolls,
+		SuccessfulPolls:   r.SuccessfulPolls,
+		Lag:               r.Lag,
+	}, nil
+}
+
+// migratePollingStateV0 converts the unversioned file, a map of marshaled
+// PollingState values, by turning nanosecond intervals into duration strings
+func migratePollingStateV0(data json.RawMessage) (json.RawMessage, error) {
+	var states map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, err
+	}
+
+	for stationID, fields := range states {
+		if fields == nil {
+			delete(states, stationID)
+			continue
+		}
+		var nanos int64
+		if err := json.Unmarshal(fields["current_interval"], &nanos); err != nil {
+			return nil, fmt.Errorf("station %s: invalid interval: %w", stationID, err)
+		}
+		interval, _ := json.Marshal(time.Duration(nanos).String())
+		fields["current_interval"] = interval
+	}
+
+	return json.Marshal(states)
+}
+
+// encodePollingStates returns the polling state file contents. The caller holds
+// the polling state lock.
+func encodePollingStates(states map[string]*PollingState) ([]byte, error) {
+	records := make(map[string]pollingStateRecord, len(states))
+	for stationID, state := range states {
+		records[stationID] = newPollingStateRecord(state)
+	}
+	return pollingStateSchema.Marshal(records)
+}
+
+// decodePollingStates parses a polling state file of any supported version
+func decodePollingStates(data []byte) (map[string]*PollingState, int, error) {
+	var records map[string]pollingStateRecord
+	version, err := pollingStateSchema.Unmarshal(data, &records)
+	if err != nil {
+		return nil, version, err
+	}
+
+	states := make(map[string]*PollingState, len(records))
+	for stationID, record := range records {
+		state, err := record.state()
+		if err != nil {
+			return nil, version, err
+		}
+		states[stationID] = state
+	}
+	return states, version, nil
+}
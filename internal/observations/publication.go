@@ -0,0 +1,178 @@
+package observations
+
+import (
+	"slices"
+	"time"
+)
+
+// Publication lag learning parameters
+const (
+	lagSampleLimit    = 24               // Lag samples kept per station
+	minLagSamples     = 3                // Samples needed before polls are aligned to publication
+	lagStep           = 15 * time.Second // Offset decrease after a first-try hit, probing for earlier data
+	lateRetry         = time.Minute      // Retry delay while a publication is late
+	publicationMargin = 10 * time.Second // Poll this long after data is expected
+	maxLagSample      = 2 * time.Hour    // Longer lags are backlog after downtime, not publication delay
+	minPollGap        = 30 * time.Second // Never poll a station more often than this
+)
+
+// PublicationLag tracks how long after its timestamp a station's data appears in FMI
+type PublicationLag struct {
+	Recent []float64 `json:"recent"` // Most recent lag samples in seconds, oldest first
+	Offset float64   `json:"offset"` // Learned availability offset in seconds
+	Hits   int       `json:"hits"`   // Aligned polls that found the next observation
+	Misses int       `json:"misses"` // Aligned polls made before the next observation was available
+	Late   bool      `json:"late,omitempty"`
+}
+
+// LagSummary is the publication lag of a station as reported by the status API
+type LagSummary struct {
+	Samples       int     `json:"samples"`
+	MinSeconds    float64 `json:"min_seconds"`
+	MedianSeconds float64 `json:"median_seconds"`
+	P90Seconds    float64 `json:"p90_seconds"`
+	OffsetSeconds float64 `json:"offset_seconds"`
+	Hits          int     `json:"hits"`
+	Misses        int     `json:"misses"`
+	Aligned       bool    `json:"aligned"` // Polls are scheduled from the learned offset
+}
+
+// ready reports whether enough samples have been seen to align polls
+func (l *PublicationLag) ready() bool {
+	return l != nil && len(l.Recent) >= minLagSamples
+}
+
+// Summary returns lag statistics over the recent samples
+func (l *PublicationLag) Summary() *LagSummary {
+	if l == nil || len(l.Recent) == 0 {
+		return nil
+	}
+
+	sorted := slices.Clone(l.Recent)
+	slices.Sort(sorted)
+	return &LagSummary{
+		Samples:       len(sorted),
+		MinSeconds:    sorted[0],
+		MedianSeconds: percentile(sorted, 0.5),
+		P90Seconds:    percentile(sorted, 0.9),
+		OffsetSeconds: l.Offset,
+		Hits:          l.Hits,
+		Misses:        l.Misses,
+		Aligned:       l.ready(),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
+
+// clone returns a copy of the state that shares no lag statistics with the original
+func (state *PollingState) clone() PollingState {
+	copied := *state
+	if state.Lag != nil {
+		lag := *state.Lag
+		lag.Recent = slices.Clone(state.Lag.Recent)
+		copied.Lag = &lag
+	}
+	return copied
+}
+
+// expectedPublication returns when the observation after LastObservation should be available
+func expectedPublication(state *PollingState) time.Time {
+	offset := time.Duration(state.Lag.Offset * float64(time.Second))
+	return state.LastObservation.Add(state.CurrentInterval + offset + publicationMargin)
+}
+
+// publicationAligned reports whether polls for the state follow its learned lag
+func publicationAligned(state *PollingState) bool {
+	return state.Lag.ready() && !state.LastObservation.IsZero() && state.CurrentInterval < IntervalUltraSlow
+}
+
+// inPublicationWindow reports whether at falls within one interval after the expected
+// publication, the period in which a late observation is retried quickly. Polls
+// pulled slightly early by the interval cap or batching still count as aligned.
+func inPublicationWindow(state *PollingState, at time.Time) bool {
+	if !publicationAligned(state) {
+		return false
+	}
+	expected := expectedPublication(state)
+	return !at.Before(expected.Add(-publicationMargin-pollBatchWindow)) && at.Sub(expected) < state.CurrentInterval
+}
+
+// nextPollTime returns when a station should be polled next. For a station with SSE
+// subscribers and a learned publication lag, polls are placed just after the next
+// observation should appear and retried every minute while it is late; otherwise the
+// regular interval, or the background interval without subscribers, applies.
+func nextPollTime(state *PollingState, subscribed bool, background time.Duration) time.Time {
+	base := state.LastPolled.Add(getEffectivePollingInterval(state.CurrentInterval, subscribed, background))
+	if !subscribed || !publicationAligned(state) {
+		return base
+	}
+
+	next := expectedPublication(state)
+	if !next.After(state.LastPolled) {
+		// Polled after the expected publication without finding it: retry while late
+		retry := state.LastPolled.Add(lateRetry)
+		if !inPublicationWindow(state, retry) {
+			return base
+		}
+		next = retry
+	}
+	return maxTime(minTime(next, base), state.LastPolled.Add(minPollGap))
+}
+
+// recordPublicationLag updates the lag statistics for a poll made at now, before the
+// poll's results are applied to state. newest is the latest new observation.
+func recordPublicationLag(state *PollingState, hadData bool, newest time.Time, now time.Time) {
+	lag := state.Lag
+
+	if !hadData {
+		if lag.ready() && inPublicationWindow(state, now) {
+			if !lag.Late {
+				lag.Misses++
+			}
+			lag.Late = true
+		}
+		return
+	}
+
+	sample := now.Sub(newest)
+	if sample <= 0 || sample > maxLagSample {
+		if lag != nil {
+			lag.Late = false
+		}
+		return
+	}
+	if lag == nil {
+		lag = &PublicationLag{}
+		state.Lag = lag
+	}
+
+	wasReady := lag.ready()
+	aligned := inPublicationWindow(state, now)
+	lag.Recent = append(lag.Recent, sample.Seconds())
+	if len(lag.Recent) > lagSampleLimit {
+		lag.Recent = lag.Recent[len(lag.Recent)-lagSampleLimit:]
+	}
+
+	switch {
+	case !wasReady:
+		// Seed from the smallest lag seen while polling on the regular interval
+		lag.Offset = slices.Min(lag.Recent)
+	case lag.Late:
+		// Found by a quick retry: the lag is known to within the retry delay
+		if now.Sub(state.LastPolled) <= 2*lateRetry {
+			lag.Offset = sample.Seconds()
+		}
+	case aligned:
+		// Found on the first try: probe slightly earlier next time
+		lag.Hits++
+		lag.Offset = max(0, lag.Offset-lagStep.Seconds())
+	case sample.Seconds() < lag.Offset:
+		// An early poll already found the data
+		lag.Offset = sample.Seconds()
+	}
+	lag.Late = false
+}
@@ -0,0 +1,247 @@
+package observations
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"windz/internal/stations"
+)
+
+// Quality control flags, stored comma separated in the Quality field of a sample
+const (
+	FlagSpike           = "spike"            // Speed or gust far from both neighbouring samples
+	FlagStuck           = "stuck"            // Identical non-zero speed for a long run of samples
+	FlagGustBelowSpeed  = "gust_below_speed" // Gust lower than the mean speed
+	FlagDirectionFrozen = "direction_frozen" // Direction unchanged while the speed varies
+	FlagNeighbourJump   = "neighbour_jump"   // Sudden change that nearby stations did not see
+)
+
+// Quality control thresholds
+const (
+	qcSpikeDelta        = 8.0              // m/s from the level of both neighbouring samples
+	qcRunSamples        = 6                // Minimum run length for stuck speed and frozen direction
+	qcRunSpan           = time.Hour        // Minimum time covered by such a run, as steady wind repeats one-minute values
+	qcFrozenSpeedRange  = 2.0              // m/s of speed variation under a frozen direction
+	qcGustTolerance     = 0.2              // m/s by which gust may fall below speed (rounding)
+	qcNeighbourRadiusKm = 30.0             // Stations this close are compared
+	qcNeighbourDelta    = 8.0              // m/s jump, and distance from the nearby level, that is implausible
+	qcNeighbourWindow   = 10 * time.Minute // Nearby samples this close in time are comparable
+	qcMaxGap            = 30 * time.Minute // Samples further apart are not neighbours
+)
+
+// unflagged returns the samples that passed quality control
+func unflagged(observations []WindObservation) []WindObservation {
+	if !slices.ContainsFunc(observations, func(obs WindObservation) bool { return obs.Quality != "" }) {
+		return observations
+	}
+	result := make([]WindObservation, 0, len(observations))
+	for _, obs := range observations {
+		if obs.Quality == "" {
+			result = append(result, obs)
+		}
+	}
+	return result
+}
+
+// countFlags returns how many samples carry each flag, nil when none are flagged
+func countFlags(observations []WindObservation) map[string]int {
+	var counts map[string]int
+	for _, obs := range observations {
+		if obs.Quality == "" {
+			continue
+		}
+		if counts == nil {
+			counts = make(map[string]int)
+		}
+		for flag := range strings.SplitSeq(obs.Quality, ",") {
+			counts[flag]++
+		}
+	}
+	return counts
+}
+
+// checkQuality re-evaluates the flags of the samples from index from onwards, and of
+// the earlier ones whose runs or neighbours they extend. It returns the timestamps
+// whose flags changed. Caller holds historyMutex.
+func (m *manager) checkQuality(station stations.Station, h *stationHistory, from int) []time.Time {
+	// Runs are followed back one more span, enough to tell whether they reach qcRunSpan
+	first := h.at(from).Timestamp
+	start := min(h.search(first.Add(-qcRunSpan)), max(0, from-1))
+	base := min(h.search(first.Add(-2*qcRunSpan)), start)
+	samples := make([]WindObservation, h.size-base)
+	for i := range samples {
+		samples[i] = h.at(base + i)
+	}
+	neighbours := m.neighbourHistories(station)
+
+	var changed []time.Time
+	for i := start - base; i < len(samples); i++ {
+		quality := qualityFlags(samples, i, neighbours)
+		if quality != samples[i].Quality {
+			h.setQuality(base+i, quality)
+			changed = append(changed, samples[i].Timestamp)
+		}
+	}
+	return changed
+}
+
+// sampleQuality returns the flags of a station's sample in the in-memory history
+func (m *manager) sampleQuality(stationID string, at time.Time) string {
+	m.historyMutex.RLock()
+	defer m.historyMutex.RUnlock()
+
+	if h, exists := m.history[stationID]; exists {
+		obs, _ := h.find(at)
+		return obs.Quality
+	}
+	return ""
+}
+
+// GetQualityFlags returns how many samples in a station's in-memory history carry each
+// quality flag, nil when none are flagged
+func (m *manager) GetQualityFlags(stationID string) map[string]int {
+	m.historyMutex.RLock()
+	defer m.historyMutex.RUnlock()
+
+	if h, exists := m.history[stationID]; exists && len(h.flags) > 0 {
+		return maps.Clone(h.flags)
+	}
+	return nil
+}
+
+// neighbourHistories returns the histories of monitored stations near the station.
+// Caller holds historyMutex.
+func (m *manager) neighbourHistories(station stations.Station) []*stationHistory {
+	var result []*stationHistory
+	nearby := m.stationMgr.FindNearest(station.Latitude, station.Longitude, stations.NearestOptions{RadiusKm: qcNeighbourRadiusKm})
+	for _, neighbour := range nearby {
+		if neighbour.ID == station.ID {
+			continue
+		}
+		if h, exists := m.history[neighbour.ID]; exists && h.size > 0 {
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+// qualityFlags returns the comma separated flags of sample i
+func qualityFlags(samples []WindObservation, i int, neighbours []*stationHistory) string {
+	obs := samples[i]
+	var flags []string
+
+	prev, hasPrev := adjacentSample(samples, i, -1)
+	next, hasNext := adjacentSample(samples, i, 1)
+	if hasPrev && hasNext && (isSpike(prev.WindSpeed, obs.WindSpeed, next.WindSpeed) || isSpike(prev.WindGust, obs.WindGust, next.WindGust)) {
+		flags = append(flags, FlagSpike)
+	}
+
+	if obs.WindSpeed > 0 && isLongRun(samples, i, func(a, b WindObservation) bool { return a.WindSpeed == b.WindSpeed }) {
+		flags = append(flags, FlagStuck)
+	}
+
+	// A missing gust is reported as zero and is not a fault
+	if obs.WindGust > 0 && obs.WindGust+qcGustTolerance < obs.WindSpeed {
+		flags = append(flags, FlagGustBelowSpeed)
+	}
+
+	if directionFrozen(samples, i) {
+		flags = append(flags, FlagDirectionFrozen)
+	}
+
+	if hasPrev && math.Abs(obs.WindSpeed-prev.WindSpeed) > qcNeighbourDelta {
+		if level, ok := neighbourLevel(neighbours, obs.Timestamp); ok &&
+			math.Abs(obs.WindSpeed-level) > qcNeighbourDelta && math.Abs(prev.WindSpeed-level) <= qcNeighbourDelta/2 {
+			flags = append(flags, FlagNeighbourJump)
+		}
+	}
+
+	return strings.Join(flags, ",")
+}
+
+// adjacentSample returns the sample before (step -1) or after (step 1) sample i
+// when it is close enough in time to compare with
+func adjacentSample(samples []WindObservation, i, step int) (WindObservation, bool) {
+	j := i + step
+	if j < 0 || j >= len(samples) {
+		return WindObservation{}, false
+	}
+	gap := samples[j].Timestamp.Sub(samples[i].Timestamp)
+	if gap.Abs() > qcMaxGap {
+		return WindObservation{}, false
+	}
+	return samples[j], true
+}
+
+// isSpike reports whether value stands out from two neighbouring values that agree
+func isSpike(before, value, after float64) bool {
+	level := (before + after) / 2
+	return math.Abs(value-level) > qcSpikeDelta && math.Abs(before-after) < qcSpikeDelta/2
+}
+
+// runBounds returns the first and last index of the run of consecutive samples
+// around i that are equal to sample i
+func runBounds(samples []WindObservation, i int, equal func(a, b WindObservation) bool) (int, int) {
+	start, end := i, i
+	for start > 0 && equal(samples[start-1], samples[i]) {
+		start--
+	}
+	for end < len(samples)-1 && equal(samples[end+1], samples[i]) {
+		end++
+	}
+	return start, end
+}
+
+// isLongRun reports whether sample i is in a run of equal samples that is long both
+// in samples and in time
+func isLongRun(samples []WindObservation, i int, equal func(a, b WindObservation) bool) bool {
+	start, end := runBounds(samples, i, equal)
+	return end-start+1 >= qcRunSamples && samples[end].Timestamp.Sub(samples[start].Timestamp) >= qcRunSpan
+}
+
+// directionFrozen reports whether sample i is in a long run of one direction over
+// which the wind speed changed, which a working vane would follow
+func directionFrozen(samples []WindObservation, i int) bool {
+	if samples[i].WindSpeed < windRoseCalm {
+		return false
+	}
+
+	sameDirection := func(a, b WindObservation) bool {
+		return a.WindDirection == b.WindDirection && a.WindSpeed >= windRoseCalm
+	}
+	if !isLongRun(samples, i, sameDirection) {
+		return false
+	}
+	start, end := runBounds(samples, i, sameDirection)
+
+	low, high := samples[start].WindSpeed, samples[start].WindSpeed
+	for _, obs := range samples[start : end+1] {
+		low, high = math.Min(low, obs.WindSpeed), math.Max(high, obs.WindSpeed)
+	}
+	return high-low >= qcFrozenSpeedRange
+}
+
+// neighbourLevel returns the median speed of nearby stations around the given time,
+// using each station's closest unflagged sample
+func neighbourLevel(neighbours []*stationHistory, at time.Time) (float64, bool) {
+	var speeds []float64
+	for _, h := range neighbours {
+		closest, found := time.Duration(math.MaxInt64), false
+		var speed float64
+		for _, obs := range h.rangeQuery(at.Add(-qcNeighbourWindow), at.Add(qcNeighbourWindow)) {
+			if d := obs.Timestamp.Sub(at).Abs(); obs.Quality == "" && d < closest {
+				closest, speed, found = d, obs.WindSpeed, true
+			}
+		}
+		if found {
+			speeds = append(speeds, speed)
+		}
+	}
+	if len(speeds) == 0 {
+		return 0, false
+	}
+	slices.Sort(speeds)
+	return percentile(speeds, 0.5), true
+}
@@ -0,0 +1,182 @@
+package observations
+
+import (
+	"container/heap"
+	"maps"
+	"sync"
+	"time"
+)
+
+// pollBatchWindow groups stations whose next poll falls within this window of the
+// earliest due station into one batch, so nearby due times share FMI requests
+const pollBatchWindow = 15 * time.Second
+
+// idleScheduleWait bounds the sleep when no station is scheduled
+const idleScheduleWait = time.Hour
+
+// scheduleItem is a station's next poll time in the schedule heap
+type scheduleItem struct {
+	stationID string
+	due       time.Time
+	index     int
+}
+
+// scheduleHeap is a min-heap of stations ordered by due time
+type scheduleHeap []*scheduleItem
+
+func (h scheduleHeap) Len() int { return len(h) }
+
+func (h scheduleHeap) Less(i, j int) bool {
+	if h[i].due.Equal(h[j].due) {
+		return h[i].stationID < h[j].stationID
+	}
+	return h[i].due.Before(h[j].due)
+}
+
+func (h scheduleHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *scheduleHeap) Push(x any) {
+	item := x.(*scheduleItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *scheduleHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	item.index = -1
+	return item
+}
+
+// pollSchedule tracks the next poll time of every station. The scheduler goroutine
+// sleeps until the earliest due time or until woken by wake().
+type pollSchedule struct {
+	mu     sync.Mutex
+	heap   scheduleHeap
+	items  map[string]*scheduleItem
+	wakeCh chan struct{}
+	resync bool                 // Recompute every due time on the next iteration
+	forced map[string]time.Time // Stations to poll as soon as the time has come
+}
+
+func newPollSchedule() *pollSchedule {
+	return &pollSchedule{
+		items:  make(map[string]*scheduleItem),
+		wakeCh: make(chan struct{}, 1),
+		forced: make(map[string]time.Time),
+	}
+}
+
+// set schedules a station's next poll, replacing any existing entry
+func (s *pollSchedule) set(stationID string, due time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if item, exists := s.items[stationID]; exists {
+		item.due = due
+		heap.Fix(&s.heap, item.index)
+		return
+	}
+	item := &scheduleItem{stationID: stationID, due: due}
+	heap.Push(&s.heap, item)
+	s.items[stationID] = item
+}
+
+// remove drops a station from the schedule
+func (s *pollSchedule) remove(stationID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if item, exists := s.items[stationID]; exists {
+		heap.Remove(&s.heap, item.index)
+		delete(s.items, stationID)
+	}
+}
+
+// next returns the earliest due time
+func (s *pollSchedule) next() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.heap) == 0 {
+		return time.Time{}, false
+	}
+	return s.heap[0].due, true
+}
+
+// popDue removes and returns the stations due at now. When any are due, stations
+// due within pollBatchWindow are included so they can share a batch.
+func (s *pollSchedule) popDue(now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.heap) == 0 || s.heap[0].due.After(now) {
+		return nil
+	}
+
+	cutoff := now.Add(pollBatchWindow)
+	var due []string
+	for len(s.heap) > 0 && !s.heap[0].due.After(cutoff) {
+		item := heap.Pop(&s.heap).(*scheduleItem)
+		delete(s.items, item.stationID)
+		due = append(due, item.stationID)
+	}
+	return due
+}
+
+// stationIDs returns the scheduled stations
+func (s *pollSchedule) stationIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.items))
+	for id := range s.items {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// wake interrupts the scheduler's sleep without blocking
+func (s *pollSchedule) wake() {
+	select {
+	case s.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// requestResync asks the scheduler to recompute every due time
+func (s *pollSchedule) requestResync() {
+	s.mu.Lock()
+	s.resync = true
+	s.mu.Unlock()
+	s.wake()
+}
+
+// requestPoll asks the scheduler to poll a station at the given time, or immediately
+// if it has passed. Repeated requests keep the earliest time.
+func (s *pollSchedule) requestPoll(stationID string, at time.Time) {
+	s.mu.Lock()
+	if current, exists := s.forced[stationID]; !exists || at.Before(current) {
+		s.forced[stationID] = at
+	}
+	s.mu.Unlock()
+	s.wake()
+}
+
+// takeRequests returns and clears the pending resync and poll requests
+func (s *pollSchedule) takeRequests() (bool, map[string]time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resync := s.resync
+	forced := maps.Clone(s.forced)
+	s.resync = false
+	clear(s.forced)
+	return resync, forced
+}
@@ -0,0 +1,156 @@
+package observations
+
+import (
+	"fmt"
+	"time"
+	"windz/internal/stations"
+	"windz/pkg/fmi/observations"
+)
+
+// SourceFMI is the FMI open data source, which feeds stations that do not name a source
+const SourceFMI = "fmi"
+
+// fmiBaseURL is the FMI open data WFS endpoint
+const fmiBaseURL = "https://opendata.fmi.fi/wfs"
+
+// fmiMaxBatch is how many stations one FMI request asks for
+const fmiMaxBatch = 20
+
+// Source fetches wind observations from an upstream provider
+type Source interface {
+	// Fetch returns the observations of the given stations within [start, end] in
+	// time order, keyed by station ID
+	Fetch(stationIDs []string, start, end time.Time) (map[string][]FMIWindObservation, error)
+
+	// Cadence returns the expected observation interval of a station, zero if unknown
+	Cadence(stationID string) time.Duration
+
+	// MaxBatch returns how many stations a single Fetch may ask for
+	MaxBatch() int
+}
+
+// upstream is a configured source with its circuit breaker
+type upstream struct {
+	source  Source
+	breaker *breaker
+}
+
+// fmiSource fetches observations from the FMI open data API
+type fmiSource struct {
+	query *observations.Query
+}
+
+// NewFMISource creates the FMI open data source using the given HTTP client
+func NewFMISource(client observations.HTTPClient) Source {
+	return &fmiSource{query: observations.NewQuery(fmiBaseURL, client)}
+}
+
+// Fetch queries FMI for the stations' wind observations
+func (s *fmiSource) Fetch(stationIDs []string, start, end time.Time) (map[string][]FMIWindObservation, error) {
+	response, err := s.query.Execute(observations.Request{
+		StartTime:  start,
+		EndTime:    end,
+		StationIDs: stationIDs,
+		UseGzip:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to our format
+	results := make(map[string][]FMIWindObservation)
+	for _, station := range response.Stations {
+		stationResults := make([]FMIWindObservation, 0, len(station.Observations))
+
+		for _, obs := range station.Observations {
+			windObs := FMIWindObservation{
+				Timestamp: obs.Timestamp,
+			}
+
+			if obs.WindSpeed != nil {
+				windObs.WindSpeed = *obs.WindSpeed
+			}
+			if obs.WindGust != nil {
+				windObs.WindGust = *obs.WindGust
+			}
+			if obs.WindDirection != nil {
+				windObs.WindDirection = *obs.WindDirection
+			}
+
+			// Only include valid observations
+			if windObs.WindSpeed >= 0 && windObs.WindSpeed < 100 {
+				stationResults = append(stationResults, windObs)
+			}
+		}
+
+		results[station.StationID] = stationResults
+	}
+
+	return results, nil
+}
+
+// Cadence is unknown up front: FMI stations report every minute or every ten minutes
+func (s *fmiSource) Cadence(stationID string) time.Duration {
+	return 0
+}
+
+// MaxBatch returns the number of stations per FMI request
+func (s *fmiSource) MaxBatch() int {
+	return fmiMaxBatch
+}
+
+// sourceName returns the name of the source feeding a station
+func sourceName(station stations.Station) string {
+	if station.Source == "" {
+		return SourceFMI
+	}
+	return station.Source
+}
+
+// stationSource returns the name of the source feeding a station, FMI for unknown stations
+func (m *manager) stationSource(stationID string) string {
+	station, _ := m.stationMgr.GetStation(stationID)
+	return sourceName(station)
+}
+
+// sourceCadence returns the observation interval a station's source expects, zero
+// if unknown
+func (m *manager) sourceCadence(stationID string) time.Duration {
+	if up, exists := m.upstreams[m.stationSource(stationID)]; exists {
+		return max(up.source.Cadence(stationID), 0)
+	}
+	return 0
+}
+
+// batchSize returns how many stations one request to a source may ask for
+func (m *manager) batchSize(source string) int {
+	if up, exists := m.upstreams[source]; exists && up.source.MaxBatch() > 0 {
+		return up.source.MaxBatch()
+	}
+	return 1
+}
+
+// fetchWindDataBatch fetches wind data for stations fed by the same source through
+// the source's circuit breaker
+func (m *manager) fetchWindDataBatch(source string, stationIDs []string, startTime, endTime time.Time) (map[string][]FMIWindObservation, error) {
+	if len(stationIDs) == 0 {
+		return make(map[string][]FMIWindObservation), nil
+	}
+
+	up, exists := m.upstreams[source]
+	if !exists {
+		return nil, fmt.Errorf("unknown observation source %q", source)
+	}
+
+	if !up.breaker.allow() {
+		return nil, ErrUpstreamUnavailable
+	}
+	results, err := up.source.Fetch(stationIDs, startTime, endTime)
+	if err != nil {
+		up.breaker.failure(err)
+		return nil, fmt.Errorf("failed to fetch wind data from %s: %w", source, err)
+	}
+	up.breaker.success()
+
+	return results, nil
+}
@@ -0,0 +1,184 @@
+package observations
+
+import (
+	"math"
+	"time"
+	"windz/internal/sse"
+)
+
+// Trend defaults
+const (
+	DefaultTrendWindow             = 30 * time.Minute
+	DefaultTrendSpeedThreshold     = 2.0  // m/s per hour
+	DefaultTrendDirectionThreshold = 30.0 // Degrees per hour
+
+	trendMinSamples = 3
+)
+
+// Speed trend states
+const (
+	TrendBuilding = "building"
+	TrendDropping = "dropping"
+	TrendSteady   = "steady"
+)
+
+// Direction trend states
+const (
+	TrendVeering = "veering" // Turning clockwise
+	TrendBacking = "backing" // Turning counter-clockwise
+)
+
+// Trend describes how the wind at a station is changing over the trend window
+type Trend struct {
+	Window        string  `json:"window"`
+	Samples       int     `json:"samples"`
+	SpeedRate     float64 `json:"speed_rate"`     // m/s per hour
+	GustRate      float64 `json:"gust_rate"`      // m/s per hour
+	DirectionRate float64 `json:"direction_rate"` // Degrees per hour, positive when veering
+	Speed         string  `json:"speed"`          // building, dropping or steady
+	Direction     string  `json:"direction"`      // veering, backing or steady
+}
+
+// TrendEvent is broadcast as a "trend" SSE event when a station's trend state changes
+type TrendEvent struct {
+	StationID         string    `json:"station_id"`
+	StationName       string    `json:"station_name"`
+	Timestamp         time.Time `json:"timestamp"`
+	Trend             Trend     `json:"trend"`
+	PreviousSpeed     string    `json:"previous_speed"`
+	PreviousDirection string    `json:"previous_direction"`
+}
+
+// trendConfig holds the trend window and classification thresholds
+type trendConfig struct {
+	window             time.Duration
+	speedThreshold     float64
+	directionThreshold float64
+}
+
+// computeTrend fits linear regressions to the time-ordered samples. It returns nil
+// when there are too few samples or they cover too little of the window.
+func computeTrend(observations []WindObservation, cfg trendConfig) *Trend {
+	if len(observations) < trendMinSamples {
+		return nil
+	}
+	first, last := observations[0].Timestamp, observations[len(observations)-1].Timestamp
+	if last.Sub(first) < cfg.window/3 {
+		return nil
+	}
+
+	hours := make([]float64, len(observations))
+	speeds := make([]float64, len(observations))
+	gusts := make([]float64, len(observations))
+	for i, obs := range observations {
+		hours[i] = obs.Timestamp.Sub(first).Hours()
+		speeds[i] = obs.WindSpeed
+		gusts[i] = obs.WindGust
+	}
+
+	trend := &Trend{
+		Window:    formatInterval(cfg.window),
+		Samples:   len(observations),
+		SpeedRate: regressionSlope(hours, speeds),
+		GustRate:  regressionSlope(hours, gusts),
+		Speed:     TrendSteady,
+		Direction: TrendSteady,
+	}
+
+	// Unwrap directions so that 350° -> 10° is +20°, skipping calm samples
+	var dirHours, unwrapped []float64
+	for i, obs := range observations {
+		if obs.WindSpeed < windRoseCalm {
+			continue
+		}
+		if len(unwrapped) == 0 {
+			unwrapped = append(unwrapped, obs.WindDirection)
+		} else {
+			prev := unwrapped[len(unwrapped)-1]
+			delta := math.Mod(obs.WindDirection-prev+540, 360) - 180
+			unwrapped = append(unwrapped, prev+delta)
+		}
+		dirHours = append(dirHours, hours[i])
+	}
+	if len(unwrapped) >= trendMinSamples {
+		trend.DirectionRate = regressionSlope(dirHours, unwrapped)
+	}
+
+	switch {
+	case trend.SpeedRate >= cfg.speedThreshold:
+		trend.Speed = TrendBuilding
+	case trend.SpeedRate <= -cfg.speedThreshold:
+		trend.Speed = TrendDropping
+	}
+	switch {
+	case trend.DirectionRate >= cfg.directionThreshold:
+		trend.Direction = TrendVeering
+	case trend.DirectionRate <= -cfg.directionThreshold:
+		trend.Direction = TrendBacking
+	}
+
+	return trend
+}
+
+// regressionSlope returns the least-squares slope of y over x
+func regressionSlope(x, y []float64) float64 {
+	n := float64(len(x))
+	var sumX, sumY float64
+	for i := range x {
+		sumX += x[i]
+		sumY += y[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var num, den float64
+	for i := range x {
+		num += (x[i] - meanX) * (y[i] - meanY)
+		den += (x[i] - meanX) * (x[i] - meanX)
+	}
+	if den == 0 {
+		return 0
+	}
+	return num / den
+}
+
+// stationTrend computes the trend from the station's history ending at the given time
+func (m *manager) stationTrend(stationID string, at time.Time) *Trend {
+	m.historyMutex.RLock()
+	h, exists := m.history[stationID]
+	var samples []WindObservation
+	if exists {
+		samples = h.rangeQuery(at.Add(-m.trend.window), at)
+	}
+	m.historyMutex.RUnlock()
+
+	return computeTrend(unflagged(samples), m.trend)
+}
+
+// broadcastTrendChange emits a "trend" SSE event when the speed or direction state changed
+func (m *manager) broadcastTrendChange(obs WindObservation, previous *Trend) {
+	if obs.Trend == nil {
+		return
+	}
+
+	prevSpeed, prevDirection := TrendSteady, TrendSteady
+	if previous != nil {
+		prevSpeed, prevDirection = previous.Speed, previous.Direction
+	}
+	if obs.Trend.Speed == prevSpeed && obs.Trend.Direction == prevDirection {
+		return
+	}
+
+	m.sseMgr.Broadcast(sse.Message{
+		ID:        obs.Timestamp.Unix(),
+		Type:      "trend",
+		StationID: obs.StationID,
+		Data: TrendEvent{
+			StationID:         obs.StationID,
+			StationName:       obs.StationName,
+			Timestamp:         obs.Timestamp,
+			Trend:             *obs.Trend,
+			PreviousSpeed:     prevSpeed,
+			PreviousDirection: prevDirection,
+		},
+	})
+}
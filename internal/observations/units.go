@@ -0,0 +1,81 @@
+package observations
+
+import (
+	"net/http"
+	"windz/internal/units"
+)
+
+// InUnits returns a copy of the observation with speeds in the convention's unit and the
+// direction named as a compass point. The default convention returns it unchanged.
+func (o WindObservation) InUnits(c units.Convention) WindObservation {
+	if c.IsDefault() {
+		return o
+	}
+
+	o.WindSpeed = c.Speed(o.WindSpeed)
+	o.WindGust = c.Speed(o.WindGust)
+	o.MaxGust60m = c.Speed(o.MaxGust60m)
+	if o.Trend != nil {
+		trend := o.Trend.inUnits(c)
+		o.Trend = &trend
+	}
+	o.Units = c.Unit
+	if o.WindDirection >= 0 {
+		o.Compass = c.Compass(o.WindDirection)
+	}
+	return o
+}
+
+// ConvertUnits implements units.Converter for SSE data events
+func (o WindObservation) ConvertUnits(c units.Convention) any {
+	return o.InUnits(c)
+}
+
+// inUnits converts the speed and gust rates of a trend
+func (t Trend) inUnits(c units.Convention) Trend {
+	t.SpeedRate = c.Unit.Rate(t.SpeedRate)
+	t.GustRate = c.Unit.Rate(t.GustRate)
+	return t
+}
+
+// ConvertUnits implements units.Converter for SSE trend events
+func (e TrendEvent) ConvertUnits(c units.Convention) any {
+	e.Trend = e.Trend.inUnits(c)
+	return e
+}
+
+// InUnits returns a copy of the aggregate in the convention's unit
+func (a Aggregate) InUnits(c units.Convention) Aggregate {
+	if c.IsDefault() {
+		return a
+	}
+
+	a.MeanSpeed = c.Speed(a.MeanSpeed)
+	a.MinSpeed = c.Speed(a.MinSpeed)
+	a.MaxGust = c.Speed(a.MaxGust)
+	a.MaxGust60m = c.Speed(a.MaxGust60m)
+	a.Units = c.Unit
+	if a.MeanDirection != nil {
+		a.Compass = c.Compass(*a.MeanDirection)
+	}
+	return a
+}
+
+// observationsInUnits converts a list of observations in place
+func observationsInUnits(observations []WindObservation, c units.Convention) []WindObservation {
+	for i := range observations {
+		observations[i] = observations[i].InUnits(c)
+	}
+	return observations
+}
+
+// parseConvention reads the units= and lang= parameters, writing a 400 response when
+// they are invalid
+func parseConvention(w http.ResponseWriter, r *http.Request) (units.Convention, bool) {
+	c, err := units.FromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return units.Convention{}, false
+	}
+	return c, true
+}
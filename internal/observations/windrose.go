@@ -0,0 +1,299 @@
+package observations
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"windz/internal/units"
+)
+
+// Wind rose defaults
+const (
+	DefaultWindRoseSectors = 16
+	windRoseCalm           = 0.5 // m/s; calmer samples have no meaningful direction
+)
+
+// DefaultWindRoseBins are the speed bin edges in m/s
+var DefaultWindRoseBins = []float64{2, 4, 6, 8, 10, 12}
+
+// windRoseBinsByUnit are the default bin edges in the other units, rounded for reading
+var windRoseBinsByUnit = map[units.Unit][]float64{
+	units.Knots:             {4, 8, 12, 16, 20, 24},
+	units.KilometersPerHour: {5, 10, 20, 30, 40, 50},
+	units.MilesPerHour:      {5, 10, 15, 20, 25, 30},
+	units.Beaufort:          {2, 3, 4, 5, 6, 7},
+}
+
+// WindRose is a direction sector × speed bin frequency table
+type WindRose struct {
+	StationID   string      `json:"station_id"`
+	From        time.Time   `json:"from"`
+	To          time.Time   `json:"to"`
+	Sectors     int         `json:"sectors"`
+	SectorWidth float64     `json:"sector_width"` // Degrees; sector i is centred on i*SectorWidth
+	Bins        []SpeedBin  `json:"bins"`
+	Counts      [][]int     `json:"counts"`      // [sector][bin]
+	Frequencies [][]float64 `json:"frequencies"` // Percent of all samples, [sector][bin]
+	Calm        int         `json:"calm"`
+	CalmPercent float64     `json:"calm_percent"`
+	Total       int         `json:"total"`
+	Units       units.Unit  `json:"units,omitempty"` // Unit of the bin edges when converted with units=
+}
+
+// SpeedBin is a speed range [Min, Max); Max is nil for the open-ended top bin
+type SpeedBin struct {
+	Min float64  `json:"min"`
+	Max *float64 `json:"max"`
+}
+
+// parseWindRoseParams reads the sectors and bins query parameters, with bin edges in unit
+func parseWindRoseParams(r *http.Request, unit units.Unit) (int, []float64, error) {
+	query := r.URL.Query()
+
+	sectors := DefaultWindRoseSectors
+	if value := query.Get("sectors"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || (parsed != 4 && parsed != 8 && parsed != 16 && parsed != 32 && parsed != 36) {
+			return 0, nil, fmt.Errorf("invalid sectors: use 4, 8, 16, 32 or 36")
+		}
+		sectors = parsed
+	}
+
+	bins, ok := windRoseBinsByUnit[unit]
+	if !ok {
+		bins = DefaultWindRoseBins
+	}
+	if value := query.Get("bins"); value != "" {
+		bins = nil
+		for _, part := range strings.Split(value, ",") {
+			edge, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil || math.IsNaN(edge) || edge <= 0 {
+				return 0, nil, fmt.Errorf("invalid bins: %q is not a positive speed", part)
+			}
+			if unit == units.Beaufort && (edge != math.Trunc(edge) || edge > 12) {
+				return 0, nil, fmt.Errorf("invalid bins: %q is not a Beaufort force", part)
+			}
+			if len(bins) > 0 && edge <= bins[len(bins)-1] {
+				return 0, nil, fmt.Errorf("invalid bins: edges must be increasing")
+			}
+			bins = append(bins, edge)
+		}
+		if len(bins) > 20 {
+			return 0, nil, fmt.Errorf("invalid bins: at most 20 edges")
+		}
+	}
+
+	return sectors, bins, nil
+}
+
+// computeWindRose counts observations per direction sector and speed bin, leaving
+// out samples flagged by quality control
+func computeWindRose(observations []WindObservation, sectors int, edges []float64) WindRose {
+	observations = unflagged(observations)
+
+	rose := WindRose{
+		Sectors:     sectors,
+		SectorWidth: 360 / float64(sectors),
+		Counts:      make([][]int, sectors),
+		Frequencies: make([][]float64, sectors),
+	}
+
+	rose.Bins = speedBins(edges)
+
+	for i := range rose.Counts {
+		rose.Counts[i] = make([]int, len(rose.Bins))
+		rose.Frequencies[i] = make([]float64, len(rose.Bins))
+	}
+
+	for _, obs := range observations {
+		rose.Total++
+		if obs.WindSpeed < windRoseCalm {
+			rose.Calm++
+			continue
+		}
+
+		direction := math.Mod(obs.WindDirection, 360)
+		if direction < 0 {
+			direction += 360
+		}
+		sector := int((direction+rose.SectorWidth/2)/rose.SectorWidth) % sectors
+
+		bin := len(edges)
+		for i, edge := range edges {
+			if obs.WindSpeed < edge {
+				bin = i
+				break
+			}
+		}
+		rose.Counts[sector][bin]++
+	}
+
+	if rose.Total > 0 {
+		for sector := range rose.Counts {
+			for bin, count := range rose.Counts[sector] {
+				rose.Frequencies[sector][bin] = 100 * float64(count) / float64(rose.Total)
+			}
+		}
+		rose.CalmPercent = 100 * float64(rose.Calm) / float64(rose.Total)
+	}
+
+	return rose
+}
+
+// speedBins returns the bins [0, e0), [e0, e1), ..., [eN, ∞)
+func speedBins(edges []float64) []SpeedBin {
+	var bins []SpeedBin
+	lower := 0.0
+	for i := range edges {
+		bins = append(bins, SpeedBin{Min: lower, Max: &edges[i]})
+		lower = edges[i]
+	}
+	return append(bins, SpeedBin{Min: lower})
+}
+
+// windRoseColors are the speed bin fill colours from light to strong wind
+var windRoseColors = []string{
+	"#c6dbef", "#9ecae1", "#6baed6", "#4292c6", "#2171b5",
+	"#08519c", "#08306b", "#54278f", "#7a0177", "#ae017e",
+}
+
+// renderWindRoseSVG draws the wind rose as stacked sector wedges with a legend, its
+// compass points in lang
+func renderWindRoseSVG(rose WindRose, lang units.Lang) string {
+	const size, center, radius = 440.0, 200.0, 170.0
+
+	// Scale the outer ring to the busiest sector
+	maxPercent := 0.0
+	for _, freqs := range rose.Frequencies {
+		total := 0.0
+		for _, freq := range freqs {
+			total += freq
+		}
+		maxPercent = math.Max(maxPercent, total)
+	}
+	if maxPercent == 0 {
+		maxPercent = 1
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="Arial, sans-serif" font-size="11">`, size, size, size, size)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#fff"/>`)
+
+	// Grid rings labelled with their frequency
+	for i := 1; i <= 4; i++ {
+		r := radius * float64(i) / 4
+		fmt.Fprintf(&b, `<circle cx="%.0f" cy="%.0f" r="%.1f" fill="none" stroke="#ddd"/>`, center, center, r)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" fill="#888">%.1f%%</text>`, center+3, center-r-2, maxPercent*float64(i)/4)
+	}
+	for _, label := range []struct {
+		text string
+		x, y float64
+	}{
+		{units.CompassPoint(0, lang), center, center - radius - 8},
+		{units.CompassPoint(90, lang), center + radius + 10, center + 4},
+		{units.CompassPoint(180, lang), center, center + radius + 16},
+		{units.CompassPoint(270, lang), center - radius - 10, center + 4},
+	} {
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle" font-weight="bold">%s</text>`, label.x, label.y, label.text)
+	}
+
+	// Stacked wedges per sector, innermost bin first
+	for sector, freqs := range rose.Frequencies {
+		mid := float64(sector) * rose.SectorWidth
+		start, end := mid-rose.SectorWidth/2, mid+rose.SectorWidth/2
+		inner := 0.0
+		for bin, freq := range freqs {
+			if freq == 0 {
+				continue
+			}
+			outer := inner + freq
+			fmt.Fprintf(&b, `<path d="%s" fill="%s" stroke="#fff" stroke-width="0.5"/>`,
+				wedgePath(center, center, radius*inner/maxPercent, radius*outer/maxPercent, start, end),
+				windRoseColors[bin%len(windRoseColors)])
+			inner = outer
+		}
+	}
+
+	// Legend
+	symbol := cmp.Or(rose.Units, units.MetersPerSecond).Symbol()
+	for bin, speedBin := range rose.Bins {
+		y := 20 + float64(bin)*16
+		label := fmt.Sprintf("%g+ %s", speedBin.Min, symbol)
+		if speedBin.Max != nil {
+			label = fmt.Sprintf("%g–%g %s", speedBin.Min, *speedBin.Max, symbol)
+		}
+		fmt.Fprintf(&b, `<rect x="375" y="%.0f" width="10" height="10" fill="%s"/>`, y, windRoseColors[bin%len(windRoseColors)])
+		fmt.Fprintf(&b, `<text x="388" y="%.0f" font-size="9">%s</text>`, y+9, label)
+	}
+	fmt.Fprintf(&b, `<text x="8" y="%.0f" fill="#555">%d samples, calm %.1f%%</text>`, size-10, rose.Total, rose.CalmPercent)
+
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// wedgePath returns an SVG path for an annular sector between two compass bearings
+func wedgePath(cx, cy, innerR, outerR, startDeg, endDeg float64) string {
+	point := func(r, deg float64) (float64, float64) {
+		rad := deg * math.Pi / 180
+		return cx + r*math.Sin(rad), cy - r*math.Cos(rad) // Compass: 0° up, clockwise
+	}
+
+	x1, y1 := point(outerR, startDeg)
+	x2, y2 := point(outerR, endDeg)
+	x3, y3 := point(innerR, endDeg)
+	x4, y4 := point(innerR, startDeg)
+
+	return fmt.Sprintf("M%.2f,%.2f A%.2f,%.2f 0 0,1 %.2f,%.2f L%.2f,%.2f A%.2f,%.2f 0 0,0 %.2f,%.2f Z",
+		x1, y1, outerR, outerR, x2, y2, x3, y3, innerR, innerR, x4, y4)
+}
+
+// handleStationWindRose handles the wind rose endpoints in JSON and SVG
+func handleStationWindRose(w http.ResponseWriter, r *http.Request, mgr Manager, stationID string, asSVG bool) {
+	conv, ok := parseConvention(w, r)
+	if !ok {
+		return
+	}
+
+	unit := cmp.Or(conv.Unit, units.MetersPerSecond)
+	sectors, bins, err := parseWindRoseParams(r, unit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseTimeRange(r, 7*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Bin in m/s and label the bins with the requested edges
+	edges := make([]float64, len(bins))
+	for i, edge := range bins {
+		edges[i] = unit.ToMS(edge)
+	}
+	rose := computeWindRose(mgr.GetHistory(stationID, from, to), sectors, edges)
+	rose.StationID, rose.From, rose.To = stationID, from, to
+	if !conv.IsDefault() {
+		rose.Bins = speedBins(bins)
+		rose.Units = conv.Unit
+	}
+
+	if asSVG {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		fmt.Fprint(w, renderWindRoseSVG(rose, cmp.Or(conv.Lang, units.English)))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(rose); err != nil {
+		log.Printf("Error encoding wind rose response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
@@ -2,19 +2,29 @@ package sse
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
+	"windz/internal/clock"
+	"windz/internal/units"
 )
 
+// keepaliveInterval is how often an idle connection gets a keepalive comment
+const keepaliveInterval = 30 * time.Second
+
+// errUnknownGroup is returned by parseSubscription for a group that does not exist
+var errUnknownGroup = errors.New("unknown group")
+
 // RegisterHandlers registers the SSE HTTP handlers
 func RegisterHandlers(mux *http.ServeMux, mgr Manager) {
-	mux.HandleFunc("/events", handleSSE(mgr))
+	mux.HandleFunc("/events", handleSSE(mgr, clock.System))
 }
 
 // handleSSE handles Server-Sent Events connections
-func handleSSE(mgr Manager) http.HandlerFunc {
+func handleSSE(mgr Manager, clk clock.Clock) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Set SSE headers
 		w.Header().Set("Content-Type", "text/event-stream")
@@ -30,8 +40,26 @@ func handleSSE(mgr Manager) http.HandlerFunc {
 			clientID = fmt.Sprintf("%s-%d", r.RemoteAddr, time.Now().UnixNano())
 		}
 
+		// Resolve optional station subscription (?group=id or ?stations=a,b)
+		stationIDs, err := parseSubscription(r, mgr)
+		if err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, errUnknownGroup) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		// Resolve the client's unit convention (?units=kn&lang=fi)
+		conv, err := units.FromQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		// Register client with manager
-		messageChan := mgr.AddClient(clientID)
+		messageChan := mgr.AddFilteredClient(clientID, stationIDs)
 
 		// Ensure cleanup on disconnect
 		defer mgr.RemoveClient(clientID)
@@ -59,7 +87,7 @@ func handleSSE(mgr Manager) http.HandlerFunc {
 		mgr.NotifyClientConnected(clientID)
 
 		// Create a ticker for keepalive messages
-		keepaliveTicker := time.NewTicker(30 * time.Second)
+		keepaliveTicker := clk.NewTicker(keepaliveInterval)
 		defer keepaliveTicker.Stop()
 
 		// Main event loop
@@ -76,14 +104,15 @@ func handleSSE(mgr Manager) http.HandlerFunc {
 					return
 				}
 
-				// Send the message
+				// Send the message in the client's units
+				msg.Data = units.Convert(msg.Data, conv)
 				if err := writeSSEMessage(w, msg); err != nil {
 					log.Printf("Error sending SSE message to %s: %v", clientID, err)
 					return
 				}
 				flusher.Flush()
 
-			case <-keepaliveTicker.C:
+			case <-keepaliveTicker.C():
 				// Send keepalive comment to prevent timeout
 				if _, err := fmt.Fprintf(w, ": keepalive\n\n"); err != nil {
 					log.Printf("Error sending keepalive to %s: %v", clientID, err)
@@ -95,6 +124,39 @@ func handleSSE(mgr Manager) http.HandlerFunc {
 	}
 }
 
+// parseSubscription extracts the stations a client wants to follow from the request.
+// A nil result means the client is subscribed to every station; an explicit filter
+// that selects no stations is an error rather than a subscription to all of them.
+func parseSubscription(r *http.Request, mgr Manager) ([]string, error) {
+	query := r.URL.Query()
+
+	if groupID := query.Get("group"); groupID != "" {
+		stationIDs, ok := mgr.ResolveGroup(groupID)
+		if !ok {
+			return nil, fmt.Errorf("%w %q", errUnknownGroup, groupID)
+		}
+		if len(stationIDs) == 0 {
+			return nil, fmt.Errorf("group %q has no stations", groupID)
+		}
+		return stationIDs, nil
+	}
+
+	if query.Has("stations") {
+		var stationIDs []string
+		for _, stationID := range strings.Split(query.Get("stations"), ",") {
+			if stationID = strings.TrimSpace(stationID); stationID != "" {
+				stationIDs = append(stationIDs, stationID)
+			}
+		}
+		if len(stationIDs) == 0 {
+			return nil, fmt.Errorf("stations must list at least one station ID")
+		}
+		return stationIDs, nil
+	}
+
+	return nil, nil
+}
+
 // writeSSEMessage writes a message in SSE format
 func writeSSEMessage(w http.ResponseWriter, msg Message) error {
 	// Set ID and timestamp if not set
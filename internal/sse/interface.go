@@ -1,10 +1,16 @@
 package sse
 
+import "time"
+
 // Manager defines the interface for SSE client management
 type Manager interface {
 	// AddClient registers a new SSE client and returns a channel for messages
 	AddClient(clientID string) <-chan Message
 
+	// AddFilteredClient registers a new SSE client that receives only messages for the given
+	// stations (nil means all stations) and returns a channel for messages
+	AddFilteredClient(clientID string, stationIDs []string) <-chan Message
+
 	// RemoveClient unregisters an SSE client
 	RemoveClient(clientID string)
 
@@ -14,6 +20,9 @@ type Manager interface {
 	// ClientCount returns the number of connected clients
 	ClientCount() int
 
+	// Subscribers returns the number of connected clients receiving a station's messages
+	Subscribers(stationID string) int
+
 	// Broadcast sends a message to all connected clients
 	Broadcast(message Message)
 
@@ -25,6 +34,15 @@ type Manager interface {
 
 	// SendToClient sends a message to a specific client
 	SendToClient(clientID string, message Message)
+
+	// SetGroupResolver sets the function used to expand a station group into station IDs
+	SetGroupResolver(resolver func(groupID string) ([]string, bool))
+
+	// ResolveGroup expands a station group into station IDs using the configured resolver
+	ResolveGroup(groupID string) ([]string, bool)
+
+	// AddListener registers a function called with every broadcast message; it must not block
+	AddListener(listener func(Message))
 }
 
 // Message represents a Server-Sent Event message
@@ -33,6 +51,7 @@ type Message struct {
 	Type      string      `json:"type"` // Message type (data, status, etc.)
 	StationID string      `json:"station_id,omitempty"`
 	Data      interface{} `json:"data,omitempty"`
+	Timestamp time.Time   `json:"timestamp,omitzero"` // When the message was sent
 }
 
 // @vibe: 🤖 -- ai
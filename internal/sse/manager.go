@@ -9,33 +9,64 @@ import (
 
 // manager implements the SSE Manager interface
 type manager struct {
-	clients         map[string]chan Message
+	clients         map[string]*client
 	mu              sync.RWMutex
 	connectCallback func(clientID string)
+	groupResolver   func(groupID string) ([]string, bool)
+	listeners       []func(Message)
 	callbackMu      sync.RWMutex
 }
 
+// client holds the message channel and station subscription of a connected client
+type client struct {
+	ch       chan Message
+	stations map[string]bool // nil means subscribed to all stations
+}
+
+// wants reports whether the client is subscribed to the message's station
+func (c *client) wants(message Message) bool {
+	if c.stations == nil || message.StationID == "" {
+		return true
+	}
+	return c.stations[message.StationID]
+}
+
 // NewManager creates a new SSE manager instance
 func NewManager() Manager {
 	return &manager{
-		clients: make(map[string]chan Message),
+		clients: make(map[string]*client),
 	}
 }
 
 // AddClient registers a new SSE client and returns a channel for messages
 func (m *manager) AddClient(clientID string) <-chan Message {
+	return m.AddFilteredClient(clientID, nil)
+}
+
+// AddFilteredClient registers a new SSE client that receives only messages for the given
+// stations (nil means all stations). The filter is set before the client becomes visible
+// to broadcasts and subscriber counts.
+func (m *manager) AddFilteredClient(clientID string, stationIDs []string) <-chan Message {
+	var stations map[string]bool
+	if stationIDs != nil {
+		stations = make(map[string]bool, len(stationIDs))
+		for _, stationID := range stationIDs {
+			stations[stationID] = true
+		}
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Remove existing client if present
-	if existingChan, exists := m.clients[clientID]; exists {
-		close(existingChan)
+	if existing, exists := m.clients[clientID]; exists {
+		close(existing.ch)
 		delete(m.clients, clientID)
 	}
 
 	// Create new channel with buffer to prevent blocking
 	clientChan := make(chan Message, 100)
-	m.clients[clientID] = clientChan
+	m.clients[clientID] = &client{ch: clientChan, stations: stations}
 
 	log.Printf("SSE client connected: %s (total: %d)", clientID, len(m.clients))
 
@@ -47,8 +78,8 @@ func (m *manager) RemoveClient(clientID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if clientChan, exists := m.clients[clientID]; exists {
-		close(clientChan)
+	if existing, exists := m.clients[clientID]; exists {
+		close(existing.ch)
 		delete(m.clients, clientID)
 		log.Printf("SSE client disconnected: %s (remaining: %d)", clientID, len(m.clients))
 	}
@@ -70,31 +101,68 @@ func (m *manager) ClientCount() int {
 	return len(m.clients)
 }
 
-// Broadcast sends a message to all connected clients
-func (m *manager) Broadcast(message Message) {
+// Subscribers returns the number of connected clients receiving a station's messages
+func (m *manager) Subscribers(stationID string) int {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	count := 0
+	for _, c := range m.clients {
+		if c.stations == nil || c.stations[stationID] {
+			count++
+		}
+	}
+	return count
+}
+
+// Broadcast sends a message to all connected clients
+func (m *manager) Broadcast(message Message) {
 	if message.ID == 0 {
 		message.ID = time.Now().Unix()
 	}
+	if message.Timestamp.IsZero() {
+		message.Timestamp = time.Now()
+	}
+
+	// Listeners see every message, whether or not clients are connected
+	m.callbackMu.RLock()
+	listeners := m.listeners
+	m.callbackMu.RUnlock()
+	for _, listener := range listeners {
+		listener(message)
+	}
 
-	// Send to all clients
-	for clientID, clientChan := range m.clients {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Send to all subscribed clients
+	recipients := 0
+	for clientID, c := range m.clients {
+		if !c.wants(message) {
+			continue
+		}
 		select {
-		case clientChan <- message:
-			// Message sent successfully
+		case c.ch <- message:
+			recipients++
 		default:
 			// Channel is full, client is slow or disconnected
 			log.Printf("SSE client %s channel full, skipping message", clientID)
 		}
 	}
 
-	if len(m.clients) > 0 {
-		log.Printf("Broadcasted SSE message type=%s to %d clients", message.Type, len(m.clients))
+	if recipients > 0 {
+		log.Printf("Broadcasted SSE message type=%s to %d clients", message.Type, recipients)
 	}
 }
 
+// AddListener registers a function called with every broadcast message
+func (m *manager) AddListener(listener func(Message)) {
+	m.callbackMu.Lock()
+	defer m.callbackMu.Unlock()
+
+	m.listeners = append(m.listeners, listener)
+}
+
 // SetClientConnectCallback sets a callback to be called when a new client connects
 func (m *manager) SetClientConnectCallback(callback func(clientID string)) {
 	m.callbackMu.Lock()
@@ -117,21 +185,27 @@ func (m *manager) NotifyClientConnected(clientID string) {
 // SendToClient sends a message to a specific client
 func (m *manager) SendToClient(clientID string, message Message) {
 	m.mu.RLock()
-	clientChan, exists := m.clients[clientID]
-	m.mu.RUnlock()
+	defer m.mu.RUnlock()
 
+	c, exists := m.clients[clientID]
 	if !exists {
 		log.Printf("SSE client %s not found, cannot send message", clientID)
 		return
 	}
+	if !c.wants(message) {
+		return
+	}
 
 	// Set timestamp and ID if not already set
 	if message.ID == 0 {
 		message.ID = time.Now().Unix()
 	}
+	if message.Timestamp.IsZero() {
+		message.Timestamp = time.Now()
+	}
 
 	select {
-	case clientChan <- message:
+	case c.ch <- message:
 		// Message sent successfully
 	default:
 		// Channel is full, client is slow or disconnected
@@ -139,6 +213,25 @@ func (m *manager) SendToClient(clientID string, message Message) {
 	}
 }
 
+// SetGroupResolver sets the function used to expand a station group into station IDs
+func (m *manager) SetGroupResolver(resolver func(groupID string) ([]string, bool)) {
+	m.callbackMu.Lock()
+	defer m.callbackMu.Unlock()
+	m.groupResolver = resolver
+}
+
+// ResolveGroup expands a station group into station IDs using the configured resolver
+func (m *manager) ResolveGroup(groupID string) ([]string, bool) {
+	m.callbackMu.RLock()
+	resolver := m.groupResolver
+	m.callbackMu.RUnlock()
+
+	if resolver == nil {
+		return nil, false
+	}
+	return resolver(groupID)
+}
+
 // formatSSEMessage formats a message for SSE protocol
 func formatSSEMessage(msg Message) string {
 	// Format SSE message according to protocol
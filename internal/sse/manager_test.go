@@ -1,10 +1,16 @@
 package sse
 
 import (
+	"bufio"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+	"windz/internal/clock"
+	"windz/internal/units"
 )
 
 func TestNewManager(t *testing.T) {
@@ -228,4 +234,205 @@ func TestConcurrentOperations(t *testing.T) {
 	}
 }
 
+func TestSubscribe(t *testing.T) {
+	mgr := NewManager()
+
+	all := mgr.AddClient("all")
+	filtered := mgr.AddFilteredClient("filtered", []string{"station1"})
+
+	mgr.Broadcast(Message{Type: "data", StationID: "station1"})
+	mgr.Broadcast(Message{Type: "data", StationID: "station2"})
+	mgr.Broadcast(Message{Type: "status"}) // No station, delivered to everyone
+
+	if len(all) != 3 {
+		t.Errorf("Unfiltered client should receive 3 messages, got %d", len(all))
+	}
+	if len(filtered) != 2 {
+		t.Errorf("Filtered client should receive 2 messages, got %d", len(filtered))
+	}
+	if msg := <-filtered; msg.StationID != "station1" {
+		t.Errorf("Filtered client received message for %s", msg.StationID)
+	}
+
+	// Targeted messages respect the subscription too
+	mgr.SendToClient("filtered", Message{Type: "data", StationID: "station2"})
+	if len(filtered) != 1 {
+		t.Errorf("SendToClient should skip unsubscribed stations, got %d queued", len(filtered))
+	}
+}
+
+func TestSubscribers(t *testing.T) {
+	mgr := NewManager()
+	if mgr.Subscribers("station1") != 0 {
+		t.Error("Expected no subscribers without clients")
+	}
+
+	mgr.AddClient("all")
+	mgr.AddFilteredClient("filtered", []string{"station1"})
+	if n := mgr.Subscribers("station1"); n != 2 {
+		t.Errorf("Expected 2 subscribers of station1, got %d", n)
+	}
+	if n := mgr.Subscribers("station2"); n != 1 {
+		t.Errorf("Expected 1 subscriber of station2, got %d", n)
+	}
+
+	mgr.RemoveClient("all")
+	if n := mgr.Subscribers("station2"); n != 0 {
+		t.Errorf("Expected no subscribers of station2 after disconnect, got %d", n)
+	}
+}
+
+func TestResolveGroup(t *testing.T) {
+	mgr := NewManager()
+
+	if _, ok := mgr.ResolveGroup("porkkala"); ok {
+		t.Error("Expected no group resolution without a resolver")
+	}
+
+	mgr.SetGroupResolver(func(groupID string) ([]string, bool) {
+		if groupID == "porkkala" {
+			return []string{"101022"}, true
+		}
+		return nil, false
+	})
+
+	stationIDs, ok := mgr.ResolveGroup("porkkala")
+	if !ok || len(stationIDs) != 1 || stationIDs[0] != "101022" {
+		t.Errorf("Unexpected resolution: %v, %v", stationIDs, ok)
+	}
+}
+
+func TestAddListener(t *testing.T) {
+	mgr := NewManager()
+
+	var received []Message
+	mgr.AddListener(func(message Message) {
+		received = append(received, message)
+	})
+
+	// Listeners get messages even without connected clients
+	mgr.Broadcast(Message{Type: "alert", StationID: "101022"})
+
+	if len(received) != 1 || received[0].Type != "alert" {
+		t.Fatalf("Expected listener to receive the message, got %+v", received)
+	}
+	if received[0].ID == 0 || received[0].Timestamp.IsZero() {
+		t.Error("Expected listener message to have ID and timestamp set")
+	}
+}
+
+func TestKeepalive(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	server := httptest.NewServer(handleSSE(NewManager(), clk))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+
+	readUntil := func(prefix string) {
+		t.Helper()
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Stream ended before %q: %v", prefix, err)
+			}
+			if strings.HasPrefix(line, prefix) {
+				return
+			}
+		}
+	}
+	readUntil("event: connected")
+
+	// The keepalive is sent once the fake clock passes the interval
+	clk.BlockUntil(1)
+	clk.Advance(keepaliveInterval)
+	readUntil(": keepalive")
+}
+
+// gust is a message payload that converts itself
+type gust float64
+
+func (g gust) ConvertUnits(c units.Convention) any { return c.Speed(float64(g)) }
+
+func TestUnits(t *testing.T) {
+	mgr := NewManager()
+	server := httptest.NewServer(handleSSE(mgr, clock.NewFake(time.Now())))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?units=kmh")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+
+	readData := func(event string) string {
+		t.Helper()
+		found := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Stream ended before %q: %v", event, err)
+			}
+			if line == "event: "+event+"\n" {
+				found = true
+			} else if found && strings.HasPrefix(line, "data: ") {
+				return strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+			}
+		}
+	}
+	readData("connected")
+
+	// Each client gets the payload in its own units
+	mgr.Broadcast(Message{Type: "data", Data: gust(10)})
+	if data := readData("data"); data != "36" {
+		t.Errorf("Expected the speed in km/h, got %s", data)
+	}
+
+	resp, err = http.Get(server.URL + "?units=furlongs")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown units, got %d", resp.StatusCode)
+	}
+}
+
+func TestSubscriptionErrors(t *testing.T) {
+	mgr := NewManager()
+	mgr.SetGroupResolver(func(groupID string) ([]string, bool) {
+		if groupID == "empty" {
+			return []string{}, true
+		}
+		return nil, false
+	})
+	handler := handleSSE(mgr, clock.NewFake(time.Now()))
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{"stations=,", http.StatusBadRequest},
+		{"stations=%20", http.StatusBadRequest},
+		{"stations=", http.StatusBadRequest},
+		{"group=empty", http.StatusBadRequest},
+		{"group=missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest("GET", "/events?"+tt.query, nil))
+		if rec.Code != tt.expected {
+			t.Errorf("GET /events?%s: expected %d, got %d", tt.query, tt.expected, rec.Code)
+		}
+	}
+	if mgr.ClientCount() != 0 {
+		t.Errorf("Rejected subscriptions should not register clients, got %d", mgr.ClientCount())
+	}
+}
+
 // @vibe: 🤖 -- ai
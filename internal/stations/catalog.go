@@ -0,0 +1,93 @@
+package stations
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	fmistations "windz/pkg/fmi/stations"
+)
+
+const fmiBaseURL = "https://opendata.fmi.fi/wfs"
+
+// LoadCatalog returns the FMI station catalog, using cacheFile when it is younger
+// than maxAge and refreshing it from FMI otherwise. A stale cache is still used if
+// the refresh fails.
+func LoadCatalog(client *http.Client, cacheFile string, maxAge time.Duration) ([]Station, error) {
+	var cached *fmistations.StationCollection
+	if data, err := os.ReadFile(cacheFile); err == nil {
+		var collection fmistations.StationCollection
+		if err := json.Unmarshal(data, &collection); err != nil {
+			log.Printf("Error parsing station catalog cache: %v", err)
+		} else {
+			cached = &collection
+		}
+	}
+
+	if cached != nil && !cached.IsStale(maxAge) {
+		return convertCatalog(cached.Stations), nil
+	}
+
+	query := fmistations.NewQuery(fmiBaseURL, client)
+	response, err := query.Execute(fmistations.Request{
+		BBox:    &fmistations.FinlandBBox,
+		UseGzip: true,
+	})
+	if err != nil {
+		if cached != nil {
+			log.Printf("Error refreshing station catalog, using stale cache: %v", err)
+			return convertCatalog(cached.Stations), nil
+		}
+		return nil, fmt.Errorf("failed to fetch station catalog: %w", err)
+	}
+
+	collection := fmistations.StationCollection{
+		LastUpdated: time.Now(),
+		Stations:    response.Stations,
+	}
+	if data, err := json.MarshalIndent(collection, "", "  "); err == nil {
+		if err := os.WriteFile(cacheFile, data, 0644); err != nil {
+			log.Printf("Error saving station catalog cache: %v", err)
+		}
+	}
+
+	return convertCatalog(collection.Stations), nil
+}
+
+// convertCatalog converts FMI station metadata to our Station model
+func convertCatalog(fmiStations []fmistations.Station) []Station {
+	result := make([]Station, 0, len(fmiStations))
+	for _, s := range fmiStations {
+		id := s.FMISID
+		if id == "" {
+			id = s.ID
+		}
+		region := s.Location.Region
+		if region == "" {
+			region = s.Network
+		}
+		result = append(result, Station{
+			ID:        id,
+			Name:      s.Name,
+			AltNames:  altNames(s),
+			Region:    region,
+			Latitude:  s.Location.Lat,
+			Longitude: s.Location.Lon,
+		})
+	}
+	return result
+}
+
+// altNames returns the localized names of an FMI station other than its primary name
+func altNames(s fmistations.Station) []string {
+	var result []string
+	for _, name := range s.AllNames() {
+		if name != s.Name {
+			result = append(result, name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,208 @@
+package stations
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	earthRadiusKm = 6371.0088
+
+	// Grid cell size of the spatial index in degrees
+	indexCellDeg = 0.5
+)
+
+// Scope selects which station set a spatial query runs against
+type Scope string
+
+const (
+	ScopeMonitored Scope = "monitored" // Stations this instance polls
+	ScopeCatalog   Scope = "catalog"   // All stations discovered from FMI
+)
+
+// NearestOptions controls a nearest-station query
+type NearestOptions struct {
+	Limit    int     // Maximum number of results (0 means no limit)
+	RadiusKm float64 // Maximum distance in kilometres (0 means unbounded)
+	Scope    Scope   // Station set to search (defaults to monitored)
+}
+
+// StationDistance is a station with its great-circle distance and bearing from a point
+type StationDistance struct {
+	Station
+	DistanceKm float64 `json:"distance_km"`
+	BearingDeg float64 `json:"bearing_deg"`
+	Monitored  bool    `json:"monitored"`
+}
+
+// haversineKm returns the great-circle distance between two points in kilometres
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := toRadians(lat1), toRadians(lat2)
+	dPhi := toRadians(lat2 - lat1)
+	dLambda := toRadians(lon2 - lon1)
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// initialBearing returns the initial compass bearing (0-360°) from the first point to the second
+func initialBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := toRadians(lat1), toRadians(lat2)
+	dLambda := toRadians(lon2 - lon1)
+
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	bearing := math.Mod(toDegrees(math.Atan2(y, x))+360, 360)
+	return bearing
+}
+
+func toRadians(deg float64) float64 { return deg * math.Pi / 180 }
+func toDegrees(rad float64) float64 { return rad * 180 / math.Pi }
+
+// cellKey identifies a grid cell of the spatial index
+type cellKey struct {
+	lat, lon int
+}
+
+// spatialIndex is a fixed-grid index over station coordinates
+type spatialIndex struct {
+	cells  map[cellKey][]Station
+	minLat int
+	maxLat int
+	minLon int
+	maxLon int
+	count  int
+}
+
+// newSpatialIndex builds a grid index over the given stations
+func newSpatialIndex(stations []Station) *spatialIndex {
+	idx := &spatialIndex{cells: make(map[cellKey][]Station)}
+	for i, station := range stations {
+		key := cellFor(station.Latitude, station.Longitude)
+		idx.cells[key] = append(idx.cells[key], station)
+
+		if i == 0 || key.lat < idx.minLat {
+			idx.minLat = key.lat
+		}
+		if i == 0 || key.lat > idx.maxLat {
+			idx.maxLat = key.lat
+		}
+		if i == 0 || key.lon < idx.minLon {
+			idx.minLon = key.lon
+		}
+		if i == 0 || key.lon > idx.maxLon {
+			idx.maxLon = key.lon
+		}
+	}
+	idx.count = len(stations)
+	return idx
+}
+
+func cellFor(lat, lon float64) cellKey {
+	return cellKey{
+		lat: int(math.Floor(lat / indexCellDeg)),
+		lon: int(math.Floor(lon / indexCellDeg)),
+	}
+}
+
+// nearest returns stations ordered by distance from the point. Without a radius the
+// search window grows until enough candidates are found or the whole grid is covered.
+func (idx *spatialIndex) nearest(lat, lon float64, limit int, radiusKm float64) []StationDistance {
+	if idx.count == 0 {
+		return []StationDistance{}
+	}
+
+	searchKm := radiusKm
+	if searchKm <= 0 {
+		searchKm = 25
+	}
+
+	for {
+		candidates, coveredAll := idx.within(lat, lon, searchKm)
+
+		// Everything inside searchKm is exact; grow the window if we need more
+		enough := limit > 0 && len(candidates) >= limit
+		if radiusKm <= 0 && !enough && coveredAll {
+			// The window spans the whole grid, so rank every station
+			candidates, _ = idx.within(lat, lon, math.MaxFloat64)
+		}
+		if radiusKm > 0 || enough || coveredAll {
+			sort.Slice(candidates, func(i, j int) bool {
+				return candidates[i].DistanceKm < candidates[j].DistanceKm
+			})
+			if limit > 0 && len(candidates) > limit {
+				candidates = candidates[:limit]
+			}
+			return candidates
+		}
+		searchKm *= 2
+	}
+}
+
+// within returns all stations within radiusKm of the point and whether the scanned
+// window covered the entire grid
+func (idx *spatialIndex) within(lat, lon, radiusKm float64) ([]StationDistance, bool) {
+	lo, hi := cellKey{idx.minLat, idx.minLon}, cellKey{idx.maxLat, idx.maxLon}
+	coveredAll := true
+
+	// Degree span of the radius; longitude degrees shrink towards the poles
+	dLat := radiusKm / 111.32
+	cosLat := math.Cos(toRadians(lat))
+	if dLat < 180 && cosLat > 0.01 {
+		dLon := radiusKm / (111.32 * cosLat)
+		winLo := cellFor(lat-dLat, lon-dLon)
+		winHi := cellFor(lat+dLat, lon+dLon)
+
+		coveredAll = winLo.lat <= idx.minLat && winHi.lat >= idx.maxLat &&
+			winLo.lon <= idx.minLon && winHi.lon >= idx.maxLon
+
+		// Clamp to occupied cells so large windows stay cheap
+		lo.lat, hi.lat = max(winLo.lat, idx.minLat), min(winHi.lat, idx.maxLat)
+		lo.lon, hi.lon = max(winLo.lon, idx.minLon), min(winHi.lon, idx.maxLon)
+	}
+
+	result := []StationDistance{}
+	for cLat := lo.lat; cLat <= hi.lat; cLat++ {
+		for cLon := lo.lon; cLon <= hi.lon; cLon++ {
+			for _, station := range idx.cells[cellKey{cLat, cLon}] {
+				distance := haversineKm(lat, lon, station.Latitude, station.Longitude)
+				if distance > radiusKm {
+					continue
+				}
+				result = append(result, StationDistance{
+					Station:    station,
+					DistanceKm: distance,
+					BearingDeg: initialBearing(lat, lon, station.Latitude, station.Longitude),
+				})
+			}
+		}
+	}
+	return result, coveredAll
+}
+
+// ParseBBox parses a "minLon,minLat,maxLon,maxLat" bounding box string
+func ParseBBox(value string) (BBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return BBox{}, fmt.Errorf("bbox must be minLon,minLat,maxLon,maxLat")
+	}
+
+	var values [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(f) {
+			return BBox{}, fmt.Errorf("invalid bbox value %q", part)
+		}
+		values[i] = f
+	}
+
+	bbox := BBox{MinLon: values[0], MinLat: values[1], MaxLon: values[2], MaxLat: values[3]}
+	if bbox.MinLon > bbox.MaxLon || bbox.MinLat > bbox.MaxLat {
+		return BBox{}, fmt.Errorf("bbox minimum exceeds maximum")
+	}
+	return bbox, nil
+}
@@ -0,0 +1,140 @@
+package stations
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// GetAllGroups returns all configured station groups in configuration order
+func (m *manager) GetAllGroups() []Group {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]Group, len(m.groups))
+	for i, group := range m.groups {
+		result[i] = copyGroup(group)
+	}
+	return result
+}
+
+// GetGroup returns a specific group by ID
+func (m *manager) GetGroup(groupID string) (Group, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	group, exists := m.groupsByID[groupID]
+	if !exists {
+		return Group{}, false
+	}
+	return copyGroup(group), true
+}
+
+// GetGroupStations returns the stations of a group in the group's order
+func (m *manager) GetGroupStations(groupID string) ([]Station, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	group, exists := m.groupsByID[groupID]
+	if !exists {
+		return nil, false
+	}
+
+	result := make([]Station, 0, len(group.StationIDs))
+	for _, stationID := range group.StationIDs {
+		if station, ok := m.stationsByID[stationID]; ok {
+			result = append(result, station)
+		}
+	}
+	return result, true
+}
+
+// LoadGroups replaces the configured groups with the ones defined in a JSON file
+func (m *manager) LoadGroups(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read groups file: %w", err)
+	}
+
+	var groups []Group
+	if err := json.Unmarshal(data, &groups); err != nil {
+		return fmt.Errorf("failed to parse groups file: %w", err)
+	}
+
+	return m.setGroups(groups)
+}
+
+// setGroups validates the groups against known stations and installs them
+func (m *manager) setGroups(groups []Group) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byID := make(map[string]Group, len(groups))
+	for _, group := range groups {
+		if group.ID == "" {
+			return fmt.Errorf("group %q has no id", group.Name)
+		}
+		if _, duplicate := byID[group.ID]; duplicate {
+			return fmt.Errorf("duplicate group id %q", group.ID)
+		}
+		for _, stationID := range group.StationIDs {
+			if _, exists := m.stationsByID[stationID]; !exists {
+				return fmt.Errorf("group %q references unknown station %q", group.ID, stationID)
+			}
+		}
+		if group.Name == "" {
+			group.Name = group.ID
+		}
+		byID[group.ID] = group
+	}
+
+	m.groups = make([]Group, 0, len(groups))
+	for _, group := range groups {
+		m.groups = append(m.groups, byID[group.ID])
+	}
+	m.groupsByID = byID
+	return nil
+}
+
+// loadDefaultGroups loads the built-in group configuration
+func (m *manager) loadDefaultGroups() {
+	defaultGroups := []Group{
+		{
+			ID:          "porkkala",
+			Name:        "Porkkala area",
+			Description: "Key stations around the Porkkala lighthouse and eastern Gulf of Finland",
+			StationIDs:  []string{"101022", "101023", "105392", "151028"},
+			Extent:      &BBox{MinLon: 24.3, MinLat: 59.9, MaxLon: 25.8, MaxLat: 60.3},
+		},
+		{
+			ID:          "maritime",
+			Name:        "Maritime & coastal",
+			Description: "Lighthouse and archipelago stations from Helsinki to Utö",
+			StationIDs:  []string{"100996", "100969", "100965", "100946", "100932", "100945", "100908"},
+			Extent:      &BBox{MinLon: 21.2, MinLat: 59.7, MaxLon: 25.1, MaxLat: 60.2},
+		},
+		{
+			ID:          "northern-coast",
+			Name:        "Northern coast",
+			Description: "Bothnian Sea and Bothnian Bay coastal stations",
+			StationIDs:  []string{"101267", "101661", "101673", "101784", "101794"},
+			Extent:      &BBox{MinLon: 21.2, MinLat: 61.5, MaxLon: 25.6, MaxLat: 65.1},
+		},
+	}
+
+	if err := m.setGroups(defaultGroups); err != nil {
+		panic(fmt.Sprintf("invalid default station groups: %v", err))
+	}
+}
+
+// copyGroup returns a deep copy of a group to prevent external modification
+func copyGroup(group Group) Group {
+	result := group
+	result.StationIDs = make([]string, len(group.StationIDs))
+	copy(result.StationIDs, group.StationIDs)
+	if group.Extent != nil {
+		extent := *group.Extent
+		result.Extent = &extent
+	}
+	return result
+}
@@ -2,8 +2,11 @@ package stations
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -11,6 +14,15 @@ import (
 func RegisterHandlers(mux *http.ServeMux, mgr Manager) {
 	mux.HandleFunc("/api/stations", handleStations(mgr))
 	mux.HandleFunc("/api/stations/", handleStation(mgr))
+	mux.HandleFunc("/api/stations/nearest", handleNearestStations(mgr))
+	mux.HandleFunc("/api/groups", handleGroups(mgr))
+	mux.HandleFunc("/api/groups/", handleGroup(mgr))
+}
+
+// GroupDetail represents a group together with its resolved stations
+type GroupDetail struct {
+	Group
+	Stations []Station `json:"stations"`
 }
 
 // handleStations handles the stations list endpoint
@@ -18,6 +30,12 @@ func handleStations(mgr Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
+		// Name search takes precedence over listing
+		if q := r.URL.Query().Get("q"); q != "" {
+			handleStationSearch(w, r, mgr, q)
+			return
+		}
+
 		// Check for region filter
 		region := r.URL.Query().Get("region")
 
@@ -36,6 +54,37 @@ func handleStations(mgr Manager) http.HandlerFunc {
 	}
 }
 
+// handleStationSearch writes name search results for the stations endpoint
+func handleStationSearch(w http.ResponseWriter, r *http.Request, mgr Manager, q string) {
+	limit := 10
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 100 {
+			http.Error(w, "Invalid limit: must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	scope := ScopeMonitored
+	switch value := Scope(r.URL.Query().Get("scope")); value {
+	case "", ScopeMonitored:
+	case ScopeCatalog:
+		scope = ScopeCatalog
+	default:
+		http.Error(w, "Invalid scope: must be monitored or catalog", http.StatusBadRequest)
+		return
+	}
+
+	results := mgr.Search(q, limit, scope)
+
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		log.Printf("Error encoding station search response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
 // handleStation handles individual station lookup
 func handleStation(mgr Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -61,3 +110,112 @@ func handleStation(mgr Manager) http.HandlerFunc {
 		}
 	}
 }
+
+// handleGroups handles the groups list endpoint
+func handleGroups(mgr Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		groups := mgr.GetAllGroups()
+
+		if err := json.NewEncoder(w).Encode(groups); err != nil {
+			log.Printf("Error encoding groups response: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// handleGroup handles individual group lookup
+func handleGroup(mgr Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Extract group ID from path
+		path := strings.TrimPrefix(r.URL.Path, "/api/groups/")
+		if path == "" {
+			http.Error(w, "Group ID required", http.StatusBadRequest)
+			return
+		}
+
+		group, exists := mgr.GetGroup(path)
+		if !exists {
+			http.Error(w, "Group not found", http.StatusNotFound)
+			return
+		}
+		stations, _ := mgr.GetGroupStations(path)
+
+		if err := json.NewEncoder(w).Encode(GroupDetail{Group: group, Stations: stations}); err != nil {
+			log.Printf("Error encoding group response: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// handleNearestStations handles nearest-station and radius search
+func handleNearestStations(mgr Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		query := r.URL.Query()
+
+		lat, err := parseFloatParam(query.Get("lat"), -90, 90)
+		if err != nil {
+			http.Error(w, "Invalid lat: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		lon, err := parseFloatParam(query.Get("lon"), -180, 180)
+		if err != nil {
+			http.Error(w, "Invalid lon: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		opts := NearestOptions{Limit: 5, Scope: ScopeMonitored}
+		if limit := query.Get("limit"); limit != "" {
+			opts.Limit, err = strconv.Atoi(limit)
+			if err != nil || opts.Limit < 1 || opts.Limit > 100 {
+				http.Error(w, "Invalid limit: must be between 1 and 100", http.StatusBadRequest)
+				return
+			}
+		}
+		if radius := query.Get("radius_km"); radius != "" {
+			opts.RadiusKm, err = parseFloatParam(radius, 0, 5000)
+			if err != nil {
+				http.Error(w, "Invalid radius_km: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		switch scope := Scope(query.Get("scope")); scope {
+		case "", ScopeMonitored:
+		case ScopeCatalog:
+			opts.Scope = ScopeCatalog
+		default:
+			http.Error(w, "Invalid scope: must be monitored or catalog", http.StatusBadRequest)
+			return
+		}
+
+		result := mgr.FindNearest(lat, lon, opts)
+
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			log.Printf("Error encoding nearest stations response: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// parseFloatParam parses a required float query parameter within [min, max]
+func parseFloatParam(value string, min, max float64) (float64, error) {
+	if value == "" {
+		return 0, fmt.Errorf("missing value")
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, fmt.Errorf("not a number")
+	}
+	if f < min || f > max {
+		return 0, fmt.Errorf("must be between %g and %g", min, max)
+	}
+	return f, nil
+}
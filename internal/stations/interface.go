@@ -10,13 +10,62 @@ type Manager interface {
 
 	// GetStationsByRegion returns all stations in a specific region
 	GetStationsByRegion(region string) []Station
+
+	// GetAllGroups returns all configured station groups in configuration order
+	GetAllGroups() []Group
+
+	// GetGroup returns a specific group by ID
+	GetGroup(groupID string) (Group, bool)
+
+	// GetGroupStations returns the stations of a group in the group's order
+	GetGroupStations(groupID string) ([]Station, bool)
+
+	// LoadGroups replaces the configured groups with the ones defined in a JSON file
+	LoadGroups(path string) error
+
+	// FindNearest returns stations ordered by great-circle distance from a point
+	FindNearest(lat, lon float64, opts NearestOptions) []StationDistance
+
+	// Search finds stations by name, ignoring case and diacritics and tolerating typos
+	Search(query string, limit int, scope Scope) []SearchResult
+
+	// SetCatalog installs the FMI-discovered station catalog used for catalog-scope lookups
+	SetCatalog(catalog []Station)
+
+	// AddStation registers an additional monitored station, such as a private station fed
+	// by a non-FMI source
+	AddStation(station Station) error
 }
 
 // Station represents a weather station with its metadata
 type Station struct {
-	ID        string  `json:"id"`
-	Name      string  `json:"name"`
-	Region    string  `json:"region"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	AltNames  []string `json:"alt_names,omitempty"` // Names in other languages (e.g. Swedish)
+	Region    string   `json:"region"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Source    string   `json:"source,omitempty"` // Observation source feeding the station, empty for FMI
+}
+
+// Group represents a named, ordered set of stations such as a racing area or watchlist
+type Group struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	StationIDs  []string `json:"station_ids"`
+	Extent      *BBox    `json:"extent,omitempty"`
+}
+
+// BBox represents a geographic bounding box used as a map extent
+type BBox struct {
+	MinLon float64 `json:"min_lon"`
+	MinLat float64 `json:"min_lat"`
+	MaxLon float64 `json:"max_lon"`
+	MaxLat float64 `json:"max_lat"`
+}
+
+// Contains reports whether the given coordinates are inside the bounding box
+func (b BBox) Contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
 }
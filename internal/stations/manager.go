@@ -1,12 +1,20 @@
 package stations
 
-import "sync"
+import (
+	"fmt"
+	"sync"
+)
 
 // manager implements the Station Manager interface
 type manager struct {
 	stations         []Station
 	stationsByID     map[string]Station
 	stationsByRegion map[string][]Station
+	groups           []Group
+	groupsByID       map[string]Group
+	monitoredIndex   *spatialIndex
+	catalog          []Station
+	catalogIndex     *spatialIndex
 	mu               sync.RWMutex
 }
 
@@ -16,10 +24,12 @@ func NewManager() Manager {
 		stations:         make([]Station, 0),
 		stationsByID:     make(map[string]Station),
 		stationsByRegion: make(map[string][]Station),
+		groupsByID:       make(map[string]Group),
 	}
 
 	// Load default stations from configuration
 	m.loadDefaultStations()
+	m.loadDefaultGroups()
 
 	return m
 }
@@ -64,26 +74,26 @@ func (m *manager) GetStationsByRegion(region string) []Station {
 func (m *manager) loadDefaultStations() {
 	defaultStations := []Station{
 		// Porkkala Area (KEY STATIONS)
-		{ID: "101023", Name: "Emäsalo", Region: "Porvoo", Latitude: 60.2042, Longitude: 25.6258},
+		{ID: "101023", Name: "Emäsalo", AltNames: []string{"Emsalö", "Porvoo Emäsalo"}, Region: "Porvoo", Latitude: 60.2042, Longitude: 25.6258},
 		{ID: "101022", Name: "Kalbådagrund", Region: "Porkkala", Latitude: 59.9747, Longitude: 24.5281},
-		{ID: "105392", Name: "Itätoukki", Region: "Sipoo", Latitude: 60.2653, Longitude: 25.2097},
-		{ID: "151028", Name: "Vuosaari", Region: "Helsinki", Latitude: 60.2075, Longitude: 25.1947},
+		{ID: "105392", Name: "Itätoukki", AltNames: []string{"Sipoo Itätoukki"}, Region: "Sipoo", Latitude: 60.2653, Longitude: 25.2097},
+		{ID: "151028", Name: "Vuosaari", AltNames: []string{"Nordsjö", "Helsinki Vuosaari satama"}, Region: "Helsinki", Latitude: 60.2075, Longitude: 25.1947},
 
 		// Maritime & Coastal
-		{ID: "100996", Name: "Harmaja", Region: "Helsinki Maritime", Latitude: 60.1042, Longitude: 24.9758},
-		{ID: "100969", Name: "Bågaskär", Region: "Inkoo Coastal", Latitude: 59.9025, Longitude: 24.0419},
-		{ID: "100965", Name: "Jussarö", Region: "Raasepori Maritime", Latitude: 59.8133, Longitude: 23.5639},
-		{ID: "100946", Name: "Tulliniemi", Region: "Hanko Coastal", Latitude: 59.8458, Longitude: 22.9028},
-		{ID: "100932", Name: "Russarö", Region: "Hanko Southern", Latitude: 59.7686, Longitude: 22.9533},
-		{ID: "100945", Name: "Vänö", Region: "Kemiönsaari", Latitude: 59.8906, Longitude: 23.2569},
-		{ID: "100908", Name: "Utö", Region: "Archipelago HELCOM", Latitude: 59.7800, Longitude: 21.3719},
+		{ID: "100996", Name: "Harmaja", AltNames: []string{"Gråhara", "Helsinki Harmaja"}, Region: "Helsinki Maritime", Latitude: 60.1042, Longitude: 24.9758},
+		{ID: "100969", Name: "Bågaskär", AltNames: []string{"Inkoo Bågaskär"}, Region: "Inkoo Coastal", Latitude: 59.9025, Longitude: 24.0419},
+		{ID: "100965", Name: "Jussarö", AltNames: []string{"Jussaari", "Raasepori Jussarö"}, Region: "Raasepori Maritime", Latitude: 59.8133, Longitude: 23.5639},
+		{ID: "100946", Name: "Tulliniemi", AltNames: []string{"Tulludden", "Hanko Tulliniemi"}, Region: "Hanko Coastal", Latitude: 59.8458, Longitude: 22.9028},
+		{ID: "100932", Name: "Russarö", AltNames: []string{"Russaari", "Hanko Russarö"}, Region: "Hanko Southern", Latitude: 59.7686, Longitude: 22.9533},
+		{ID: "100945", Name: "Vänö", AltNames: []string{"Kemiönsaari Vänö"}, Region: "Kemiönsaari", Latitude: 59.8906, Longitude: 23.2569},
+		{ID: "100908", Name: "Utö", AltNames: []string{"Parainen Utö"}, Region: "Archipelago HELCOM", Latitude: 59.7800, Longitude: 21.3719},
 
 		// Northern Coastal
-		{ID: "101267", Name: "Tahkoluoto", Region: "Pori", Latitude: 61.6231, Longitude: 21.4081},
-		{ID: "101661", Name: "Tankar", Region: "Kokkola", Latitude: 63.9583, Longitude: 23.2681},
-		{ID: "101673", Name: "Ulkokalla", Region: "Kalajoki", Latitude: 64.3286, Longitude: 23.3442},
-		{ID: "101784", Name: "Marjaniemi", Region: "Hailuoto", Latitude: 65.0361, Longitude: 24.5583},
-		{ID: "101794", Name: "Vihreäsaari", Region: "Oulu", Latitude: 65.0403, Longitude: 25.4244},
+		{ID: "101267", Name: "Tahkoluoto", AltNames: []string{"Pori Tahkoluoto satama"}, Region: "Pori", Latitude: 61.6231, Longitude: 21.4081},
+		{ID: "101661", Name: "Tankar", AltNames: []string{"Tankari", "Kokkola Tankar"}, Region: "Kokkola", Latitude: 63.9583, Longitude: 23.2681},
+		{ID: "101673", Name: "Ulkokalla", AltNames: []string{"Kalajoki Ulkokalla"}, Region: "Kalajoki", Latitude: 64.3286, Longitude: 23.3442},
+		{ID: "101784", Name: "Marjaniemi", AltNames: []string{"Hailuoto Marjaniemi"}, Region: "Hailuoto", Latitude: 65.0361, Longitude: 24.5583},
+		{ID: "101794", Name: "Vihreäsaari", AltNames: []string{"Oulu Vihreäsaari satama"}, Region: "Oulu", Latitude: 65.0403, Longitude: 25.4244},
 	}
 
 	m.mu.Lock()
@@ -99,4 +109,81 @@ func (m *manager) loadDefaultStations() {
 		m.stationsByID[station.ID] = station
 		m.stationsByRegion[station.Region] = append(m.stationsByRegion[station.Region], station)
 	}
+	m.monitoredIndex = newSpatialIndex(defaultStations)
+}
+
+// AddStation registers an additional monitored station
+func (m *manager) AddStation(station Station) error {
+	if station.ID == "" || station.Name == "" {
+		return fmt.Errorf("station needs an ID and a name")
+	}
+	if station.Latitude < -90 || station.Latitude > 90 || station.Longitude < -180 || station.Longitude > 180 {
+		return fmt.Errorf("station %s: invalid coordinates %.4f, %.4f", station.ID, station.Latitude, station.Longitude)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.stationsByID[station.ID]; exists {
+		return fmt.Errorf("station %s already exists", station.ID)
+	}
+
+	// Copy on write: slices handed out under the read lock stay unchanged
+	m.stations = append(m.stations[:len(m.stations):len(m.stations)], station)
+	m.stationsByID[station.ID] = station
+	m.stationsByRegion[station.Region] = append(m.stationsByRegion[station.Region], station)
+	m.monitoredIndex = newSpatialIndex(m.stations)
+	return nil
+}
+
+// FindNearest returns stations ordered by great-circle distance from a point
+func (m *manager) FindNearest(lat, lon float64, opts NearestOptions) []StationDistance {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Catalog queries use the monitored set until the catalog has been loaded
+	idx := m.monitoredIndex
+	if opts.Scope == ScopeCatalog && m.catalogIndex != nil {
+		idx = m.catalogIndex
+	}
+
+	result := idx.nearest(lat, lon, opts.Limit, opts.RadiusKm)
+	for i := range result {
+		_, result[i].Monitored = m.stationsByID[result[i].ID]
+	}
+	return result
+}
+
+// SetCatalog installs the FMI-discovered station catalog used for catalog-scope lookups
+func (m *manager) SetCatalog(catalog []Station) {
+	idx := newSpatialIndex(catalog)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.catalog = catalog
+	m.catalogIndex = idx
+}
+
+// Search finds stations by name, ignoring case and diacritics and tolerating typos
+func (m *manager) Search(query string, limit int, scope Scope) []SearchResult {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	candidates := m.stations
+	if scope == ScopeCatalog && m.catalog != nil {
+		// Prefer monitored entries, which carry curated alternate names
+		candidates = make([]Station, 0, len(m.stations)+len(m.catalog))
+		candidates = append(candidates, m.stations...)
+		for _, station := range m.catalog {
+			if _, monitored := m.stationsByID[station.ID]; !monitored {
+				candidates = append(candidates, station)
+			}
+		}
+	}
+
+	results := searchStations(query, candidates, limit)
+	for i := range results {
+		_, results[i].Monitored = m.stationsByID[results[i].ID]
+	}
+	return results
 }
@@ -1,6 +1,8 @@
 package stations
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -129,3 +131,236 @@ func TestStationCoordinates(t *testing.T) {
 		t.Errorf("Station longitude %f seems outside Finland bounds", station.Longitude)
 	}
 }
+
+func TestGetGroups(t *testing.T) {
+	mgr := NewManager()
+
+	groups := mgr.GetAllGroups()
+	if len(groups) == 0 {
+		t.Fatal("New manager should have default groups loaded")
+	}
+
+	group, exists := mgr.GetGroup("porkkala")
+	if !exists {
+		t.Fatal("Expected group 'porkkala' to exist")
+	}
+	if group.Extent == nil || !group.Extent.Contains(59.9747, 24.5281) {
+		t.Error("Expected Porkkala extent to contain Kalbådagrund")
+	}
+
+	// Stations are returned in the group's order
+	groupStations, exists := mgr.GetGroupStations("porkkala")
+	if !exists {
+		t.Fatal("Expected stations for group 'porkkala'")
+	}
+	if len(groupStations) != len(group.StationIDs) {
+		t.Fatalf("Expected %d stations, got %d", len(group.StationIDs), len(groupStations))
+	}
+	for i, station := range groupStations {
+		if station.ID != group.StationIDs[i] {
+			t.Errorf("Station %d: expected %s, got %s", i, group.StationIDs[i], station.ID)
+		}
+	}
+
+	// Returned groups are copies
+	group.StationIDs[0] = "modified"
+	groupAgain, _ := mgr.GetGroup("porkkala")
+	if groupAgain.StationIDs[0] == "modified" {
+		t.Error("GetGroup should return copies, not references")
+	}
+
+	if _, exists := mgr.GetGroup("nonexistent"); exists {
+		t.Error("Expected non-existing group to not exist")
+	}
+}
+
+func TestLoadGroups(t *testing.T) {
+	mgr := NewManager()
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "groups.json")
+	if err := os.WriteFile(valid, []byte(`[
+		{"id": "racing", "name": "Our racing area", "station_ids": ["100996", "101022"]}
+	]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mgr.LoadGroups(valid); err != nil {
+		t.Fatalf("LoadGroups failed: %v", err)
+	}
+	groups := mgr.GetAllGroups()
+	if len(groups) != 1 || groups[0].ID != "racing" {
+		t.Fatalf("Expected only the 'racing' group, got %+v", groups)
+	}
+
+	unknown := filepath.Join(dir, "unknown.json")
+	if err := os.WriteFile(unknown, []byte(`[{"id": "x", "station_ids": ["999999"]}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.LoadGroups(unknown); err == nil {
+		t.Error("Expected error for group with unknown station")
+	}
+
+	// A failed load keeps the previous groups
+	if _, exists := mgr.GetGroup("racing"); !exists {
+		t.Error("Expected previous groups to remain after failed load")
+	}
+}
+
+func TestAddStation(t *testing.T) {
+	mgr := NewManager()
+	before := mgr.GetAllStations()
+
+	pier := Station{ID: "pier", Name: "Club pier", Region: "Helsinki", Latitude: 60.1530, Longitude: 24.9650, Source: "ingest"}
+	if err := mgr.AddStation(pier); err != nil {
+		t.Fatalf("AddStation failed: %v", err)
+	}
+	if station, exists := mgr.GetStation("pier"); !exists || station.Source != "ingest" {
+		t.Errorf("Expected added station with its source, got %+v", station)
+	}
+	if got := mgr.GetAllStations(); len(got) != len(before)+1 || len(before) != 16 {
+		t.Errorf("Expected %d stations after adding, got %d (copy had %d)", len(before)+1, len(got), len(before))
+	}
+	if got := mgr.GetStationsByRegion("Helsinki"); len(got) != 2 {
+		t.Errorf("Expected the station in its region, got %+v", got)
+	}
+	if nearest := mgr.FindNearest(60.1530, 24.9650, NearestOptions{Limit: 1}); len(nearest) != 1 || nearest[0].ID != "pier" || !nearest[0].Monitored {
+		t.Errorf("Expected the added station nearest to itself, got %+v", nearest)
+	}
+	if results := mgr.Search("club pier", 1, ScopeMonitored); len(results) != 1 || results[0].ID != "pier" {
+		t.Errorf("Expected the added station to be searchable, got %+v", results)
+	}
+
+	for name, station := range map[string]Station{
+		"duplicate":   {ID: "100996", Name: "Harmaja again"},
+		"no name":     {ID: "other"},
+		"bad lat/lon": {ID: "other", Name: "Other", Latitude: 95},
+	} {
+		if err := mgr.AddStation(station); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestFindNearest(t *testing.T) {
+	mgr := NewManager()
+
+	// Porkkala harbour: Kalbådagrund is the closest monitored station
+	result := mgr.FindNearest(60.0, 24.42, NearestOptions{Limit: 3})
+	if len(result) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(result))
+	}
+	if result[0].ID != "101022" {
+		t.Errorf("Expected Kalbådagrund (101022) nearest, got %s", result[0].ID)
+	}
+	for i := 1; i < len(result); i++ {
+		if result[i].DistanceKm < result[i-1].DistanceKm {
+			t.Error("Results should be ordered by distance")
+		}
+	}
+	if !result[0].Monitored {
+		t.Error("Monitored scope results should be marked monitored")
+	}
+
+	// Kalbådagrund lies to the east-southeast of the point
+	if result[0].BearingDeg < 90 || result[0].BearingDeg > 135 {
+		t.Errorf("Unexpected bearing %.1f°", result[0].BearingDeg)
+	}
+
+	// Radius filter
+	within := mgr.FindNearest(60.1042, 24.9758, NearestOptions{RadiusKm: 20})
+	for _, station := range within {
+		if station.DistanceKm > 20 {
+			t.Errorf("Station %s at %.1f km outside radius", station.ID, station.DistanceKm)
+		}
+	}
+	if len(within) == 0 || within[0].ID != "100996" || within[0].DistanceKm > 0.01 {
+		t.Error("Expected Harmaja at distance zero")
+	}
+
+	// Unbounded query with a far away point still finds every station
+	all := mgr.FindNearest(69.0, 27.0, NearestOptions{})
+	if len(all) != 16 {
+		t.Errorf("Expected all 16 stations, got %d", len(all))
+	}
+}
+
+func TestFindNearestCatalog(t *testing.T) {
+	mgr := NewManager()
+	mgr.SetCatalog([]Station{
+		{ID: "100996", Name: "Helsinki Harmaja", Latitude: 60.1051, Longitude: 24.9754},
+		{ID: "100971", Name: "Helsinki Kaisaniemi", Latitude: 60.1752, Longitude: 24.9446},
+	})
+
+	result := mgr.FindNearest(60.17, 24.94, NearestOptions{Limit: 2, Scope: ScopeCatalog})
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(result))
+	}
+	if result[0].ID != "100971" || result[0].Monitored {
+		t.Errorf("Expected unmonitored Kaisaniemi first, got %+v", result[0])
+	}
+	if !result[1].Monitored {
+		t.Error("Harmaja should be marked monitored")
+	}
+}
+
+func TestHaversine(t *testing.T) {
+	// Helsinki to Tallinn is roughly 80 km
+	distance := haversineKm(60.1699, 24.9384, 59.4370, 24.7536)
+	if distance < 80 || distance > 83 {
+		t.Errorf("Unexpected Helsinki-Tallinn distance %.1f km", distance)
+	}
+
+	if bearing := initialBearing(60, 25, 61, 25); bearing > 0.001 && bearing < 359.999 {
+		t.Errorf("Expected due north bearing, got %.3f", bearing)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	mgr := NewManager()
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"bagaskar", "100969"},    // Diacritics folded
+		{"Russaro", "100932"},     // Case and diacritics
+		{"EMÄSALO", "101023"},     // Upper case
+		{"emsalö", "101023"},      // Swedish name
+		{"Gråhara", "100996"},     // Swedish name of Harmaja
+		{"harm", "100996"},        // Prefix
+		{"vuosari", "151028"},     // Typo
+		{"kalbadagrnd", "101022"}, // Missing letter
+		{"tahkolouto", "101267"},  // Transposition
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results := mgr.Search(tt.query, 5, ScopeMonitored)
+			if len(results) == 0 {
+				t.Fatalf("No results for %q", tt.query)
+			}
+			if results[0].ID != tt.expected {
+				t.Errorf("Search(%q) best match %s (%s), expected %s", tt.query, results[0].ID, results[0].MatchedName, tt.expected)
+			}
+		})
+	}
+
+	if results := mgr.Search("xyzzy", 5, ScopeMonitored); len(results) != 0 {
+		t.Errorf("Expected no results for nonsense query, got %d", len(results))
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	if got := normalizeName("  Inkoo  Bågaskär! "); got != "inkoo bagaskar" {
+		t.Errorf("normalizeName() = %q", got)
+	}
+	if got := editDistance("russaro", "rusaro"); got != 1 {
+		t.Errorf("editDistance() = %d, expected 1", got)
+	}
+
+	// Word prefixes are cut by runes, not bytes, for letters that are not folded
+	if got := matchScore("ceavet", "čeavetjavri"); got != scoreFuzzy-10 {
+		t.Errorf("matchScore() = %d, expected %d", got, scoreFuzzy-10)
+	}
+}
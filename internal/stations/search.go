@@ -0,0 +1,170 @@
+package stations
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SearchResult is a station matched by a name search
+type SearchResult struct {
+	Station
+	MatchedName string `json:"matched_name"`
+	Score       int    `json:"score"`
+	Monitored   bool   `json:"monitored"`
+}
+
+// Match scores, highest first
+const (
+	scoreExact      = 100
+	scorePrefix     = 90
+	scoreWordPrefix = 80
+	scoreSubstring  = 60
+	scoreFuzzy      = 50 // Minus 10 per edit
+)
+
+// foldReplacements maps letters with diacritics to their plain ASCII form
+var foldReplacements = map[rune]string{
+	'ä': "a", 'å': "a", 'á': "a", 'à': "a", 'â': "a", 'ã': "a",
+	'ö': "o", 'ø': "o", 'ó': "o", 'ò': "o", 'ô': "o", 'õ': "o",
+	'é': "e", 'è': "e", 'ê': "e", 'ë': "e",
+	'í': "i", 'ì': "i", 'î': "i", 'ï': "i",
+	'ú': "u", 'ù': "u", 'û': "u", 'ü': "u",
+	'ý': "y", 'ÿ': "y",
+	'æ': "ae", 'ß': "ss", 'ñ': "n", 'ç': "c", 'š': "s", 'ž': "z",
+}
+
+// normalizeName lowercases a name, folds diacritics and collapses punctuation to single spaces
+func normalizeName(name string) string {
+	var b strings.Builder
+	space := false
+
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case foldReplacements[r] != "":
+			b.WriteString(foldReplacements[r])
+			space = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			space = false
+		default:
+			if !space && b.Len() > 0 {
+				b.WriteByte(' ')
+				space = true
+			}
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// matchScore scores how well a normalized query matches a normalized name (0 means no match)
+func matchScore(query, name string) int {
+	switch {
+	case name == query:
+		return scoreExact
+	case strings.HasPrefix(name, query):
+		return scorePrefix
+	}
+
+	words := strings.Fields(name)
+	for _, word := range words {
+		if strings.HasPrefix(word, query) {
+			return scoreWordPrefix
+		}
+	}
+
+	if strings.Contains(name, query) {
+		return scoreSubstring
+	}
+
+	// Fuzzy: compare against whole words and word prefixes of the query's length in
+	// runes, so typos in partially typed names still match
+	length := utf8.RuneCountInString(query)
+	allowed := 1
+	if length >= 7 {
+		allowed = 2
+	}
+	if length < 3 {
+		return 0
+	}
+
+	best := allowed + 1
+	for _, word := range append(words, name) {
+		candidates := []string{word}
+		if runes := []rune(word); len(runes) > length {
+			candidates = append(candidates, string(runes[:length]))
+		}
+		for _, candidate := range candidates {
+			if d := editDistance(query, candidate); d < best {
+				best = d
+			}
+		}
+	}
+	if best <= allowed {
+		return scoreFuzzy - 10*best
+	}
+	return 0
+}
+
+// editDistance returns the optimal string alignment (Damerau-Levenshtein) distance
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
+
+// searchStations scores every station name and alternate name against the query
+func searchStations(query string, candidates []Station, limit int) []SearchResult {
+	normalized := normalizeName(query)
+	if normalized == "" {
+		return []SearchResult{}
+	}
+
+	results := []SearchResult{}
+	for _, station := range candidates {
+		best := SearchResult{Station: station}
+		for _, name := range append([]string{station.Name}, station.AltNames...) {
+			if score := matchScore(normalized, normalizeName(name)); score > best.Score {
+				best.Score = score
+				best.MatchedName = name
+			}
+		}
+		if best.Score > 0 {
+			results = append(results, best)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Name < results[j].Name
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,52 @@
+package store
+
+import "time"
+
+// Store defines the interface for the append-only observation time-series store
+type Store interface {
+	// Append durably writes records; a record replaces any earlier one with the same station and timestamp
+	Append(records ...Record) error
+
+	// Range returns a station's records with from <= timestamp <= to in time order
+	Range(stationID string, from, to time.Time) ([]Record, error)
+
+	// Stations returns the IDs of all stations with stored records
+	Stations() []string
+
+	// Compact rewrites sealed segments, dropping records older than before (unless zero) and superseded duplicates
+	Compact(before time.Time) error
+
+	// Stats returns store size and recovery statistics
+	Stats() Stats
+
+	// Close flushes and closes all segment files
+	Close() error
+}
+
+// Record represents a single stored observation
+type Record struct {
+	StationID     string    `json:"station_id"`
+	Timestamp     time.Time `json:"timestamp"`
+	WindSpeed     float64   `json:"wind_speed"`
+	WindGust      float64   `json:"wind_gust"`
+	WindDirection float64   `json:"wind_direction"`
+	Quality       string    `json:"quality,omitempty"`
+}
+
+// Options configures a store
+type Options struct {
+	SegmentSize int64         // Active segment is sealed once it grows past this size (bytes)
+	SyncWrites  bool          // fsync after every Append
+	Retention   time.Duration // Records older than this are left out of the index on open (0 keeps everything)
+}
+
+// Stats describes the store contents and what recovery found on open
+type Stats struct {
+	Segments       int       `json:"segments"`
+	Records        int       `json:"records"`
+	Stations       int       `json:"stations"`
+	Bytes          int64     `json:"bytes"`
+	CorruptSkipped int       `json:"corrupt_skipped"` // Damaged records skipped during recovery
+	TruncatedBytes int64     `json:"truncated_bytes"` // Torn tail removed from the last segment
+	LastCompacted  time.Time `json:"last_compacted,omitempty"`
+}
@@ -0,0 +1,185 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"time"
+)
+
+// On-disk record layout (little endian):
+//
+//	magic   uint16  recordMagic, lets recovery resynchronise after damage
+//	length  uint32  payload length
+//	crc     uint32  CRC-32 (Castagnoli) of the payload
+//	payload:
+//	  version   uint8
+//	  stationID uint8 length + bytes
+//	  timestamp int64 Unix nanoseconds
+//	  speed, gust, direction float64 bits
+//	  quality   uint16 length + bytes
+const (
+	recordMagic   uint16 = 0x575A // "WZ"
+	recordVersion uint8  = 1
+	headerSize           = 2 + 4 + 4
+	maxPayload           = 64 * 1024
+)
+
+var (
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+	errCorrupt   = errors.New("corrupt record")
+	errTruncated = errors.New("truncated record")
+)
+
+// encodeRecord serialises a record including its header
+func encodeRecord(rec Record) ([]byte, error) {
+	if len(rec.StationID) == 0 || len(rec.StationID) > math.MaxUint8 {
+		return nil, fmt.Errorf("invalid station id length %d", len(rec.StationID))
+	}
+	if len(rec.Quality) > math.MaxUint16 {
+		return nil, fmt.Errorf("quality too long")
+	}
+
+	payload := make([]byte, 0, 1+1+len(rec.StationID)+8*4+2+len(rec.Quality))
+	payload = append(payload, recordVersion, uint8(len(rec.StationID)))
+	payload = append(payload, rec.StationID...)
+	payload = binary.LittleEndian.AppendUint64(payload, uint64(rec.Timestamp.UnixNano()))
+	payload = binary.LittleEndian.AppendUint64(payload, math.Float64bits(rec.WindSpeed))
+	payload = binary.LittleEndian.AppendUint64(payload, math.Float64bits(rec.WindGust))
+	payload = binary.LittleEndian.AppendUint64(payload, math.Float64bits(rec.WindDirection))
+	payload = binary.LittleEndian.AppendUint16(payload, uint16(len(rec.Quality)))
+	payload = append(payload, rec.Quality...)
+
+	buf := make([]byte, headerSize, headerSize+len(payload))
+	binary.LittleEndian.PutUint16(buf[0:2], recordMagic)
+	binary.LittleEndian.PutUint32(buf[2:6], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[6:10], crc32.Checksum(payload, crcTable))
+	return append(buf, payload...), nil
+}
+
+// decodeRecord parses the record at the start of data and returns it with its encoded size
+func decodeRecord(data []byte) (Record, int, error) {
+	if len(data) < headerSize {
+		return Record{}, 0, errTruncated
+	}
+	if binary.LittleEndian.Uint16(data[0:2]) != recordMagic {
+		return Record{}, 0, errCorrupt
+	}
+
+	length := int(binary.LittleEndian.Uint32(data[2:6]))
+	if length > maxPayload {
+		return Record{}, 0, errCorrupt
+	}
+	if len(data) < headerSize+length {
+		return Record{}, 0, errTruncated
+	}
+
+	payload := data[headerSize : headerSize+length]
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(data[6:10]) {
+		return Record{}, 0, errCorrupt
+	}
+
+	rec, err := decodePayload(payload)
+	if err != nil {
+		return Record{}, 0, err
+	}
+	return rec, headerSize + length, nil
+}
+
+func decodePayload(payload []byte) (Record, error) {
+	r := bytes.NewReader(payload)
+
+	var version, idLen uint8
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil || version != recordVersion {
+		return Record{}, errCorrupt
+	}
+	if err := binary.Read(r, binary.LittleEndian, &idLen); err != nil {
+		return Record{}, errCorrupt
+	}
+	id := make([]byte, idLen)
+	if _, err := io.ReadFull(r, id); err != nil {
+		return Record{}, errCorrupt
+	}
+
+	var fixed struct {
+		Timestamp int64
+		Speed     uint64
+		Gust      uint64
+		Direction uint64
+		QualLen   uint16
+	}
+	if err := binary.Read(r, binary.LittleEndian, &fixed); err != nil {
+		return Record{}, errCorrupt
+	}
+	quality := make([]byte, fixed.QualLen)
+	if _, err := io.ReadFull(r, quality); err != nil {
+		return Record{}, errCorrupt
+	}
+
+	return Record{
+		StationID:     string(id),
+		Timestamp:     time.Unix(0, fixed.Timestamp),
+		WindSpeed:     math.Float64frombits(fixed.Speed),
+		WindGust:      math.Float64frombits(fixed.Gust),
+		WindDirection: math.Float64frombits(fixed.Direction),
+		Quality:       string(quality),
+	}, nil
+}
+
+// scannedRecord is a record found while scanning a segment
+type scannedRecord struct {
+	rec    Record
+	offset int64
+	size   int32
+}
+
+// scanResult summarises a segment scan
+type scanResult struct {
+	records  []scannedRecord
+	corrupt  int   // Damaged regions skipped
+	validEnd int64 // Offset just past the last valid record
+	tailBad  bool  // The segment ends in a damaged region (e.g. a torn write)
+}
+
+// scanSegment decodes every valid record in a segment, skipping damaged regions by
+// searching for the next record header with a valid checksum
+func scanSegment(data []byte) scanResult {
+	var result scanResult
+	offset := 0
+	inDamage := false
+
+	for offset < len(data) {
+		rec, size, err := decodeRecord(data[offset:])
+		if err == nil {
+			result.records = append(result.records, scannedRecord{rec: rec, offset: int64(offset), size: int32(size)})
+			offset += size
+			result.validEnd = int64(offset)
+			inDamage = false
+			continue
+		}
+
+		if !inDamage {
+			result.corrupt++
+			inDamage = true
+		}
+
+		// Resynchronise on the next magic marker
+		next := bytes.Index(data[offset+1:], magicBytes())
+		if next < 0 {
+			break
+		}
+		offset += next + 1
+	}
+
+	result.tailBad = inDamage
+	return result
+}
+
+func magicBytes() []byte {
+	return binary.LittleEndian.AppendUint16(nil, recordMagic)
+}
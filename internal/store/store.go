@@ -0,0 +1,498 @@
+package store
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSegmentSize is the size at which the active segment is sealed
+const DefaultSegmentSize = 8 * 1024 * 1024
+
+const segmentExt = ".seg"
+
+// indexEntry locates a record on disk
+type indexEntry struct {
+	timestamp int64 // Unix nanoseconds
+	segment   uint32
+	offset    int64
+	size      int32
+}
+
+// segment is one append-only file of the store
+type segment struct {
+	id   uint32
+	file *os.File
+	size int64
+	live int // Records referenced by the index
+	dead int // Records superseded or dropped
+}
+
+// fileStore implements the Store interface on a directory of segment files
+type fileStore struct {
+	dir  string
+	opts Options
+
+	mu       sync.RWMutex
+	segments map[uint32]*segment
+	active   *segment
+	index    map[string][]indexEntry // Per station, sorted by timestamp
+
+	corruptSkipped int
+	truncatedBytes int64
+	lastCompacted  time.Time
+}
+
+// Open opens (or creates) a store in dir, recovering every segment it finds.
+// Damaged records are skipped and a torn tail on the newest segment is truncated.
+func Open(dir string, opts Options) (Store, error) {
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = DefaultSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	s := &fileStore{
+		dir:      dir,
+		opts:     opts,
+		segments: make(map[uint32]*segment),
+		index:    make(map[string][]indexEntry),
+	}
+
+	if err := s.recover(); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	if s.active == nil {
+		if err := s.rotate(); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// recover loads all segments in order and rebuilds the index. Records past the
+// retention, which compaction leaves in the active segment, are counted as dead.
+func (s *fileStore) recover() error {
+	ids, err := s.segmentIDs()
+	if err != nil {
+		return err
+	}
+
+	var cutoff int64
+	if s.opts.Retention > 0 {
+		cutoff = time.Now().Add(-s.opts.Retention).UnixNano()
+	}
+
+	for i, id := range ids {
+		path := s.segmentPath(id)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read segment %s: %w", path, err)
+		}
+
+		scan := scanSegment(data)
+		last := i == len(ids)-1
+
+		if last && scan.tailBad {
+			// A torn write at the end of the newest segment: drop it
+			scan.corrupt--
+			s.truncatedBytes += int64(len(data)) - scan.validEnd
+			if err := os.Truncate(path, scan.validEnd); err != nil {
+				return fmt.Errorf("failed to truncate segment %s: %w", path, err)
+			}
+			data = data[:scan.validEnd]
+		}
+		if scan.corrupt > 0 {
+			log.Printf("Store segment %s: skipped %d damaged regions", filepath.Base(path), scan.corrupt)
+		}
+		s.corruptSkipped += scan.corrupt
+
+		file, err := os.OpenFile(path, os.O_RDWR, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open segment %s: %w", path, err)
+		}
+		seg := &segment{id: id, file: file, size: int64(len(data))}
+		s.segments[id] = seg
+
+		for _, scanned := range scan.records {
+			if cutoff != 0 && scanned.rec.Timestamp.UnixNano() < cutoff {
+				seg.dead++
+				continue
+			}
+			s.indexRecord(scanned.rec, seg, scanned.offset, scanned.size)
+		}
+
+		if last {
+			s.active = seg
+		}
+	}
+
+	if s.active != nil && s.active.size >= s.opts.SegmentSize {
+		return s.rotate()
+	}
+	return nil
+}
+
+// segmentIDs lists segment IDs in the store directory in ascending order
+func (s *fileStore) segmentIDs() ([]uint32, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list store directory: %w", err)
+	}
+
+	var ids []uint32
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		var id uint32
+		if _, err := fmt.Sscanf(name, "%08d"+segmentExt, &id); err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (s *fileStore) segmentPath(id uint32) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%08d%s", id, segmentExt))
+}
+
+// indexRecord adds a record to the index, superseding an entry with the same timestamp
+func (s *fileStore) indexRecord(rec Record, seg *segment, offset int64, size int32) {
+	entry := indexEntry{timestamp: rec.Timestamp.UnixNano(), segment: seg.id, offset: offset, size: size}
+	entries := s.index[rec.StationID]
+
+	pos := sort.Search(len(entries), func(i int) bool { return entries[i].timestamp >= entry.timestamp })
+	seg.live++
+
+	if pos < len(entries) && entries[pos].timestamp == entry.timestamp {
+		if old, ok := s.segments[entries[pos].segment]; ok {
+			old.live--
+			old.dead++
+		}
+		entries[pos] = entry
+		return
+	}
+
+	entries = append(entries, indexEntry{})
+	copy(entries[pos+1:], entries[pos:])
+	entries[pos] = entry
+	s.index[rec.StationID] = entries
+}
+
+// rotate seals the active segment and starts a new one
+func (s *fileStore) rotate() error {
+	var next uint32 = 1
+	for id := range s.segments {
+		if id >= next {
+			next = id + 1
+		}
+	}
+
+	path := s.segmentPath(next)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create segment: %w", err)
+	}
+	if err := syncDir(s.dir); err != nil {
+		log.Printf("Error syncing store directory: %v", err)
+	}
+
+	seg := &segment{id: next, file: file}
+	s.segments[next] = seg
+	s.active = seg
+	return nil
+}
+
+// Append durably writes records; a record replaces any earlier one with the same station and timestamp
+func (s *fileStore) Append(records ...Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil {
+		return fmt.Errorf("store is closed")
+	}
+
+	for _, rec := range records {
+		data, err := encodeRecord(rec)
+		if err != nil {
+			return fmt.Errorf("failed to encode record: %w", err)
+		}
+
+		seg := s.active
+		if _, err := seg.file.WriteAt(data, seg.size); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+		s.indexRecord(rec, seg, seg.size, int32(len(data)))
+		seg.size += int64(len(data))
+
+		if seg.size >= s.opts.SegmentSize {
+			if err := seg.file.Sync(); err != nil {
+				return fmt.Errorf("failed to sync segment: %w", err)
+			}
+			if err := s.rotate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.opts.SyncWrites {
+		if err := s.active.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync segment: %w", err)
+		}
+	}
+	return nil
+}
+
+// Range returns a station's records with from <= timestamp <= to in time order
+func (s *fileStore) Range(stationID string, from, to time.Time) ([]Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := s.index[stationID]
+	fromNs, toNs := from.UnixNano(), to.UnixNano()
+	start := sort.Search(len(entries), func(i int) bool { return entries[i].timestamp >= fromNs })
+
+	result := []Record{}
+	for _, entry := range entries[start:] {
+		if entry.timestamp > toNs {
+			break
+		}
+		rec, err := s.readRecord(entry)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rec)
+	}
+	return result, nil
+}
+
+// readRecord reads the record an index entry points at
+func (s *fileStore) readRecord(entry indexEntry) (Record, error) {
+	seg, ok := s.segments[entry.segment]
+	if !ok {
+		return Record{}, fmt.Errorf("segment %d missing", entry.segment)
+	}
+
+	buf := make([]byte, entry.size)
+	if _, err := seg.file.ReadAt(buf, entry.offset); err != nil {
+		return Record{}, fmt.Errorf("failed to read record: %w", err)
+	}
+	rec, _, err := decodeRecord(buf)
+	if err != nil {
+		return Record{}, fmt.Errorf("segment %d offset %d: %w", entry.segment, entry.offset, err)
+	}
+	return rec, nil
+}
+
+// Stations returns the IDs of all stations with stored records
+func (s *fileStore) Stations() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]string, 0, len(s.index))
+	for stationID, entries := range s.index {
+		if len(entries) > 0 {
+			result = append(result, stationID)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Compact rewrites sealed segments, dropping records older than before and superseded
+// duplicates. A zero before keeps every record and only drops duplicates.
+func (s *fileStore) Compact(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil {
+		return fmt.Errorf("store is closed")
+	}
+	if !before.IsZero() {
+		s.expire(before.UnixNano())
+	}
+
+	for id, seg := range s.segments {
+		if seg == s.active || seg.dead == 0 {
+			continue
+		}
+		if err := s.rewriteSegment(id, seg); err != nil {
+			return err
+		}
+	}
+
+	s.lastCompacted = time.Now()
+	return nil
+}
+
+// expire drops index entries older than cutoff (Unix nanoseconds) so they count as dead
+func (s *fileStore) expire(cutoff int64) {
+	for stationID, entries := range s.index {
+		keep := sort.Search(len(entries), func(i int) bool { return entries[i].timestamp >= cutoff })
+		for _, entry := range entries[:keep] {
+			if seg, ok := s.segments[entry.segment]; ok {
+				seg.live--
+				seg.dead++
+			}
+		}
+		if keep == len(entries) {
+			delete(s.index, stationID)
+		} else if keep > 0 {
+			s.index[stationID] = append([]indexEntry(nil), entries[keep:]...)
+		}
+	}
+}
+
+// rewriteSegment copies the live records of a sealed segment into a replacement file
+// and atomically swaps it in; a segment without live records is removed
+func (s *fileStore) rewriteSegment(id uint32, seg *segment) error {
+	path := s.segmentPath(id)
+
+	// Collect live entries of this segment in file order
+	type ref struct {
+		stationID string
+		pos       int
+	}
+	var refs []ref
+	for stationID, entries := range s.index {
+		for i, entry := range entries {
+			if entry.segment == id {
+				refs = append(refs, ref{stationID, i})
+			}
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return s.index[refs[i].stationID][refs[i].pos].offset < s.index[refs[j].stationID][refs[j].pos].offset
+	})
+
+	if len(refs) == 0 {
+		seg.file.Close()
+		delete(s.segments, id)
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove segment: %w", err)
+		}
+		return syncDir(s.dir)
+	}
+
+	tmpPath := path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create compaction file: %w", err)
+	}
+
+	newOffsets := make([]int64, len(refs))
+	var size int64
+	for i, r := range refs {
+		entry := s.index[r.stationID][r.pos]
+		buf := make([]byte, entry.size)
+		if _, err := seg.file.ReadAt(buf, entry.offset); err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to read record during compaction: %w", err)
+		}
+		if _, err := tmp.WriteAt(buf, size); err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to write compacted record: %w", err)
+		}
+		newOffsets[i] = size
+		size += int64(len(buf))
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync compacted segment: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace segment: %w", err)
+	}
+	if err := syncDir(s.dir); err != nil {
+		log.Printf("Error syncing store directory: %v", err)
+	}
+
+	seg.file.Close()
+	seg.file = tmp
+	seg.size = size
+	seg.live = len(refs)
+	seg.dead = 0
+	for i, r := range refs {
+		s.index[r.stationID][r.pos].offset = newOffsets[i]
+	}
+	return nil
+}
+
+// Stats returns store size and recovery statistics
+func (s *fileStore) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := Stats{
+		Segments:       len(s.segments),
+		CorruptSkipped: s.corruptSkipped,
+		TruncatedBytes: s.truncatedBytes,
+		LastCompacted:  s.lastCompacted,
+	}
+	for _, seg := range s.segments {
+		stats.Bytes += seg.size
+	}
+	for _, entries := range s.index {
+		if len(entries) > 0 {
+			stats.Stations++
+			stats.Records += len(entries)
+		}
+	}
+	return stats
+}
+
+// Close flushes and closes all segment files
+func (s *fileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for _, seg := range s.segments {
+		if seg == s.active {
+			if err := seg.file.Sync(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if err := seg.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.segments = make(map[uint32]*segment)
+	s.active = nil
+	return firstErr
+}
+
+// syncDir fsyncs a directory so file creations and renames are durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,317 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testRecord(stationID string, ts time.Time, speed float64) Record {
+	return Record{
+		StationID:     stationID,
+		Timestamp:     ts,
+		WindSpeed:     speed,
+		WindGust:      speed + 2,
+		WindDirection: 225,
+	}
+}
+
+func TestAppendAndRange(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		if err := s.Append(testRecord("101023", base.Add(time.Duration(i)*10*time.Minute), float64(i))); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if err := s.Append(testRecord("100996", base, 7)); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	// Same timestamp replaces the earlier record
+	if err := s.Append(testRecord("101023", base, 42)); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	records, err := s.Range("101023", base.Add(-time.Hour), base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Range() error = %v", err)
+	}
+	if len(records) != 7 {
+		t.Fatalf("Expected 7 records, got %d", len(records))
+	}
+	if records[0].WindSpeed != 42 {
+		t.Errorf("Expected replaced speed 42, got %v", records[0].WindSpeed)
+	}
+	for i := 1; i < len(records); i++ {
+		if !records[i].Timestamp.After(records[i-1].Timestamp) {
+			t.Errorf("Records not in time order at %d", i)
+		}
+	}
+
+	if stations := s.Stations(); len(stations) != 2 {
+		t.Errorf("Expected 2 stations, got %v", stations)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Everything survives a reopen
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	records, err = s.Range("101023", base, base.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("Range() error = %v", err)
+	}
+	if len(records) != 10 {
+		t.Fatalf("Expected 10 records after reopen, got %d", len(records))
+	}
+	if records[0].WindSpeed != 42 || records[9].WindGust != 11 {
+		t.Errorf("Unexpected records after reopen: %+v", records)
+	}
+	if stats := s.Stats(); stats.Records != 11 || stats.Stations != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestSegmentRotation(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{SegmentSize: 256})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	base := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 50; i++ {
+		if err := s.Append(testRecord("101023", base.Add(time.Duration(i)*time.Minute), float64(i))); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	stats := s.Stats()
+	if stats.Segments < 2 {
+		t.Errorf("Expected multiple segments, got %d", stats.Segments)
+	}
+
+	records, err := s.Range("101023", base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Range() error = %v", err)
+	}
+	if len(records) != 50 {
+		t.Errorf("Expected 50 records across segments, got %d", len(records))
+	}
+}
+
+func TestRecoverTornTail(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	base := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		if err := s.Append(testRecord("101023", base.Add(time.Duration(i)*time.Minute), float64(i))); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	s.Close()
+
+	// Simulate a crash in the middle of writing a record
+	path := filepath.Join(dir, "00000001"+segmentExt)
+	partial, _ := encodeRecord(testRecord("101023", base.Add(time.Hour), 99))
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open segment: %v", err)
+	}
+	f.Write(partial[:len(partial)/2])
+	f.Close()
+
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() after torn write error = %v", err)
+	}
+	defer s.Close()
+
+	stats := s.Stats()
+	if stats.TruncatedBytes != int64(len(partial)/2) {
+		t.Errorf("Expected %d truncated bytes, got %d", len(partial)/2, stats.TruncatedBytes)
+	}
+	if stats.CorruptSkipped != 0 {
+		t.Errorf("A torn tail should not count as corruption, got %d", stats.CorruptSkipped)
+	}
+
+	// New appends land after the truncated tail and are readable
+	if err := s.Append(testRecord("101023", base.Add(2*time.Hour), 12)); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	records, err := s.Range("101023", base, base.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("Range() error = %v", err)
+	}
+	if len(records) != 6 || records[5].WindSpeed != 12 {
+		t.Errorf("Unexpected records after recovery: %+v", records)
+	}
+}
+
+func TestRecoverCorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	base := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	var sizes []int
+	for i := 0; i < 5; i++ {
+		rec := testRecord("101023", base.Add(time.Duration(i)*time.Minute), float64(i))
+		data, _ := encodeRecord(rec)
+		sizes = append(sizes, len(data))
+		if err := s.Append(rec); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	s.Close()
+
+	// Flip a payload byte of the third record so its checksum fails
+	path := filepath.Join(dir, "00000001"+segmentExt)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read segment: %v", err)
+	}
+	offset := sizes[0] + sizes[1] + sizes[2] - 3
+	data[offset] ^= 0xFF
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write segment: %v", err)
+	}
+
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open() with corrupt record error = %v", err)
+	}
+	defer s.Close()
+
+	records, err := s.Range("101023", base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Range() error = %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("Expected 4 surviving records, got %d", len(records))
+	}
+	if records[2].WindSpeed != 3 {
+		t.Errorf("Expected record after the damage to be recovered, got %+v", records[2])
+	}
+	if stats := s.Stats(); stats.CorruptSkipped != 1 {
+		t.Errorf("Expected 1 corrupt region, got %d", stats.CorruptSkipped)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{SegmentSize: 256})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	base := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 40; i++ {
+		if err := s.Append(testRecord("101023", base.Add(time.Duration(i)*time.Minute), float64(i))); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	before := s.Stats()
+
+	cutoff := base.Add(30 * time.Minute)
+	if err := s.Compact(cutoff); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+
+	after := s.Stats()
+	if after.Bytes >= before.Bytes || after.Segments >= before.Segments {
+		t.Errorf("Compaction did not shrink store: before %+v, after %+v", before, after)
+	}
+	if after.LastCompacted.IsZero() {
+		t.Error("Expected LastCompacted to be set")
+	}
+
+	records, err := s.Range("101023", base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Range() error = %v", err)
+	}
+	if len(records) != 10 || !records[0].Timestamp.Equal(cutoff) {
+		t.Errorf("Expected 10 records from cutoff, got %d", len(records))
+	}
+	s.Close()
+
+	// Compacted segments reopen cleanly
+	s, err = Open(dir, Options{SegmentSize: 256})
+	if err != nil {
+		t.Fatalf("Open() after compaction error = %v", err)
+	}
+	defer s.Close()
+
+	records, err = s.Range("101023", base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Range() error = %v", err)
+	}
+	if len(records) != 10 {
+		t.Errorf("Expected 10 records after reopen, got %d", len(records))
+	}
+	if stats := s.Stats(); stats.CorruptSkipped != 0 {
+		t.Errorf("Unexpected corruption after compaction: %+v", stats)
+	}
+}
+
+func TestCompactRetention(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Retention: time.Hour}
+	s, err := Open(dir, opts)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	s.Append(testRecord("101023", now.Add(-2*time.Hour), 4), testRecord("101023", now, 6))
+
+	// A zero cutoff keeps everything
+	if err := s.Compact(time.Time{}); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if stats := s.Stats(); stats.Records != 2 {
+		t.Errorf("Expected zero cutoff to keep both records, got %d", stats.Records)
+	}
+
+	if err := s.Compact(now.Add(-opts.Retention)); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if stats := s.Stats(); stats.Records != 1 {
+		t.Errorf("Expected the expired record to be dropped, got %d", stats.Records)
+	}
+	s.Close()
+
+	// The expired record is still in the active segment but stays out of the index
+	s, err = Open(dir, opts)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+
+	records, err := s.Range("101023", now.Add(-3*time.Hour), now)
+	if err != nil {
+		t.Fatalf("Range() error = %v", err)
+	}
+	if len(records) != 1 || !records[0].Timestamp.Equal(now) {
+		t.Errorf("Expected only the retained record after reopen, got %+v", records)
+	}
+}
@@ -0,0 +1,226 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+)
+
+// Unit is a wind speed unit as named in the units= query parameter
+type Unit string
+
+// Supported wind speed units
+const (
+	MetersPerSecond   Unit = "ms"
+	Knots             Unit = "kn"
+	KilometersPerHour Unit = "kmh"
+	MilesPerHour      Unit = "mph"
+	Beaufort          Unit = "bft"
+)
+
+// Lang is the language of compass-point names as named in the lang= query parameter
+type Lang string
+
+// Supported compass-point languages
+const (
+	English Lang = "en"
+	Finnish Lang = "fi"
+	Swedish Lang = "sv"
+)
+
+// perMS is how many of a linear unit make one m/s
+var perMS = map[Unit]float64{
+	MetersPerSecond:   1,
+	Knots:             3600.0 / 1852,
+	KilometersPerHour: 3.6,
+	MilesPerHour:      1 / 0.44704,
+}
+
+var symbols = map[Unit]string{
+	MetersPerSecond:   "m/s",
+	Knots:             "kn",
+	KilometersPerHour: "km/h",
+	MilesPerHour:      "mph",
+	Beaufort:          "Bft",
+}
+
+// beaufortLimits are the lowest speeds in m/s of Beaufort forces 1 to 12 (WMO)
+var beaufortLimits = [...]float64{0.3, 1.6, 3.4, 5.5, 8.0, 10.8, 13.9, 17.2, 20.8, 24.5, 28.5, 32.7}
+
+// compassPoints are the 16 compass points clockwise from north
+var compassPoints = map[Lang][16]string{
+	English: {"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"},
+	Finnish: {"P", "PKO", "KO", "IKO", "I", "IKA", "KA", "EKA", "E", "ELO", "LO", "LLO", "L", "LLU", "LU", "PLU"},
+	Swedish: {"N", "NNO", "NO", "ONO", "O", "OSO", "SO", "SSO", "S", "SSV", "SV", "VSV", "V", "VNV", "NV", "NNV"},
+}
+
+// ParseUnit validates a unit name, empty meaning m/s
+func ParseUnit(value string) (Unit, error) {
+	if value == "" {
+		return MetersPerSecond, nil
+	}
+	unit := Unit(value)
+	if _, ok := symbols[unit]; !ok {
+		return "", fmt.Errorf("invalid units %q (use ms, kn, kmh, mph or bft)", value)
+	}
+	return unit, nil
+}
+
+// ParseLang validates a language name, empty meaning English
+func ParseLang(value string) (Lang, error) {
+	if value == "" {
+		return English, nil
+	}
+	lang := Lang(value)
+	if _, ok := compassPoints[lang]; !ok {
+		return "", fmt.Errorf("invalid lang %q (use en, fi or sv)", value)
+	}
+	return lang, nil
+}
+
+// Symbol returns the unit's display symbol
+func (u Unit) Symbol() string {
+	return symbols[u]
+}
+
+// Decimals returns how many decimals speeds in the unit are shown with
+func (u Unit) Decimals() int {
+	if u == Beaufort {
+		return 0
+	}
+	return 1
+}
+
+// Format formats a speed already in the unit with its symbol
+func (u Unit) Format(speed float64) string {
+	return strconv.FormatFloat(speed, 'f', u.Decimals(), 64) + " " + u.Symbol()
+}
+
+// FromMS converts a speed in m/s to the unit; Beaufort gives the force number.
+// Negative values mark missing data and are returned unchanged.
+func (u Unit) FromMS(speed float64) float64 {
+	if speed < 0 {
+		return speed
+	}
+	if u == Beaufort {
+		return float64(BeaufortForce(speed))
+	}
+	return speed * perMS[u]
+}
+
+// ToMS converts a speed in the unit to m/s; a Beaufort force gives the lowest speed of its range
+func (u Unit) ToMS(speed float64) float64 {
+	if speed < 0 {
+		return speed
+	}
+	if u == Beaufort {
+		force := min(int(speed), len(beaufortLimits))
+		if force < 1 {
+			return 0
+		}
+		return beaufortLimits[force-1]
+	}
+	return speed / perMS[u]
+}
+
+// Rate converts a rate of change in m/s per hour. The Beaufort scale is not linear,
+// so rates stay in m/s per hour.
+func (u Unit) Rate(rate float64) float64 {
+	if u == Beaufort {
+		return rate
+	}
+	return rate * perMS[u]
+}
+
+// RateSymbol returns the symbol of rates converted by Rate
+func (u Unit) RateSymbol() string {
+	if u == Beaufort {
+		return "m/s/h"
+	}
+	return u.Symbol() + "/h"
+}
+
+// BeaufortForce returns the Beaufort force of a speed in m/s
+func BeaufortForce(speed float64) int {
+	force := 0
+	for force < len(beaufortLimits) && speed >= beaufortLimits[force] {
+		force++
+	}
+	return force
+}
+
+// CompassPoint names the 16-point compass direction of a bearing in degrees
+func CompassPoint(degrees float64, lang Lang) string {
+	points, ok := compassPoints[lang]
+	if !ok {
+		points = compassPoints[English]
+	}
+	degrees = math.Mod(degrees, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+	return points[int(math.Round(degrees/22.5))%16]
+}
+
+// Convention is a client's choice of speed unit and compass language. The zero value
+// leaves data as stored: m/s without compass names.
+type Convention struct {
+	Unit Unit
+	Lang Lang
+}
+
+// FromQuery reads the units= and lang= query parameters. Giving either one selects a
+// convention; the other takes its default.
+func FromQuery(query url.Values) (Convention, error) {
+	unitValue, langValue := query.Get("units"), query.Get("lang")
+	if unitValue == "" && langValue == "" {
+		return Convention{}, nil
+	}
+
+	unit, err := ParseUnit(unitValue)
+	if err != nil {
+		return Convention{}, err
+	}
+	lang, err := ParseLang(langValue)
+	if err != nil {
+		return Convention{}, err
+	}
+	return Convention{Unit: unit, Lang: lang}, nil
+}
+
+// IsDefault reports whether the convention leaves data unchanged
+func (c Convention) IsDefault() bool {
+	return c == Convention{}
+}
+
+// Speed converts a speed in m/s to the convention's unit
+func (c Convention) Speed(speed float64) float64 {
+	if c.IsDefault() {
+		return speed
+	}
+	return c.Unit.FromMS(speed)
+}
+
+// Compass names a bearing in the convention's language
+func (c Convention) Compass(degrees float64) string {
+	return CompassPoint(degrees, c.Lang)
+}
+
+// Converter is implemented by values that can present themselves in a convention
+type Converter interface {
+	// ConvertUnits returns a copy of the value in the convention
+	ConvertUnits(c Convention) any
+}
+
+// Convert presents v in the convention when it is a Converter and the convention is not
+// the default, otherwise it returns v unchanged
+func Convert(v any, c Convention) any {
+	if c.IsDefault() {
+		return v
+	}
+	if converter, ok := v.(Converter); ok {
+		return converter.ConvertUnits(c)
+	}
+	return v
+}
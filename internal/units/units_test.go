@@ -0,0 +1,128 @@
+package units
+
+import (
+	"math"
+	"net/url"
+	"testing"
+)
+
+func TestFromMS(t *testing.T) {
+	tests := []struct {
+		unit  Unit
+		speed float64
+		want  float64
+	}{
+		{MetersPerSecond, 10, 10},
+		{Knots, 10, 19.438},
+		{KilometersPerHour, 10, 36},
+		{MilesPerHour, 10, 22.369},
+		{Beaufort, 10, 5},
+		{Beaufort, 0.2, 0},
+		{Beaufort, 40, 12},
+		{Knots, -1, -1},
+	}
+	for _, tt := range tests {
+		if got := tt.unit.FromMS(tt.speed); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("%s.FromMS(%v) = %v, want %v", tt.unit, tt.speed, got, tt.want)
+		}
+	}
+
+	for _, unit := range []Unit{MetersPerSecond, Knots, KilometersPerHour, MilesPerHour} {
+		if got := unit.ToMS(unit.FromMS(7.3)); math.Abs(got-7.3) > 1e-9 {
+			t.Errorf("%s round trip = %v", unit, got)
+		}
+	}
+	if got := Beaufort.ToMS(4); got != 5.5 {
+		t.Errorf("Beaufort.ToMS(4) = %v, want 5.5", got)
+	}
+	if got := Knots.Format(Knots.FromMS(5)); got != "9.7 kn" {
+		t.Errorf("Knots.Format() = %q", got)
+	}
+	if got := Beaufort.Format(Beaufort.FromMS(5)); got != "3 Bft" {
+		t.Errorf("Beaufort.Format() = %q", got)
+	}
+	if got := Beaufort.Rate(2); got != 2 || Beaufort.RateSymbol() != "m/s/h" {
+		t.Errorf("Beaufort rates must stay in m/s per hour, got %v %s", got, Beaufort.RateSymbol())
+	}
+}
+
+func TestBeaufortForce(t *testing.T) {
+	// Each limit is the first speed of its force
+	for i, limit := range beaufortLimits {
+		if got := BeaufortForce(limit); got != i+1 {
+			t.Errorf("BeaufortForce(%v) = %d, want %d", limit, got, i+1)
+		}
+		if got := BeaufortForce(limit - 0.1); got != i {
+			t.Errorf("BeaufortForce(%v) = %d, want %d", limit-0.1, got, i)
+		}
+	}
+}
+
+func TestCompassPoint(t *testing.T) {
+	tests := []struct {
+		degrees float64
+		lang    Lang
+		want    string
+	}{
+		{0, English, "N"},
+		{11.2, English, "N"},
+		{11.3, English, "NNE"},
+		{225, English, "SW"},
+		{348.75, English, "N"},
+		{360, English, "N"},
+		{-90, English, "W"},
+		{90, Finnish, "I"},
+		{225, Finnish, "LO"},
+		{315, Finnish, "LU"},
+		{67.5, Swedish, "ONO"},
+		{270, Swedish, "V"},
+		{45, "xx", "NE"},
+	}
+	for _, tt := range tests {
+		if got := CompassPoint(tt.degrees, tt.lang); got != tt.want {
+			t.Errorf("CompassPoint(%v, %s) = %q, want %q", tt.degrees, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestFromQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    Convention
+		wantErr bool
+	}{
+		{"", Convention{}, false},
+		{"units=kn", Convention{Unit: Knots, Lang: English}, false},
+		{"lang=fi", Convention{Unit: MetersPerSecond, Lang: Finnish}, false},
+		{"units=bft&lang=sv", Convention{Unit: Beaufort, Lang: Swedish}, false},
+		{"units=furlongs", Convention{}, true},
+		{"units=kn&lang=de", Convention{}, true},
+	}
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		got, err := FromQuery(query)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("FromQuery(%q) = %+v, %v", tt.query, got, err)
+		}
+	}
+
+	if !(Convention{}).IsDefault() || (Convention{Unit: MetersPerSecond, Lang: English}).IsDefault() {
+		t.Error("Only the zero convention is the default")
+	}
+}
+
+type speed float64
+
+func (s speed) ConvertUnits(c Convention) any { return c.Speed(float64(s)) }
+
+func TestConvert(t *testing.T) {
+	if got := Convert(speed(10), Convention{}); got != speed(10) {
+		t.Errorf("Default convention must not convert, got %v", got)
+	}
+	if got := Convert(speed(10), Convention{Unit: KilometersPerHour}); got != 36.0 {
+		t.Errorf("Expected km/h, got %v", got)
+	}
+	if got := Convert("text", Convention{Unit: Knots}); got != "text" {
+		t.Errorf("Non-converters pass through, got %v", got)
+	}
+}
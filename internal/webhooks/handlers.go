@@ -0,0 +1,62 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"windz/internal/auth"
+)
+
+// RegisterHandlers registers the webhook admin handlers. When adminToken is set,
+// requests must carry it as a bearer token.
+func RegisterHandlers(mux *http.ServeMux, mgr Manager, adminToken string) {
+	mux.HandleFunc("/api/admin/webhooks", auth.RequireToken(adminToken, handleEndpoints(mgr)))
+	mux.HandleFunc("/api/admin/webhooks/dead-letters", auth.RequireToken(adminToken, handleDeadLetters(mgr)))
+}
+
+// handleEndpoints lists the webhook endpoints with delivery statistics
+func handleEndpoints(mgr Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(mgr.GetEndpoints()); err != nil {
+			log.Printf("Error encoding webhook endpoints response: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// handleDeadLetters lists failed deliveries, newest first (?limit=n)
+func handleDeadLetters(mgr Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		limit := 100
+		if value := r.URL.Query().Get("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "Invalid limit", http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+
+		deadLetters, err := mgr.GetDeadLetters(limit)
+		if err != nil {
+			log.Printf("Error reading dead letters: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if deadLetters == nil {
+			deadLetters = []DeadLetter{}
+		}
+
+		if err := json.NewEncoder(w).Encode(deadLetters); err != nil {
+			log.Printf("Error encoding dead letters response: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
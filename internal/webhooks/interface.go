@@ -0,0 +1,62 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+	"windz/internal/sse"
+)
+
+// Manager defines the interface for outbound webhook delivery
+type Manager interface {
+	// Start begins delivering queued messages
+	Start(ctx context.Context) error
+
+	// Stop stops delivery; undelivered messages go to the dead-letter log
+	Stop() error
+
+	// Enqueue queues a broadcast message for every matching endpoint without blocking
+	Enqueue(message sse.Message)
+
+	// GetEndpoints returns the configured endpoints with delivery statistics (secrets omitted)
+	GetEndpoints() []EndpointStatus
+
+	// GetDeadLetters returns the most recent failed deliveries, newest first
+	GetDeadLetters(limit int) ([]DeadLetter, error)
+}
+
+// Endpoint is a webhook receiver. Empty Stations or Events match everything.
+type Endpoint struct {
+	ID       string   `json:"id"`
+	URL      string   `json:"url"`
+	Secret   string   `json:"secret,omitempty"` // HMAC-SHA256 signing key
+	Stations []string `json:"stations,omitempty"`
+	Events   []string `json:"events,omitempty"` // SSE message types such as data, alert, trend
+	Disabled bool     `json:"disabled,omitempty"`
+}
+
+// EndpointStatus describes an endpoint and its delivery counters for the admin API
+type EndpointStatus struct {
+	ID           string    `json:"id"`
+	URL          string    `json:"url"`
+	Signed       bool      `json:"signed"`
+	Stations     []string  `json:"stations,omitempty"`
+	Events       []string  `json:"events,omitempty"`
+	Disabled     bool      `json:"disabled"`
+	Queued       int       `json:"queued"`
+	Delivered    int       `json:"delivered"`
+	Retried      int       `json:"retried"`
+	Failed       int       `json:"failed"`
+	LastDelivery time.Time `json:"last_delivery"`
+	LastError    string    `json:"last_error,omitempty"`
+}
+
+// DeadLetter is a delivery that failed permanently, as written to the dead-letter log
+type DeadLetter struct {
+	EndpointID string      `json:"endpoint_id"`
+	URL        string      `json:"url"`
+	Message    sse.Message `json:"message"`
+	Attempts   int         `json:"attempts"`
+	StatusCode int         `json:"status_code,omitempty"`
+	Error      string      `json:"error"`
+	FailedAt   time.Time   `json:"failed_at"`
+}
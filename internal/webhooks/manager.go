@@ -0,0 +1,501 @@
+package webhooks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"windz/internal/sse"
+)
+
+// Delivery defaults
+const (
+	DefaultMaxAttempts = 6
+	DefaultBaseBackoff = 2 * time.Second
+	DefaultMaxBackoff  = 2 * time.Minute
+	DefaultQueueSize   = 256
+	DefaultTimeout     = 10 * time.Second
+
+	DefaultDeadLetterMaxSize = 4 << 20 // Bytes
+
+	maxMemoryDeadLetters = 100
+)
+
+// deadLetterRotatedSuffix is appended to the path of the rotated dead-letter log
+const deadLetterRotatedSuffix = ".1"
+
+// Request headers sent with every delivery
+const (
+	HeaderEvent     = "X-Windz-Event"
+	HeaderDelivery  = "X-Windz-Delivery"
+	HeaderTimestamp = "X-Windz-Timestamp"
+	HeaderSignature = "X-Windz-Signature"
+)
+
+// Config holds the webhook manager settings
+type Config struct {
+	Endpoints         []Endpoint
+	DeadLetterFile    string        // JSON Lines log of failed deliveries (empty keeps them in memory only)
+	DeadLetterMaxSize int64         // Size in bytes at which the log is rotated to <file>.1, replacing the previous one (0 uses the default)
+	MaxAttempts       int           // Delivery attempts before dead-lettering (0 uses the default)
+	BaseBackoff       time.Duration // Delay after the first failure, doubled per attempt (0 uses the default)
+	MaxBackoff        time.Duration // Upper bound of the retry delay (0 uses the default)
+	QueueSize         int           // Per-endpoint queue length (0 uses the default)
+	Timeout           time.Duration // Per-request timeout (0 uses the default)
+}
+
+// manager implements the webhooks Manager interface
+type manager struct {
+	client            *http.Client
+	deadLetterFile    string
+	deadLetterMaxSize int64
+	maxAttempts       int
+	baseBackoff       time.Duration
+	maxBackoff        time.Duration
+
+	workers []*worker
+	seq     atomic.Int64
+
+	mu          sync.Mutex // Guards worker stats and the in-memory dead letters
+	deadLetters []DeadLetter
+	logMu       sync.Mutex // Serializes dead-letter log writes, rotation and reads
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// worker delivers the messages of one endpoint in order
+type worker struct {
+	endpoint Endpoint
+	stations map[string]bool
+	events   map[string]bool
+	queue    chan sse.Message
+	status   EndpointStatus
+}
+
+// NewManager creates a new webhook manager instance
+func NewManager(cfg Config) (Manager, error) {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = DefaultBaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultMaxBackoff
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.DeadLetterMaxSize <= 0 {
+		cfg.DeadLetterMaxSize = DefaultDeadLetterMaxSize
+	}
+
+	m := &manager{
+		client:            &http.Client{Timeout: cfg.Timeout},
+		deadLetterFile:    cfg.DeadLetterFile,
+		deadLetterMaxSize: cfg.DeadLetterMaxSize,
+		maxAttempts:       cfg.MaxAttempts,
+		baseBackoff:       cfg.BaseBackoff,
+		maxBackoff:        cfg.MaxBackoff,
+	}
+
+	seen := make(map[string]bool)
+	for _, endpoint := range cfg.Endpoints {
+		if err := validateEndpoint(endpoint); err != nil {
+			return nil, err
+		}
+		if seen[endpoint.ID] {
+			return nil, fmt.Errorf("duplicate webhook endpoint %q", endpoint.ID)
+		}
+		seen[endpoint.ID] = true
+
+		m.workers = append(m.workers, &worker{
+			endpoint: endpoint,
+			stations: toSet(endpoint.Stations),
+			events:   toSet(endpoint.Events),
+			queue:    make(chan sse.Message, cfg.QueueSize),
+			status: EndpointStatus{
+				ID:       endpoint.ID,
+				URL:      endpoint.URL,
+				Signed:   endpoint.Secret != "",
+				Stations: endpoint.Stations,
+				Events:   endpoint.Events,
+				Disabled: endpoint.Disabled,
+			},
+		})
+	}
+
+	return m, nil
+}
+
+// LoadEndpoints reads endpoint definitions from a JSON file
+func LoadEndpoints(path string) ([]Endpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhooks file: %w", err)
+	}
+
+	var endpoints []Endpoint
+	if err := json.Unmarshal(data, &endpoints); err != nil {
+		return nil, fmt.Errorf("failed to parse webhooks file: %w", err)
+	}
+	return endpoints, nil
+}
+
+func validateEndpoint(endpoint Endpoint) error {
+	if endpoint.ID == "" {
+		return fmt.Errorf("webhook endpoint without ID")
+	}
+	parsed, err := url.Parse(endpoint.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("webhook endpoint %s: invalid URL %q", endpoint.ID, endpoint.URL)
+	}
+	return nil
+}
+
+func toSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// Start begins delivering queued messages
+func (m *manager) Start(ctx context.Context) error {
+	ctx, m.cancel = context.WithCancel(ctx)
+
+	for _, w := range m.workers {
+		if w.endpoint.Disabled {
+			continue
+		}
+		m.wg.Add(1)
+		go m.runWorker(ctx, w)
+	}
+
+	log.Printf("Webhook delivery started for %d endpoints", len(m.workers))
+	return nil
+}
+
+// Stop stops delivery; undelivered messages go to the dead-letter log
+func (m *manager) Stop() error {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+	return nil
+}
+
+// Enqueue queues a broadcast message for every matching endpoint without blocking
+func (m *manager) Enqueue(message sse.Message) {
+	for _, w := range m.workers {
+		if !w.matches(message) {
+			continue
+		}
+
+		select {
+		case w.queue <- message:
+		default:
+			m.deadLetter(w, message, 0, 0, fmt.Errorf("queue full"))
+		}
+	}
+}
+
+// matches reports whether the endpoint subscribes to the message
+func (w *worker) matches(message sse.Message) bool {
+	if w.endpoint.Disabled {
+		return false
+	}
+	if w.events != nil && !w.events[message.Type] {
+		return false
+	}
+	if w.stations != nil && !w.stations[message.StationID] {
+		return false
+	}
+	return true
+}
+
+// runWorker delivers an endpoint's messages until the context is cancelled
+func (m *manager) runWorker(ctx context.Context, w *worker) {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case message := <-w.queue:
+			m.deliver(ctx, w, message)
+		case <-ctx.Done():
+			// Keep what could not be sent
+			for {
+				select {
+				case message := <-w.queue:
+					m.deadLetter(w, message, 0, 0, fmt.Errorf("shutdown before delivery"))
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver posts a message with retries, dead-lettering it when all attempts fail
+func (m *manager) deliver(ctx context.Context, w *worker, message sse.Message) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		m.deadLetter(w, message, 0, 0, fmt.Errorf("failed to encode message: %w", err))
+		return
+	}
+	deliveryID := fmt.Sprintf("%d-%d", message.ID, m.seq.Add(1))
+
+	var statusCode, attempt int
+	for attempt = 1; attempt <= m.maxAttempts; attempt++ {
+		var retryable bool
+		statusCode, retryable, err = m.send(ctx, w.endpoint, deliveryID, message.Type, body)
+		if err == nil {
+			m.mu.Lock()
+			w.status.Delivered++
+			w.status.LastDelivery = time.Now()
+			w.status.LastError = ""
+			m.mu.Unlock()
+			return
+		}
+		if !retryable || attempt == m.maxAttempts {
+			break
+		}
+
+		m.mu.Lock()
+		w.status.Retried++
+		w.status.LastError = err.Error()
+		m.mu.Unlock()
+
+		select {
+		case <-time.After(m.backoff(attempt)):
+		case <-ctx.Done():
+			m.deadLetter(w, message, attempt, statusCode, fmt.Errorf("shutdown during retry: %w", err))
+			return
+		}
+	}
+
+	m.deadLetter(w, message, min(attempt, m.maxAttempts), statusCode, err)
+}
+
+// send makes one delivery attempt. It reports whether a failure is worth retrying.
+func (m *manager) send(ctx context.Context, endpoint Endpoint, deliveryID, eventType string, body []byte) (int, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "windz-webhooks")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if endpoint.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(endpoint.Secret, timestamp, body))
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, true, fmt.Errorf("request failed: %w", err)
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+
+	// Client errors other than timeouts and rate limiting will not succeed on retry
+	retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return resp.StatusCode, retryable, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+// backoff returns the delay before the next attempt with ±20% jitter
+func (m *manager) backoff(attempt int) time.Duration {
+	delay := m.baseBackoff << (attempt - 1)
+	if delay > m.maxBackoff || delay <= 0 {
+		delay = m.maxBackoff
+	}
+	jitter := time.Duration(rand.Int64N(int64(delay)/5 + 1))
+	return delay - delay/10 + jitter
+}
+
+// Sign returns the signature header value for a delivery: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the endpoint secret, prefixed with "sha256="
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header value in constant time
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// deadLetter records a failed delivery in memory and in the dead-letter log
+func (m *manager) deadLetter(w *worker, message sse.Message, attempts, statusCode int, err error) {
+	entry := DeadLetter{
+		EndpointID: w.endpoint.ID,
+		URL:        w.endpoint.URL,
+		Message:    message,
+		Attempts:   attempts,
+		StatusCode: statusCode,
+		Error:      err.Error(),
+		FailedAt:   time.Now(),
+	}
+	log.Printf("Webhook %s: delivery of %s message failed after %d attempts: %v", entry.EndpointID, message.Type, attempts, err)
+
+	m.mu.Lock()
+	w.status.Failed++
+	w.status.LastError = entry.Error
+	m.deadLetters = append(m.deadLetters, entry)
+	if len(m.deadLetters) > maxMemoryDeadLetters {
+		m.deadLetters = append([]DeadLetter(nil), m.deadLetters[len(m.deadLetters)-maxMemoryDeadLetters:]...)
+	}
+	m.mu.Unlock()
+
+	if m.deadLetterFile != "" {
+		if err := m.appendDeadLetter(entry); err != nil {
+			log.Printf("Error writing dead-letter log: %v", err)
+		}
+	}
+}
+
+// appendDeadLetter writes an entry to the dead-letter log, rotating the log to
+// <file>.1 once it reaches the size limit
+func (m *manager) appendDeadLetter(entry DeadLetter) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	m.logMu.Lock()
+	defer m.logMu.Unlock()
+
+	f, err := os.OpenFile(m.deadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	info, statErr := f.Stat()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if statErr == nil && info.Size() >= m.deadLetterMaxSize {
+		return os.Rename(m.deadLetterFile, m.deadLetterFile+deadLetterRotatedSuffix)
+	}
+	return nil
+}
+
+// GetEndpoints returns the configured endpoints with delivery statistics
+func (m *manager) GetEndpoints() []EndpointStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]EndpointStatus, 0, len(m.workers))
+	for _, w := range m.workers {
+		status := w.status
+		status.Queued = len(w.queue)
+		result = append(result, status)
+	}
+	return result
+}
+
+// GetDeadLetters returns the most recent failed deliveries, newest first. The log
+// file is read when configured so entries survive restarts; the rotated log is only
+// read when the current one holds fewer than limit entries.
+func (m *manager) GetDeadLetters(limit int) ([]DeadLetter, error) {
+	var entries []DeadLetter
+	if m.deadLetterFile == "" {
+		m.mu.Lock()
+		entries = slices.Clone(m.deadLetters)
+		m.mu.Unlock()
+	} else {
+		var err error
+		if entries, err = m.readDeadLetterLogs(limit); err != nil {
+			return nil, err
+		}
+	}
+
+	if limit <= 0 || limit > len(entries) {
+		limit = len(entries)
+	}
+	result := make([]DeadLetter, 0, limit)
+	for i := len(entries) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, entries[i])
+	}
+	return result, nil
+}
+
+// readDeadLetterLogs reads the current dead-letter log and, when it holds fewer than
+// limit entries (or limit is not positive), the rotated one before it
+func (m *manager) readDeadLetterLogs(limit int) ([]DeadLetter, error) {
+	m.logMu.Lock()
+	defer m.logMu.Unlock()
+
+	entries, err := readDeadLetters(m.deadLetterFile)
+	if err != nil || (limit > 0 && len(entries) >= limit) {
+		return entries, err
+	}
+	older, err := readDeadLetters(m.deadLetterFile + deadLetterRotatedSuffix)
+	if err != nil {
+		return nil, err
+	}
+	return append(older, entries...), nil
+}
+
+// readDeadLetters parses the dead-letter log, skipping damaged lines
+func readDeadLetters(path string) ([]DeadLetter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open dead-letter log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []DeadLetter
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var entry DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,275 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"windz/internal/sse"
+)
+
+// receiver is a local webhook endpoint that records verified deliveries
+type receiver struct {
+	t        *testing.T
+	secret   string
+	mu       sync.Mutex
+	received []sse.Message
+	headers  []http.Header
+	failures atomic.Int32 // Respond 503 this many times before succeeding
+	status   int          // Fixed failure status, 0 to succeed
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	if rc.secret != "" && !Verify(rc.secret, r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)) {
+		rc.t.Errorf("Invalid signature %q", r.Header.Get(HeaderSignature))
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+	if rc.status != 0 {
+		http.Error(w, "failing", rc.status)
+		return
+	}
+	if rc.failures.Add(-1) >= 0 {
+		http.Error(w, "try again", http.StatusServiceUnavailable)
+		return
+	}
+
+	var message sse.Message
+	if err := json.Unmarshal(body, &message); err != nil {
+		rc.t.Errorf("Invalid body: %v", err)
+	}
+	rc.mu.Lock()
+	rc.received = append(rc.received, message)
+	rc.headers = append(rc.headers, r.Header.Clone())
+	rc.mu.Unlock()
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.received)
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func startManager(t *testing.T, cfg Config) Manager {
+	t.Helper()
+	cfg.BaseBackoff = time.Millisecond
+	cfg.MaxBackoff = 5 * time.Millisecond
+	mgr, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if err := mgr.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { mgr.Stop() })
+	return mgr
+}
+
+func TestSignedDeliveryWithFilters(t *testing.T) {
+	rc := &receiver{t: t, secret: "s3cret"}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	mgr := startManager(t, Config{Endpoints: []Endpoint{{
+		ID:       "chat",
+		URL:      server.URL,
+		Secret:   "s3cret",
+		Stations: []string{"101022"},
+		Events:   []string{"alert", "data"},
+	}}})
+
+	mgr.Enqueue(sse.Message{ID: 1, Type: "alert", StationID: "101022", Data: map[string]string{"message": "gust"}})
+	mgr.Enqueue(sse.Message{ID: 2, Type: "status", StationID: "101022"}) // Event type filtered
+	mgr.Enqueue(sse.Message{ID: 3, Type: "data", StationID: "100996"})   // Station filtered
+	mgr.Enqueue(sse.Message{ID: 4, Type: "data", StationID: "101022"})
+
+	waitFor(t, func() bool { return rc.count() == 2 })
+	time.Sleep(20 * time.Millisecond)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if len(rc.received) != 2 || rc.received[0].ID != 1 || rc.received[1].ID != 4 {
+		t.Errorf("Unexpected deliveries: %+v", rc.received)
+	}
+	if rc.headers[0].Get(HeaderEvent) != "alert" || rc.headers[0].Get(HeaderDelivery) == "" {
+		t.Errorf("Missing delivery headers: %v", rc.headers[0])
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	rc := &receiver{t: t}
+	rc.failures.Store(2)
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	mgr := startManager(t, Config{Endpoints: []Endpoint{{ID: "flaky", URL: server.URL}}})
+	mgr.Enqueue(sse.Message{ID: 1, Type: "data", StationID: "100996"})
+
+	// Stats are updated after the receiver has responded
+	waitFor(t, func() bool { return mgr.GetEndpoints()[0].Delivered == 1 })
+
+	status := mgr.GetEndpoints()[0]
+	if status.Delivered != 1 || status.Retried != 2 || status.Failed != 0 {
+		t.Errorf("Unexpected endpoint stats: %+v", status)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	permanent := httptest.NewServer(&receiver{t: t, status: http.StatusBadRequest})
+	defer permanent.Close()
+	failing := httptest.NewServer(&receiver{t: t, status: http.StatusInternalServerError})
+	defer failing.Close()
+
+	deadLetterFile := filepath.Join(t.TempDir(), "dead_letters.jsonl")
+	mgr := startManager(t, Config{
+		Endpoints: []Endpoint{
+			{ID: "rejecting", URL: permanent.URL},
+			{ID: "down", URL: failing.URL},
+		},
+		DeadLetterFile: deadLetterFile,
+		MaxAttempts:    3,
+	})
+	mgr.Enqueue(sse.Message{ID: 7, Type: "alert", StationID: "100908"})
+
+	waitFor(t, func() bool {
+		entries, _ := mgr.GetDeadLetters(0)
+		return len(entries) == 2
+	})
+
+	entries, err := mgr.GetDeadLetters(10)
+	if err != nil {
+		t.Fatalf("GetDeadLetters() error = %v", err)
+	}
+	attempts := map[string]int{}
+	for _, entry := range entries {
+		attempts[entry.EndpointID] = entry.Attempts
+		if entry.Message.ID != 7 || entry.Error == "" {
+			t.Errorf("Unexpected dead letter: %+v", entry)
+		}
+	}
+	// 4xx is not retried, 5xx is retried up to MaxAttempts
+	if attempts["rejecting"] != 1 || attempts["down"] != 3 {
+		t.Errorf("Unexpected attempts: %v", attempts)
+	}
+
+	// Entries are read back from the log by a new manager
+	reopened, _ := NewManager(Config{DeadLetterFile: deadLetterFile})
+	if entries, _ := reopened.GetDeadLetters(1); len(entries) != 1 {
+		t.Errorf("Expected dead letters from log, got %d", len(entries))
+	}
+}
+
+func TestDeadLetterRotation(t *testing.T) {
+	deadLetterFile := filepath.Join(t.TempDir(), "dead_letters.jsonl")
+	mgr, err := NewManager(Config{
+		Endpoints:         []Endpoint{{ID: "down", URL: "http://localhost:1/hook"}},
+		DeadLetterFile:    deadLetterFile,
+		DeadLetterMaxSize: 600,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	m := mgr.(*manager)
+	for i := range 20 {
+		m.deadLetter(m.workers[0], sse.Message{ID: int64(i + 1), Type: "data"}, 1, http.StatusBadRequest, errors.New("rejected"))
+	}
+
+	// The log never grows much past the limit and keeps one rotated generation
+	var total int64
+	for _, path := range []string{deadLetterFile, deadLetterFile + deadLetterRotatedSuffix} {
+		if info, err := os.Stat(path); err == nil {
+			total += info.Size()
+		}
+	}
+	if _, err := os.Stat(deadLetterFile + deadLetterRotatedSuffix); err != nil {
+		t.Errorf("Expected a rotated log: %v", err)
+	}
+	if total > 2*600 {
+		t.Errorf("Dead-letter logs hold %d bytes, expected at most %d", total, 2*600)
+	}
+
+	// The newest entries come from the current log, topped up from the rotated one
+	entries, err := mgr.GetDeadLetters(4)
+	if err != nil || len(entries) != 4 || entries[0].Message.ID != 20 || entries[3].Message.ID != 17 {
+		t.Errorf("Unexpected newest dead letters: %+v (%v)", entries, err)
+	}
+	if all, _ := mgr.GetDeadLetters(0); len(all) >= 20 || all[0].Message.ID != 20 {
+		t.Errorf("Expected only the retained dead letters, got %d", len(all))
+	}
+}
+
+func TestSSEListener(t *testing.T) {
+	rc := &receiver{t: t}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	mgr := startManager(t, Config{Endpoints: []Endpoint{{ID: "all", URL: server.URL}}})
+	sseMgr := sse.NewManager()
+	sseMgr.AddListener(mgr.Enqueue)
+
+	sseMgr.Broadcast(sse.Message{Type: "trend", StationID: "100996"})
+	waitFor(t, func() bool { return rc.count() == 1 })
+}
+
+func TestNewManagerValidation(t *testing.T) {
+	invalid := [][]Endpoint{
+		{{ID: "", URL: "http://localhost"}},
+		{{ID: "a", URL: "ftp://localhost"}},
+		{{ID: "a", URL: "http://localhost"}, {ID: "a", URL: "http://localhost"}},
+	}
+	for _, endpoints := range invalid {
+		if _, err := NewManager(Config{Endpoints: endpoints}); err == nil {
+			t.Errorf("Expected error for endpoints %+v", endpoints)
+		}
+	}
+}
+
+func TestAdminHandlers(t *testing.T) {
+	mgr, _ := NewManager(Config{Endpoints: []Endpoint{{ID: "chat", URL: "http://localhost:1", Secret: "hidden"}}})
+	mux := http.NewServeMux()
+	RegisterHandlers(mux, mgr, "token")
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/admin/webhooks", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/api/admin/webhooks", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var endpoints []EndpointStatus
+	if err := json.NewDecoder(rec.Body).Decode(&endpoints); err != nil || len(endpoints) != 1 || !endpoints[0].Signed {
+		t.Errorf("Unexpected endpoints response: %+v (%v)", endpoints, err)
+	}
+
+	req = httptest.NewRequest("GET", "/api/admin/webhooks/dead-letters", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "[]\n" {
+		t.Errorf("Expected empty dead letters, got %d %q", rec.Code, rec.Body.String())
+	}
+}
@@ -2,18 +2,29 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"html"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"windz/internal/alerts"
+	"windz/internal/ingest"
+	"windz/internal/mqtt"
 	"windz/internal/observations"
 	"windz/internal/sse"
 	"windz/internal/stations"
+	"windz/internal/store"
+	"windz/internal/units"
+	"windz/internal/webhooks"
 )
 
 // Build metadata - injected at build time
@@ -24,10 +35,33 @@ var (
 )
 
 var (
-	port         = flag.Int("port", 8080, "HTTP server port")
-	stateFile    = flag.String("state-file", "polling_state.json", "Polling state persistence file")
-	windDataFile = flag.String("wind-data-file", "wind_data.json", "Wind data cache persistence file")
-	debug        = flag.Bool("debug", false, "Enable debug logging")
+	port               = flag.Int("port", 8080, "HTTP server port")
+	stateFile          = flag.String("state-file", "polling_state.json", "Polling state persistence file")
+	windDataFile       = flag.String("wind-data-file", "wind_data.json", "Wind data cache persistence file")
+	backgroundInterval = flag.Duration("background-interval", observations.DefaultBackgroundInterval, "Polling interval of stations no SSE client is subscribed to")
+	checkpointInterval = flag.Duration("checkpoint-interval", observations.DefaultCheckpointInterval, "How often polling state and wind data are saved while running")
+	groupsFile         = flag.String("groups-file", "", "Station groups configuration file (JSON)")
+	ingestFile         = flag.String("ingest-stations", "", "Private stations accepting uploads through the ingest API (JSON)")
+	catalogFile        = flag.String("station-catalog", "", "FMI station catalog cache file (enables catalog-wide nearest search)")
+	debug              = flag.Bool("debug", false, "Enable debug logging")
+
+	historyRetention = flag.Duration("history-retention", observations.DefaultHistoryRetention, "In-memory observation history kept per station")
+	storeDir         = flag.String("store-dir", "", "Directory of the durable observation store (disabled when empty)")
+	storeRetention   = flag.Duration("store-retention", 30*24*time.Hour, "How long stored observations are kept (0 keeps everything)")
+	storeSync        = flag.Bool("store-sync", true, "fsync the observation store after every append (false leaves flushing to the OS until a segment is sealed or the server stops)")
+	backfillLookback = flag.Duration("backfill-lookback", observations.DefaultBackfillLookback, "How far back missing observations are fetched from FMI (0 disables backfill)")
+	backfillRate     = flag.Int("backfill-rate", observations.DefaultBackfillRate, "Backfill FMI requests per minute")
+	trendWindow      = flag.Duration("trend-window", observations.DefaultTrendWindow, "Window of the wind speed and direction trend")
+	alertRulesFile   = flag.String("alert-rules", "alert_rules.json", "Alert rules file (also written by the alerts API)")
+	alertStateFile   = flag.String("alert-state-file", "alert_state.json", "Alert state persistence file")
+	webhooksFile     = flag.String("webhooks-file", "", "Webhook endpoints configuration file (JSON)")
+	deadLetterFile   = flag.String("webhook-dead-letters", "webhook_dead_letters.jsonl", "Log of webhook deliveries that failed permanently")
+	adminToken       = flag.String("admin-token", "", "Bearer token required by admin endpoints (open when empty)")
+	mqttBroker       = flag.String("mqtt-broker", "", "MQTT broker address such as tcp://localhost:1883 (disabled when empty)")
+	mqttUsername     = flag.String("mqtt-username", "", "MQTT user name")
+	mqttPassword     = flag.String("mqtt-password", "", "MQTT password")
+	mqttTopicPrefix  = flag.String("mqtt-topic-prefix", mqtt.DefaultTopicPrefix, "MQTT topic prefix")
+	mqttDiscovery    = flag.String("mqtt-discovery-prefix", mqtt.DefaultDiscoveryPrefix, "Home Assistant discovery prefix (\"-\" disables discovery)")
 )
 
 // Finnish timezone (init at startup)
@@ -49,16 +83,115 @@ func main() {
 	// Initialize managers
 	sseManager := sse.NewManager()
 	stationManager := stations.NewManager()
-	observationManager := observations.NewManager(
+
+	// Private stations are registered before groups, which may refer to them
+	var ingestStations []ingest.Station
+	if *ingestFile != "" {
+		ingestStations, err = ingest.LoadStations(*ingestFile)
+		if err != nil {
+			log.Fatalf("Error loading ingest stations: %v", err)
+		}
+	}
+	ingestManager, err := ingest.NewManager(stationManager, ingest.Config{Stations: ingestStations})
+	if err != nil {
+		log.Fatalf("Error configuring ingest stations: %v", err)
+	}
+
+	if *groupsFile != "" {
+		if err := stationManager.LoadGroups(*groupsFile); err != nil {
+			log.Fatalf("Error loading station groups: %v", err)
+		}
+	}
+	var observationStore store.Store
+	if *storeDir != "" {
+		observationStore, err = store.Open(*storeDir, store.Options{SyncWrites: *storeSync, Retention: *storeRetention})
+		if err != nil {
+			log.Fatalf("Error opening observation store: %v", err)
+		}
+		stats := observationStore.Stats()
+		if stats.CorruptSkipped > 0 || stats.TruncatedBytes > 0 {
+			log.Printf("Observation store recovered: %d damaged regions skipped, %d bytes truncated", stats.CorruptSkipped, stats.TruncatedBytes)
+		}
+	}
+	observationManager := observations.NewManagerWithConfig(
 		stationManager,
 		sseManager,
-		*stateFile,
-		*windDataFile,
-		*debug,
+		observations.Config{
+			StateFile:          *stateFile,
+			WindDataFile:       *windDataFile,
+			Debug:              *debug,
+			HistoryRetention:   *historyRetention,
+			Store:              observationStore,
+			StoreRetention:     *storeRetention,
+			BackfillLookback:   *backfillLookback,
+			BackfillRate:       *backfillRate,
+			TrendWindow:        *trendWindow,
+			CheckpointInterval: *checkpointInterval,
+			BackgroundInterval: *backgroundInterval,
+			Sources:            map[string]observations.Source{ingest.SourceName: ingestManager},
+		},
 	)
 
+	// Uploaded readings are polled from the ingest source right away, or once the
+	// minimum gap since the last poll has passed
+	ingestManager.SetReceiveCallback(func(stationID string) {
+		observationManager.Refresh(stationID)
+	})
+
+	alertManager := alerts.NewManager(stationManager, sseManager, observationManager, alerts.Config{
+		RulesFile: *alertRulesFile,
+		StateFile: *alertStateFile,
+	})
+	observationManager.AddObservationListener(alertManager.Evaluate)
+	observationManager.AddFreshnessListener(alertManager.EvaluateFreshness)
+
+	var webhookEndpoints []webhooks.Endpoint
+	if *webhooksFile != "" {
+		webhookEndpoints, err = webhooks.LoadEndpoints(*webhooksFile)
+		if err != nil {
+			log.Fatalf("Error loading webhooks: %v", err)
+		}
+	}
+	webhookManager, err := webhooks.NewManager(webhooks.Config{
+		Endpoints:      webhookEndpoints,
+		DeadLetterFile: *deadLetterFile,
+	})
+	if err != nil {
+		log.Fatalf("Error configuring webhooks: %v", err)
+	}
+	sseManager.AddListener(webhookManager.Enqueue)
+
+	var mqttManager mqtt.Manager
+	if *mqttBroker != "" {
+		mqttManager, err = mqtt.NewManager(mqtt.Config{
+			Broker:          *mqttBroker,
+			Username:        *mqttUsername,
+			Password:        *mqttPassword,
+			TopicPrefix:     *mqttTopicPrefix,
+			DiscoveryPrefix: *mqttDiscovery,
+			Stations:        stationManager,
+		})
+		if err != nil {
+			log.Fatalf("Error configuring MQTT: %v", err)
+		}
+		sseManager.AddListener(mqttManager.Enqueue)
+	}
+
+	// Load the FMI station catalog in the background for catalog-wide lookups
+	if *catalogFile != "" {
+		go func() {
+			catalog, err := stations.LoadCatalog(&http.Client{Timeout: 60 * time.Second}, *catalogFile, 7*24*time.Hour)
+			if err != nil {
+				log.Printf("Error loading station catalog: %v", err)
+				return
+			}
+			stationManager.SetCatalog(catalog)
+			log.Printf("Loaded FMI station catalog with %d stations", len(catalog))
+		}()
+	}
+
 	allStations := stationManager.GetAllStations()
-	log.Printf("Monitoring %d Finnish weather stations", len(allStations))
+	log.Printf("Monitoring %d Finnish weather stations in %d groups", len(allStations), len(stationManager.GetAllGroups()))
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -69,11 +202,14 @@ func main() {
 
 	// Legacy handlers (for backward compatibility with existing HTML)
 	mux.HandleFunc("/", handleIndex(stationManager, observationManager))
-	mux.HandleFunc("/health", handleHealth(stationManager, sseManager))
+	mux.HandleFunc("/health", handleHealth(stationManager, sseManager, observationManager))
 	mux.HandleFunc("/metrics", handleMetrics(observationManager))
 
 	// Set up callback for SSE client connections to send initial data
 	sseManager.SetClientConnectCallback(func(clientID string) {
+		// Polling intervals depend on whether clients are connected
+		observationManager.Wake()
+
 		// Get all current observations
 		allObservations := observationManager.GetAllLatestObservations()
 
@@ -88,23 +224,56 @@ func main() {
 			sseManager.SendToClient(clientID, dataMsg)
 		}
 
+		// Let the dashboard show an ongoing FMI outage right away
+		if upstream := observationManager.UpstreamStatus(); upstream.State != observations.BreakerClosed {
+			sseManager.SendToClient(clientID, sse.Message{
+				ID:   upstream.Since.Unix(),
+				Type: "upstream",
+				Data: upstream,
+			})
+		}
+
 		log.Printf("Sent %d initial observations to SSE client %s", len(allObservations), clientID)
 	})
 
+	// Let SSE clients subscribe to a station group (/events?group=id)
+	sseManager.SetGroupResolver(func(groupID string) ([]string, bool) {
+		group, exists := stationManager.GetGroup(groupID)
+		if !exists {
+			return nil, false
+		}
+		return group.StationIDs, true
+	})
+
 	// Register module handlers
 	sse.RegisterHandlers(mux, sseManager)
 	stations.RegisterHandlers(mux, stationManager)
-	observations.RegisterHandlers(mux, observationManager)
+	observations.RegisterHandlers(mux, observationManager, stationManager, *adminToken)
+	alerts.RegisterHandlers(mux, alertManager, *adminToken)
+	webhooks.RegisterHandlers(mux, webhookManager, *adminToken)
+	ingest.RegisterHandlers(mux, ingestManager)
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", *port),
 		Handler: mux,
 	}
 
+	if err := alertManager.Start(ctx); err != nil {
+		log.Fatalf("Error starting alert manager: %v", err)
+	}
+	if err := webhookManager.Start(ctx); err != nil {
+		log.Fatalf("Error starting webhook delivery: %v", err)
+	}
+	if mqttManager != nil {
+		if err := mqttManager.Start(ctx); err != nil {
+			log.Fatalf("Error starting MQTT publisher: %v", err)
+		}
+	}
+
 	// Start observation polling
 	go func() {
 		if err := observationManager.Start(ctx); err != nil {
-			log.Printf("Error starting observation manager: %v", err)
+			log.Fatalf("Error starting observation manager: %v", err)
 		}
 	}()
 
@@ -120,6 +289,22 @@ func main() {
 		if err := observationManager.Stop(); err != nil {
 			log.Printf("Error stopping observation manager: %v", err)
 		}
+		if err := alertManager.Stop(); err != nil {
+			log.Printf("Error stopping alert manager: %v", err)
+		}
+		if err := webhookManager.Stop(); err != nil {
+			log.Printf("Error stopping webhook delivery: %v", err)
+		}
+		if mqttManager != nil {
+			if err := mqttManager.Stop(); err != nil {
+				log.Printf("Error stopping MQTT publisher: %v", err)
+			}
+		}
+		if observationStore != nil {
+			if err := observationStore.Close(); err != nil {
+				log.Printf("Error closing observation store: %v", err)
+			}
+		}
 
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer shutdownCancel()
@@ -139,8 +324,30 @@ func handleIndex(stationMgr stations.Manager, obsMgr observations.Manager) http.
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
-		// Get all stations and their data
+		// Get the selected group's stations (or all stations) and their data
 		allStations := stationMgr.GetAllStations()
+		groupID := r.URL.Query().Get("group")
+		title := "All stations"
+		if groupID != "" {
+			groupStations, exists := stationMgr.GetGroupStations(groupID)
+			if !exists {
+				http.Error(w, "Group not found", http.StatusNotFound)
+				return
+			}
+			group, _ := stationMgr.GetGroup(groupID)
+			allStations = groupStations
+			title = group.Name
+		}
+
+		// Render in the requested units (?units=kn&lang=fi), m/s and English otherwise
+		conv, err := units.FromQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if conv.IsDefault() {
+			conv = units.Convention{Unit: units.MetersPerSecond, Lang: units.English}
+		}
 		allObservations := obsMgr.GetAllLatestObservations()
 
 		// Create template data structure similar to original
@@ -158,6 +365,7 @@ func handleIndex(stationMgr stations.Manager, obsMgr observations.Manager) http.
 			var pollingState *observations.PollingState
 
 			if obs, exists := allObservations[station.ID]; exists {
+				obs = obs.InUnits(conv)
 				windData = &obs
 			}
 
@@ -175,8 +383,12 @@ func handleIndex(stationMgr stations.Manager, obsMgr observations.Manager) http.
 		}
 
 		templateData := struct {
+			Title    string
+			Groups   []stations.Group
 			Stations []StationRowData
 		}{
+			Title:    title,
+			Groups:   stationMgr.GetAllGroups(),
 			Stations: stationRows,
 		}
 
@@ -191,35 +403,85 @@ func handleIndex(stationMgr stations.Manager, obsMgr observations.Manager) http.
         .station { margin: 10px 0; padding: 10px; border: 1px solid #ccc; }
         .data { color: green; }
         .no-data { color: #999; }
+        .alert { margin: 5px 0; padding: 5px 10px; background: #fff3cd; border: 1px solid #e0c060; }
+        .alert.cleared { background: #eef; border-color: #ccd; }
+        .station.stale, .station.offline { opacity: 0.5; }
+        .station.offline .data { color: #999; }
+        .upstream { margin: 10px 0; padding: 5px 10px; background: #f8d7da; border: 1px solid #d9a0a6; color: #721c24; }
     </style>
 </head>
 <body>
+    <h2>%s</h2>
+    <p class="groups"><a href="%s">All stations</a>`, html.EscapeString(templateData.Title), html.EscapeString(dashboardURL("", conv)))
+
+		upstreamHidden := " hidden"
+		if obsMgr.UpstreamStatus().State != observations.BreakerClosed {
+			upstreamHidden = ""
+		}
+
+		for _, group := range templateData.Groups {
+			fmt.Fprintf(w, ` | <a href="%s">%s</a>`, html.EscapeString(dashboardURL(group.ID, conv)), html.EscapeString(group.Name))
+		}
+
+		fmt.Fprint(w, `</p>
+    <p class="units">`)
+		for i, unit := range []units.Unit{units.MetersPerSecond, units.Knots, units.KilometersPerHour, units.MilesPerHour, units.Beaufort} {
+			if i > 0 {
+				fmt.Fprint(w, " | ")
+			}
+			fmt.Fprintf(w, `<a href="%s">%s</a>`, html.EscapeString(dashboardURL(groupID, units.Convention{Unit: unit, Lang: conv.Lang})), unit.Symbol())
+		}
+		for _, lang := range []units.Lang{units.English, units.Finnish, units.Swedish} {
+			fmt.Fprintf(w, ` | <a href="%s">%s</a>`, html.EscapeString(dashboardURL(groupID, units.Convention{Unit: conv.Unit, Lang: lang})), lang)
+		}
+
+		fmt.Fprintf(w, `</p>
     <p>%d stations monitored</p>
-    <div class="stations">`, len(templateData.Stations))
+    <div id="upstream" class="upstream"%s>FMI unavailable, showing the latest data received</div>
+    <div id="alerts" class="alerts"></div>
+    <div class="stations">`, len(templateData.Stations), upstreamHidden)
 
 		for _, station := range templateData.Stations {
 			status := "no-data"
 			dataText := "No data"
+			freshness := observations.FreshnessOffline
 			if station.WindData != nil {
+				freshness = station.WindData.Freshness
 				status = "data"
-				dataText = fmt.Sprintf("%.1f m/s, gust %.1f m/s, %.0f° %s",
-					station.WindData.WindSpeed,
-					station.WindData.WindGust,
+				dataText = fmt.Sprintf("%s, gust %s (60 min max %s), %.0f° %s %s",
+					conv.Unit.Format(station.WindData.WindSpeed),
+					conv.Unit.Format(station.WindData.WindGust),
+					conv.Unit.Format(station.WindData.MaxGust60m),
 					station.WindData.WindDirection,
-					station.WindData.UpdatedAt.In(helsinkiLoc).Format("15:04"))
+					station.WindData.Compass,
+					station.WindData.UpdatedAt.In(helsinkiLoc).Format("15:04")) + trendLabel(station.WindData.Trend, conv.Unit)
 			}
 
 			fmt.Fprintf(w, `
-        <div class="station">
+        <div class="station %s">
             <strong>%s</strong> - %s<br>
             <span class="%s">%s</span>
-        </div>`, station.Name, station.Region, status, dataText)
+        </div>`, freshness, station.Name, station.Region, status, dataText)
 		}
 
-		fmt.Fprint(w, `
+		// The event stream delivers data in the same units
+		eventsQuery := url.Values{"units": {string(conv.Unit)}, "lang": {string(conv.Lang)}}
+		if groupID != "" {
+			eventsQuery.Set("group", groupID)
+		}
+		eventsURL := "/events?" + eventsQuery.Encode()
+
+		fmt.Fprintf(w, `
     </div>
-    <p><a href="/api/stations">View Stations API</a> | <a href="/api/observations/latest">View Latest Observations</a></p>
+    <p><a href="/api/stations">View Stations API</a> | <a href="/api/observations/latest">View Latest Observations</a> | <a href="/api/groups">View Groups</a> | <a href="/api/alerts">View Alerts</a></p>
     <script>
+		const eventsURL = %q;
+		const speedUnit = %q;
+		const speedDecimals = %d;
+		const rateUnit = %q;
+`, eventsURL, conv.Unit.Symbol(), conv.Unit.Decimals(), conv.Unit.RateSymbol())
+
+		fmt.Fprint(w, `
 		let eventSource = null;
 
 		ensureSSE();
@@ -236,12 +498,12 @@ func handleIndex(stationMgr stations.Manager, obsMgr observations.Manager) http.
 
         function newSSE() {
 
-			let eventSource = new EventSource('/events');
+			let eventSource = new EventSource(eventsURL);
 
 			// if closed, recreate
 			if(eventSource.readyState == 2) {
 				eventSource=null;
-				eventSource = new EventSource('/events');
+				eventSource = new EventSource(eventsURL);
 			}
 
             eventSource.onopen = function() {
@@ -264,6 +526,40 @@ func handleIndex(stationMgr stations.Manager, obsMgr observations.Manager) http.
                 }
             });
 
+            eventSource.addEventListener('freshness', function(event) {
+                try {
+                    const change = JSON.parse(event.data);
+                    setFreshness(change.station_name, change.freshness);
+                } catch (e) {
+                    console.error('Error parsing freshness:', e);
+                }
+            });
+
+            eventSource.addEventListener('upstream', function(event) {
+                try {
+                    const upstream = JSON.parse(event.data);
+                    document.getElementById('upstream').hidden = upstream.state === 'closed';
+                } catch (e) {
+                    console.error('Error parsing upstream status:', e);
+                }
+            });
+
+            eventSource.addEventListener('alert', function(event) {
+                try {
+                    const alert = JSON.parse(event.data);
+                    const div = document.createElement('div');
+                    div.className = alert.type === 'cleared' ? 'alert cleared' : 'alert';
+                    div.textContent = new Date(alert.timestamp).toLocaleTimeString('fi-FI', {hour: '2-digit', minute: '2-digit'}) + ' ' + alert.message;
+                    const container = document.getElementById('alerts');
+                    container.prepend(div);
+                    while (container.children.length > 5) {
+                        container.lastChild.remove();
+                    }
+                } catch (e) {
+                    console.error('Error parsing alert:', e);
+                }
+            });
+
             eventSource.onerror = function() {
                 console.log('SSE connection error');
 				updateConnectionStatus(getState())
@@ -273,6 +569,29 @@ func handleIndex(stationMgr stations.Manager, obsMgr observations.Manager) http.
         }
 
 
+        function trendLabel(trend) {
+            if (!trend) {
+                return '';
+            }
+            const parts = [];
+            if (trend.speed !== 'steady') {
+                parts.push(trend.speed + ' ' + trend.speed_rate.toFixed(1) + ' ' + rateUnit);
+            }
+            if (trend.direction !== 'steady') {
+                parts.push(trend.direction + ' ' + Math.abs(trend.direction_rate).toFixed(0) + '°/h');
+            }
+            return parts.length ? ' (' + parts.join(', ') + ')' : '';
+        }
+
+        function setFreshness(stationName, freshness) {
+            // Grey out stations whose data is stale or offline
+            document.querySelectorAll('.station').forEach(div => {
+                if (div.querySelector('strong').textContent === stationName) {
+                    div.className = 'station ' + freshness;
+                }
+            });
+        }
+
         function updateStationData(data) {
             // Simple DOM update for the station data
             const stationDivs = document.querySelectorAll('.station');
@@ -280,15 +599,19 @@ func handleIndex(stationMgr stations.Manager, obsMgr observations.Manager) http.
                 const stationName = div.querySelector('strong').textContent;
                 if (data.station_name === stationName) {
                     const dataSpan = div.querySelector('span');
-                    const windSpeed = data.wind_speed >= 0 ? data.wind_speed.toFixed(1) : '-';
-                    const windGust = data.wind_gust >= 0 ? data.wind_gust.toFixed(1) : '-';
+                    const windSpeed = data.wind_speed >= 0 ? data.wind_speed.toFixed(speedDecimals) : '-';
+                    const windGust = data.wind_gust >= 0 ? data.wind_gust.toFixed(speedDecimals) : '-';
+                    const maxGust = data.max_gust_60m >= 0 ? data.max_gust_60m.toFixed(speedDecimals) : windGust;
                     const windDirection = data.wind_direction>= 0 ? data.wind_direction.toFixed(0) : '-';
                     const time = new Date(data.updated_at).toLocaleTimeString('fi-FI', {hour: '2-digit', minute: '2-digit'});
                     
-                    dataSpan.textContent = windSpeed + ' m/s, gust ' + windGust + ' m/s, ' + windDirection + '° ' + time;
+                    dataSpan.textContent = windSpeed + ' ' + speedUnit + ', gust ' + windGust + ' ' + speedUnit + ' (60 min max ' + maxGust + ' ' + speedUnit + '), ' + windDirection + '° ' + (data.compass || '') + ' ' + time + trendLabel(data.trend);
                     dataSpan.className = 'data';
                 }
             });
+            if (data.freshness) {
+                setFreshness(data.station_name, data.freshness);
+            }
         }
 
 		function getState() {
@@ -354,23 +677,37 @@ func handleIndex(stationMgr stations.Manager, obsMgr observations.Manager) http.
 	}
 }
 
-func handleHealth(stationMgr stations.Manager, sseMgr sse.Manager) http.HandlerFunc {
+func handleHealth(stationMgr stations.Manager, sseMgr sse.Manager, obsMgr observations.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
 		allStations := stationMgr.GetAllStations()
 		clientCount := sseMgr.ClientCount()
 
+		// The service itself stays up while FMI is unavailable, so report it as degraded
+		upstream := obsMgr.UpstreamStatus()
+		status := "ok"
+		if upstream.State != observations.BreakerClosed {
+			status = "degraded"
+		}
+		upstreamJSON, err := json.Marshal(upstream)
+		if err != nil {
+			log.Printf("Error encoding upstream status: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
 		fmt.Fprintf(w, `{
-    "status": "ok",
+    "status": "%s",
     "stations": %d,
     "sse_clients": %d,
+    "upstream": %s,
     "build": {
         "version": "%s",
         "commit": "%s",
         "date": "%s"
     }
-}`, len(allStations), clientCount, BuildVersion, BuildCommit, BuildDate)
+}`, status, len(allStations), clientCount, upstreamJSON, BuildVersion, BuildCommit, BuildDate)
 	}
 }
 
@@ -381,21 +718,56 @@ func handleMetrics(obsMgr observations.Manager) http.HandlerFunc {
 		allObservations := obsMgr.GetAllLatestObservations()
 
 		active, withData := 0, 0
+		freshness := make(map[observations.Freshness]int)
 		for _, obs := range allObservations {
 			active++
 			if obs.WindSpeed >= 0 {
 				withData++
 			}
+			freshness[obs.Freshness]++
 		}
 
 		fmt.Fprintf(w, `{
     "total_stations": %d,
     "stations_with_data": %d,
+    "stations_fresh": %d,
+    "stations_late": %d,
+    "stations_stale": %d,
+    "stations_offline": %d,
     "build": {
         "version": "%s",
         "commit": "%s",
         "date": "%s"
     }
-}`, active, withData, BuildVersion, BuildCommit, BuildDate)
+}`, active, withData, freshness[observations.FreshnessFresh], freshness[observations.FreshnessLate],
+			freshness[observations.FreshnessStale], freshness[observations.FreshnessOffline], BuildVersion, BuildCommit, BuildDate)
+	}
+}
+
+// trendLabel formats a non-steady wind trend for the dashboard, with rates already in unit
+func trendLabel(trend *observations.Trend, unit units.Unit) string {
+	if trend == nil {
+		return ""
+	}
+
+	var parts []string
+	if trend.Speed != observations.TrendSteady {
+		parts = append(parts, fmt.Sprintf("%s %.1f %s", trend.Speed, trend.SpeedRate, unit.RateSymbol()))
+	}
+	if trend.Direction != observations.TrendSteady {
+		parts = append(parts, fmt.Sprintf("%s %.0f°/h", trend.Direction, math.Abs(trend.DirectionRate)))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// dashboardURL links to the dashboard of a group (all stations when empty) in a convention
+func dashboardURL(groupID string, conv units.Convention) string {
+	query := url.Values{"units": {string(conv.Unit)}, "lang": {string(conv.Lang)}}
+	if groupID != "" {
+		query.Set("group", groupID)
 	}
+	return "/?" + query.Encode()
 }
@@ -3,6 +3,7 @@ package stations
 
 import (
 	"fmt"
+	"sort"
 	"time"
 )
 
@@ -11,6 +12,7 @@ type Station struct {
 	ID           string            `json:"id"`
 	FMISID       string            `json:"fmisid"`
 	Name         string            `json:"name"`
+	Names        map[string]string `json:"names,omitempty"` // Names by language code (fi, sv, ...)
 	Location     Coordinates       `json:"coordinates"`
 	StartDate    time.Time         `json:"start_date"`
 	EndDate      *time.Time        `json:"end_date,omitempty"`
@@ -106,6 +108,34 @@ func (sc *StationCollection) GetStationByFMISID(fmisID string) *Station {
 	return nil
 }
 
+// AllNames returns the primary name followed by every distinct localized name
+func (s Station) AllNames() []string {
+	result := []string{}
+	seen := make(map[string]bool)
+
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+
+	add(s.Name)
+	for _, lang := range []string{"fi", "sv", "en"} {
+		add(s.Names[lang])
+	}
+	langs := make([]string, 0, len(s.Names))
+	for lang := range s.Names {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		add(s.Names[lang])
+	}
+
+	return result
+}
+
 // FilterByCapabilities returns stations that have all the specified capabilities
 func (sc *StationCollection) FilterByCapabilities(requiredCapabilities []string) []Station {
 	var filtered []Station
@@ -71,6 +71,7 @@ func convertToStationModel(member WFSStationMember) *Station {
 		ID:     member.MonitoringFacility.ID,
 		FMISID: fmisID,
 		Name:   stationName,
+		Names:  extractStationNames(member.MonitoringFacility.Names),
 		Location: Coordinates{
 			Lat: coords[0], // Latitude is first in coordinate pair (FMI uses "Lat Long" order)
 			Lon: coords[1], // Longitude is second in coordinate pair
@@ -127,6 +128,45 @@ func extractStationName(names []GMLName) string {
 	return ""
 }
 
+// extractStationNames collects every human-readable name keyed by language.
+// Names in the FMI name codespace without a language tag are Finnish; identifier
+// codespaces such as geoid and wmo are skipped.
+func extractStationNames(names []GMLName) map[string]string {
+	result := make(map[string]string)
+
+	for _, name := range names {
+		value := strings.TrimSpace(name.Value)
+		if value == "" || isIdentifierCodeSpace(name.CodeSpace) {
+			continue
+		}
+
+		lang := strings.ToLower(strings.TrimSpace(name.Lang))
+		if lang == "" {
+			lang = "fi"
+		}
+
+		if _, exists := result[lang]; !exists {
+			result[lang] = value
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// isIdentifierCodeSpace reports whether a name codespace holds a code rather than a name
+func isIdentifierCodeSpace(codeSpace string) bool {
+	codeSpace = strings.ToLower(codeSpace)
+	for _, code := range []string{"geoid", "wmo", "lpnn", "icao", "fmisid"} {
+		if strings.HasSuffix(codeSpace, "/"+code) {
+			return true
+		}
+	}
+	return false
+}
+
 // extractFMISID extracts the FMIS ID from the identifier
 func extractFMISID(identifier GMLIdentifier) string {
 	// FMIS ID is usually in the identifier field
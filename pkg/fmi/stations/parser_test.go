@@ -321,3 +321,62 @@ func TestBBoxString(t *testing.T) {
 		t.Errorf("BBox.String() = '%s', want '%s'", result, expected)
 	}
 }
+
+func TestExtractStationNames(t *testing.T) {
+	names := []GMLName{
+		{CodeSpace: "http://xml.fmi.fi/namespace/locationcode/name", Value: "Hanko Russarö"},
+		{CodeSpace: "http://xml.fmi.fi/namespace/locationcode/geoid", Value: "-16000123"},
+		{CodeSpace: "http://xml.fmi.fi/namespace/locationcode/name", Lang: "sv", Value: "Hangö Russarö"},
+		{Value: "Hanko Russarö"},
+	}
+
+	result := extractStationNames(names)
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 localized names, got %v", result)
+	}
+	if result["fi"] != "Hanko Russarö" {
+		t.Errorf("Expected Finnish name 'Hanko Russarö', got '%s'", result["fi"])
+	}
+	if result["sv"] != "Hangö Russarö" {
+		t.Errorf("Expected Swedish name 'Hangö Russarö', got '%s'", result["sv"])
+	}
+
+	if extractStationNames([]GMLName{}) != nil {
+		t.Error("Expected nil for no names")
+	}
+}
+
+func TestParseLocalizedNames(t *testing.T) {
+	testXML := `<?xml version="1.0" encoding="UTF-8"?>
+<wfs:FeatureCollection xmlns:wfs="http://www.opengis.net/wfs/2.0"
+                       xmlns:ef="http://inspire.ec.europa.eu/schemas/ef/4.0"
+                       xmlns:gml="http://www.opengis.net/gml/3.2">
+  <wfs:member>
+    <ef:EnvironmentalMonitoringFacility gml:id="station-100996">
+      <gml:identifier codeSpace="http://xml.fmi.fi/namespace/stationcode/fmisid">100996</gml:identifier>
+      <gml:name codeSpace="http://xml.fmi.fi/namespace/locationcode/name">Helsinki Harmaja</gml:name>
+      <gml:name codeSpace="http://xml.fmi.fi/namespace/locationcode/name" xml:lang="sv">Helsingfors Gråhara</gml:name>
+      <ef:representativePoint>
+        <gml:Point>
+          <gml:pos>60.10512 24.97539</gml:pos>
+        </gml:Point>
+      </ef:representativePoint>
+    </ef:EnvironmentalMonitoringFacility>
+  </wfs:member>
+</wfs:FeatureCollection>`
+
+	response, err := NewParser().ParseXML(strings.NewReader(testXML))
+	if err != nil {
+		t.Fatalf("Failed to parse stations XML: %v", err)
+	}
+
+	station := response.Stations[0]
+	if station.Names["sv"] != "Helsingfors Gråhara" {
+		t.Errorf("Expected Swedish name to be kept, got %v", station.Names)
+	}
+
+	allNames := station.AllNames()
+	if len(allNames) != 2 || allNames[0] != "Helsinki Harmaja" || allNames[1] != "Helsingfors Gråhara" {
+		t.Errorf("Unexpected AllNames result: %v", allNames)
+	}
+}
@@ -35,10 +35,11 @@ type GMLIdentifier struct {
 	Value     string   `xml:",chardata"`
 }
 
-// GMLName represents a name element with codeSpace attribute
+// GMLName represents a name element with codeSpace and optional language attributes
 type GMLName struct {
 	XMLName   xml.Name `xml:"name"`
 	CodeSpace string   `xml:"codeSpace,attr"`
+	Lang      string   `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
 	Value     string   `xml:",chardata"`
 }
 